@@ -1,13 +1,20 @@
 package config
 
 const (
+	DB_USER     = "DB_USER"
 	DB_PASSWORD = "DB_PASSWORD"
 	DB_IP       = "DB_IP"
 	DB_PORT     = "DB_PORT"
+	DB_NAME     = "DB_NAME"
 	DB_SSL_MODE = "DB_SSL_MODE"
 	DB_URL      = "DB_URL"
 	RPC_URL     = "RPC_URL"
 
+	DB_MAX_OPEN_CONNS    = "DB_MAX_OPEN_CONNS"
+	DB_MAX_IDLE_CONNS    = "DB_MAX_IDLE_CONNS"
+	DB_CONN_MAX_LIFETIME = "DB_CONN_MAX_LIFETIME"
+	METRICS_ADDR         = "METRICS_ADDR"
+
 	DB_EMBED_PORT         = "DB_EMBED_PORT"
 	DB_EMBED_USERNAME     = "DB_EMBED_USERNAME"
 	DB_EMBED_PASSWORD     = "DB_EMBED_PASSWORD"
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+var (
+	ErrAuditDisabled = errors.New("table audit is not enabled")
+)
+
+// Transaction setting read by the audit trigger as the change actor
+const auditActorSetting = "app.actor"
+
+// Operation recorded in the audit history
+type AuditOperation string
+
+const (
+	// Row existing when its table audit has been enabled
+	AuditSnapshot AuditOperation = "SNAPSHOT"
+	AuditInsert   AuditOperation = "INSERT"
+	AuditUpdate   AuditOperation = "UPDATE"
+	AuditDelete   AuditOperation = "DELETE"
+)
+
+// Change recorded in the audit history, Old is nil for insertions and
+// snapshots and New is nil for deletions
+type AuditEntry struct {
+	Id        int64
+	Table     string
+	RowId     int64
+	Operation AuditOperation
+	Old       json.RawMessage
+	New       json.RawMessage
+	Actor     string
+	ChangedAt time.Time
+}
+
+// Checks that the changes of the tables are recorded into the audit
+// history. The history and the triggers are created by the migrations,
+// see migrations/sql/0004_audit_history.up.sql
+//
+// Parameters:
+//   - db:		the database struct
+//   - tables:	the audited tables
+//
+// Returns:
+//   - error:	ErrAuditDisabled if a table has no audit trigger, error if occured
+func CheckAudit(db Executor, tables ...types.Table) error {
+	return CheckAuditContext(context.Background(), db, tables...)
+}
+
+// Checks that the changes of the tables are recorded into the audit
+// history. The history and the triggers are created by the migrations,
+// see migrations/sql/0004_audit_history.up.sql.
+// The queries are aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - tables:	the audited tables
+//
+// Returns:
+//   - error:	ErrAuditDisabled if a table has no audit trigger, error if occured
+func CheckAuditContext(ctx context.Context, db Executor, tables ...types.Table) error {
+	for _, table := range tables {
+		name := auditTableName(table)
+
+		exists, err := triggerExistsContext(ctx, db, name, name+"_audit")
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrAuditDisabled, name)
+		}
+	}
+
+	return nil
+}
+
+// Sets the actor recorded with the changes of the transaction, it must be
+// called inside WithTx as the setting ends with the transaction:
+//
+//	database.WithTx(db, func(tx database.Executor) error {
+//		if err := database.SetActor(tx, "alice"); err != nil {
+//			return err
+//		}
+//		...
+//	})
+//
+// Parameters:
+//   - tx:		the transaction executor
+//   - actor:	the actor of the changes
+//
+// Returns:
+//   - error:	if an error occured
+func SetActor(tx Executor, actor string) error {
+	return SetActorContext(context.Background(), tx, actor)
+}
+
+// Sets the actor recorded with the changes of the transaction, it must be
+// called inside WithTx as the setting ends with the transaction.
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - tx:		the transaction executor
+//   - actor:	the actor of the changes
+//
+// Returns:
+//   - error:	if an error occured
+func SetActorContext(ctx context.Context, tx Executor, actor string) error {
+	_, err := tx.ExecContext(ctx, "SELECT set_config($1, $2, true)", auditActorSetting, actor)
+	return wrapError(err)
+}
+
+// Returns the recorded changes of a table row, oldest first
+//
+// Parameters:
+//   - db:		the database struct
+//   - table:	the audited table
+//   - rowId:	the row id
+//
+// Returns:
+//   - []AuditEntry:	the changes
+//   - error:			if an error occured
+func AuditHistory(db Executor, table types.Table, rowId int64) ([]AuditEntry, error) {
+	return AuditHistoryContext(context.Background(), db, table, rowId)
+}
+
+// Returns the recorded changes of a table row, oldest first.
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - table:	the audited table
+//   - rowId:	the row id
+//
+// Returns:
+//   - []AuditEntry:	the changes
+//   - error:			if an error occured
+func AuditHistoryContext(ctx context.Context, db Executor, table types.Table, rowId int64) ([]AuditEntry, error) {
+	rows, err := MakeQueryWithResultContext(ctx, db, `SELECT id, table_name, row_id, operation, old_values, new_values, actor, changed_at
+FROM audit_history WHERE table_name = $1 AND row_id = $2 ORDER BY changed_at, id`, auditTableName(table), rowId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var oldValues, newValues []byte
+		if err := rows.Scan(&e.Id, &e.Table, &e.RowId, &e.Operation, &oldValues, &newValues, &e.Actor, &e.ChangedAt); err != nil {
+			return nil, err
+		}
+		if oldValues != nil {
+			e.Old = json.RawMessage(oldValues)
+		}
+		if newValues != nil {
+			e.New = json.RawMessage(newValues)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return entries, rows.Close()
+}
+
+// Reconstructs a table row as it was at a past time from its audit history
+//
+// Parameters:
+//   - db:		the database struct
+//   - rowId:	the row id
+//   - at:		the time
+//
+// Returns:
+//   - T:		the row at the time
+//   - error:	ErrNotFound if the row did not exist or was not audited yet, error if occured
+func AsOf[T types.Table](db Executor, rowId int64, at time.Time) (T, error) {
+	return AsOfContext[T](context.Background(), db, rowId, at)
+}
+
+// Reconstructs a table row as it was at a past time from its audit history.
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - rowId:	the row id
+//   - at:		the time
+//
+// Returns:
+//   - T:		the row at the time
+//   - error:	ErrNotFound if the row did not exist or was not audited yet, error if occured
+func AsOfContext[T types.Table](ctx context.Context, db Executor, rowId int64, at time.Time) (T, error) {
+	var table T
+	name := auditTableName(table)
+
+	return SelectOneContext[T](ctx, db, buildAsOfQuery(name), name, rowId, at)
+}
+
+// Returns the name of a table in the audit history
+func auditTableName(table types.Table) string {
+	return strings.ToLower(reflect.TypeOf(table).Name())
+}
+
+// Returns whether a trigger is defined on a table of the current schema
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - table:	the table name
+//   - trigger:	the trigger name
+//
+// Returns:
+//   - bool:	whether the trigger exists
+//   - error:	if an error occured
+func triggerExistsContext(ctx context.Context, db Executor, table string, trigger string) (bool, error) {
+	rows, err := MakeQueryWithResultContext(ctx, db, `SELECT EXISTS (
+	SELECT 1 FROM pg_trigger
+	WHERE tgrelid = to_regclass($1) AND tgname = $2 AND NOT tgisinternal
+)`, table, trigger)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	var exists bool
+	if rows.Next() {
+		if err := rows.Scan(&exists); err != nil {
+			return false, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, wrapError(err)
+	}
+
+	return exists, rows.Close()
+}
+
+// Builds the query selecting the values of the last change of a row made
+// at or before a time, deleted rows have none
+//
+// Parameters:
+//   - name:	the table name
+//
+// Returns:
+//   - string:	the query, taking the table name, row id and time
+func buildAsOfQuery(name string) string {
+	return fmt.Sprintf(`WITH latest AS (
+	SELECT new_values FROM audit_history
+	WHERE table_name = $1 AND row_id = $2 AND changed_at <= $3
+	ORDER BY changed_at DESC, id DESC LIMIT 1
+)
+SELECT (jsonb_populate_record(NULL::%s, new_values)).* FROM latest WHERE new_values IS NOT NULL`, name)
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+var AUDIT_TABLE_NAME_SAMPLES = []TestInput{
+	{Input: types.Asset{}, Correct: "asset"},
+	{Input: types.Price{}, Correct: "price"},
+	{Input: types.Candle{}, Correct: "candle"},
+}
+
+func TestAuditTableNameFunc(t *testing.T) {
+	for _, s := range AUDIT_TABLE_NAME_SAMPLES {
+		if name := auditTableName(s.Input.(types.Table)); name != s.Correct {
+			t.Errorf("wrong audit table name, wanted %s, given %s", s.Correct, name)
+		}
+	}
+}
+
+func TestBuildAuditQueriesFunc(t *testing.T) {
+	query := buildAsOfQuery("asset")
+	if !strings.Contains(query, "jsonb_populate_record(NULL::asset, new_values)") {
+		t.Errorf("as of query does not populate the asset:\n%s", query)
+	}
+}
+
+func TestAuditFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	if _, err := CreateTable(db, types.Asset{}); err != nil {
+		t.Fatalf("error creating the table: %v", err)
+	}
+
+	// Assets existing before the audit are recorded as snapshots
+	if _, err := InsertEntry(db, TX_ASSETS[0]); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+	if err := CheckAudit(db, types.Asset{}); !errors.Is(err, ErrAuditDisabled) {
+		t.Errorf("audit enabled before the migration: %v", err)
+	}
+	applyMigrations(t, db, 4)
+	if err := CheckAudit(db, types.Asset{}); err != nil {
+		t.Fatalf("audit not enabled by the migration: %v", err)
+	}
+	snapshotAt := time.Now()
+
+	err := WithTx(db, func(tx Executor) error {
+		if err := SetActor(tx, "alice"); err != nil {
+			return err
+		}
+		_, err := MakeQuery(tx, "UPDATE Asset SET decimals = 8, source = 'Kraken' WHERE id = 1")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("error updating the asset: %v", err)
+	}
+	updatedAt := time.Now()
+
+	// Unchanged rows are not recorded
+	if _, err := MakeQuery(db, "UPDATE Asset SET decimals = 8 WHERE id = 1"); err != nil {
+		t.Fatalf("error updating the asset: %v", err)
+	}
+
+	if _, err := MakeQuery(db, "DELETE FROM Asset WHERE id = 1"); err != nil {
+		t.Fatalf("error deleting the asset: %v", err)
+	}
+
+	history, err := AuditHistory(db, types.Asset{}, 1)
+	if err != nil {
+		t.Fatalf("error selecting the history: %v", err)
+	}
+	operations := make([]AuditOperation, len(history))
+	for i, e := range history {
+		operations[i] = e.Operation
+	}
+	if len(history) != 3 || operations[0] != AuditSnapshot || operations[1] != AuditUpdate || operations[2] != AuditDelete {
+		t.Fatalf("wrong history: %v", operations)
+	}
+	if history[1].Actor != "alice" || history[1].Old == nil || history[1].New == nil {
+		t.Errorf("wrong update entry: %+v", history[1])
+	}
+	if history[2].Actor == "" || history[2].Actor == "alice" || history[2].New != nil {
+		t.Errorf("wrong delete entry: %+v", history[2])
+	}
+
+	// Reconstruct the asset at each time
+	asset, err := AsOf[types.Asset](db, 1, snapshotAt)
+	if err != nil || asset.Decimals != 18 || asset.Source != "Binance" {
+		t.Errorf("wrong asset at the snapshot: %+v, %v", asset, err)
+	}
+
+	asset, err = AsOf[types.Asset](db, 1, updatedAt)
+	if err != nil || asset.Decimals != 8 || asset.Source != "Kraken" || asset.Ticker != "BTC" {
+		t.Errorf("wrong asset after the update: %+v, %v", asset, err)
+	}
+
+	if _, err := AsOf[types.Asset](db, 1, time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted asset should not be found, given: %v", err)
+	}
+	if _, err := AsOf[types.Asset](db, 1, snapshotAt.Add(-time.Hour)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("asset before the audit should not be found, given: %v", err)
+	}
+}
+
+// Applies the service migrations of the versions, the triggers and views
+// are only defined by the migration files
+func applyMigrations(t *testing.T, db Executor, versions ...int64) {
+	t.Helper()
+
+	migrations, err := LoadSQLMigrations(os.DirFS("../migrations"), "sql")
+	if err != nil {
+		t.Fatalf("error loading the migrations: %v", err)
+	}
+
+	for _, version := range versions {
+		i := slices.IndexFunc(migrations, func(m Migration) bool { return m.Version == version })
+		if i < 0 {
+			t.Fatalf("unknown migration %d", version)
+		}
+
+		if err := migrations[i].Up(context.Background(), db); err != nil {
+			t.Fatalf("error applying the migration %d: %v", version, err)
+		}
+	}
+}
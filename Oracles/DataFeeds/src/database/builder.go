@@ -0,0 +1,349 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+var (
+	ErrInvalidCondition = errors.New("invalid where condition")
+	ErrDeleteClause     = errors.New("delete queries only support where conditions")
+	ErrNoConditions     = errors.New("no conditions to match the rows")
+)
+
+// Ordering of a column
+type orderTerm struct {
+	column types.ColumnRef
+	desc   bool
+}
+
+// Query builder of a table, values are bound as $1..$n placeholders.
+// Conditions added with Where are joined with AND, use types.AnyOf to
+// join them with OR:
+//
+//	query, args, err := NewQueryBuilder(types.Price{}).
+//		Where(
+//			types.Where(types.PriceAssetId, types.In, 1, 2),
+//			types.AnyOf(
+//				types.Where(types.PricePrice, types.Less, 10),
+//				types.Where(types.PricePrice, types.Greater, 100),
+//			),
+//		).
+//		OrderBy(types.PriceTimestamp, true).
+//		Limit(10).
+//		Build()
+type QueryBuilder struct {
+	table   reflect.Type
+	columns []types.ColumnRef
+	where   []types.WhereCondition
+	groupBy []types.ColumnRef
+	orderBy []orderTerm
+	limit   int
+	offset  int
+}
+
+// Returns a query builder of the table
+//
+// Parameters:
+//   - table:	the table struct
+//
+// Returns:
+//   - *QueryBuilder:	the query builder
+func NewQueryBuilder(table any) *QueryBuilder {
+	return &QueryBuilder{
+		table:  reflect.TypeOf(table),
+		limit:  -1,
+		offset: -1,
+	}
+}
+
+// Sets the selected columns, all columns are selected by default
+func (qb *QueryBuilder) Select(columns ...types.ColumnRef) *QueryBuilder {
+	qb.columns = append(qb.columns, columns...)
+	return qb
+}
+
+// Adds conditions joined with AND
+func (qb *QueryBuilder) Where(conditions ...types.WhereCondition) *QueryBuilder {
+	qb.where = append(qb.where, conditions...)
+	return qb
+}
+
+// Adds grouping columns
+func (qb *QueryBuilder) GroupBy(columns ...types.ColumnRef) *QueryBuilder {
+	qb.groupBy = append(qb.groupBy, columns...)
+	return qb
+}
+
+// Adds an ordering column, the first one added has the highest priority
+func (qb *QueryBuilder) OrderBy(column types.ColumnRef, desc bool) *QueryBuilder {
+	qb.orderBy = append(qb.orderBy, orderTerm{column: column, desc: desc})
+	return qb
+}
+
+// Sets the maximum number of rows, negative for no limit
+func (qb *QueryBuilder) Limit(limit int) *QueryBuilder {
+	qb.limit = limit
+	return qb
+}
+
+// Sets the number of skipped rows, negative for no offset
+func (qb *QueryBuilder) Offset(offset int) *QueryBuilder {
+	qb.offset = offset
+	return qb
+}
+
+// Builds the selection query
+//
+// Returns:
+//   - string:	the query
+//   - []any:	the values bound to the query placeholders
+//   - error:	error if occured
+func (qb *QueryBuilder) Build() (string, []any, error) {
+	if qb.table == nil || !utils.ValidateStruct(qb.table) {
+		return "", nil, fmt.Errorf("table is not a struct")
+	}
+
+	var qa queryArgs
+	var builder strings.Builder
+
+	builder.WriteString("SELECT ")
+	if len(qb.columns) == 0 {
+		builder.WriteString("*")
+	} else if err := qb.writeColumns(&builder, qb.columns); err != nil {
+		return "", nil, err
+	}
+	builder.WriteString(" FROM ")
+	builder.WriteString(utils.BaseTypeName(qb.table))
+
+	if err := qb.writeWhere(&builder, qa.parameter); err != nil {
+		return "", nil, err
+	}
+
+	if len(qb.groupBy) > 0 {
+		builder.WriteString("\nGROUP BY ")
+		if err := qb.writeColumns(&builder, qb.groupBy); err != nil {
+			return "", nil, err
+		}
+	}
+
+	for i, o := range qb.orderBy {
+		_, name, err := resolveColumn(qb.table, o.column)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if i == 0 {
+			builder.WriteString("\nORDER BY ")
+		} else {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(name)
+		if o.desc {
+			builder.WriteString(" DESC")
+		} else {
+			builder.WriteString(" ASC")
+		}
+	}
+
+	if qb.limit >= 0 {
+		builder.WriteString(fmt.Sprintf("\nLIMIT %d", qb.limit))
+	}
+	if qb.offset >= 0 {
+		builder.WriteString(fmt.Sprintf("\nOFFSET %d", qb.offset))
+	}
+
+	return builder.String(), qa.values, nil
+}
+
+// Builds the deletion query of the rows matching the conditions
+//
+// Returns:
+//   - string:	the query
+//   - []any:	the values bound to the query placeholders
+//   - error:	error if occured
+func (qb *QueryBuilder) BuildDelete() (string, []any, error) {
+	if qb.table == nil || !utils.ValidateStruct(qb.table) {
+		return "", nil, ErrNotValidTable
+	}
+	if len(qb.columns) > 0 || len(qb.groupBy) > 0 || len(qb.orderBy) > 0 || qb.limit >= 0 || qb.offset >= 0 {
+		return "", nil, ErrDeleteClause
+	}
+	if len(qb.where) == 0 {
+		return "", nil, ErrNoConditions
+	}
+
+	var qa queryArgs
+	var builder strings.Builder
+
+	builder.WriteString("DELETE FROM ")
+	builder.WriteString(utils.BaseTypeName(qb.table))
+
+	if err := qb.writeWhere(&builder, qa.parameter); err != nil {
+		return "", nil, err
+	}
+
+	return builder.String(), qa.values, nil
+}
+
+// Writes the comma separated column names
+func (qb *QueryBuilder) writeColumns(builder *strings.Builder, columns []types.ColumnRef) error {
+	for i, c := range columns {
+		_, name, err := resolveColumn(qb.table, c)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(name)
+	}
+
+	return nil
+}
+
+// Writes the WHERE clause, if any condition has been added
+func (qb *QueryBuilder) writeWhere(builder *strings.Builder, format valueFormatter) error {
+	if len(qb.where) == 0 {
+		return nil
+	}
+
+	condition, err := buildCondition(qb.table, types.AllOf(qb.where...), format, true)
+	if err != nil {
+		return err
+	}
+
+	builder.WriteString("\nWHERE ")
+	builder.WriteString(condition)
+	return nil
+}
+
+// Builds the condition, nested groups are wrapped in parentheses
+//
+// Parameters:
+//   - tt:		the reflect table type
+//   - c:		the condition
+//   - format:	the formatter of every value
+//   - top:		whether the condition is the top level group
+//
+// Returns:
+//   - string:	the condition
+//   - error:	error if occured
+func buildCondition(tt reflect.Type, c types.WhereCondition, format valueFormatter, top bool) (string, error) {
+	if len(c.Group) > 0 {
+		conjunction := c.Conjunction
+		if conjunction == "" {
+			conjunction = types.And
+		}
+		if conjunction != types.And && conjunction != types.Or {
+			return "", fmt.Errorf("%w: unknown conjunction %s", ErrInvalidCondition, conjunction)
+		}
+
+		parts := make([]string, len(c.Group))
+		for i, g := range c.Group {
+			part, err := buildCondition(tt, g, format, false)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+
+		group := strings.Join(parts, " "+string(conjunction)+" ")
+		if top || len(parts) == 1 {
+			return group, nil
+		}
+		return "(" + group + ")", nil
+	}
+
+	if c.Column == nil {
+		return "", fmt.Errorf("%w: no column nor group", ErrInvalidCondition)
+	}
+
+	_, name, err := resolveColumn(tt, c.Column)
+	if err != nil {
+		return "", err
+	}
+
+	switch c.Operator {
+	case types.Equal, types.NotEqual, types.Less, types.LessEqual, types.Greater, types.GreaterEqual, types.Like:
+		if len(c.Values) != 1 {
+			return "", fmt.Errorf("%w: %s takes one value, given %d", ErrInvalidCondition, c.Operator, len(c.Values))
+		}
+
+		value, err := bindConditionValue(c.Values[0], format)
+		if err != nil {
+			return "", err
+		}
+		return name + " " + string(c.Operator) + " " + value, nil
+
+	case types.Between:
+		if len(c.Values) != 2 {
+			return "", fmt.Errorf("%w: %s takes two values, given %d", ErrInvalidCondition, c.Operator, len(c.Values))
+		}
+
+		low, err := bindConditionValue(c.Values[0], format)
+		if err != nil {
+			return "", err
+		}
+		high, err := bindConditionValue(c.Values[1], format)
+		if err != nil {
+			return "", err
+		}
+		return name + " BETWEEN " + low + " AND " + high, nil
+
+	case types.In:
+		if len(c.Values) == 0 {
+			return "", fmt.Errorf("%w: %s takes at least one value", ErrInvalidCondition, c.Operator)
+		}
+
+		values := make([]string, len(c.Values))
+		for i, v := range c.Values {
+			value, err := bindConditionValue(v, format)
+			if err != nil {
+				return "", err
+			}
+			values[i] = value
+		}
+		return name + " IN (" + strings.Join(values, ", ") + ")", nil
+
+	case types.IsNull, types.IsNotNull:
+		if len(c.Values) != 0 {
+			return "", fmt.Errorf("%w: %s takes no values, given %d", ErrInvalidCondition, c.Operator, len(c.Values))
+		}
+		return name + " " + string(c.Operator), nil
+	}
+
+	return "", fmt.Errorf("%w: unknown operator %s", ErrInvalidCondition, c.Operator)
+}
+
+// Formats the condition value, custom structs are written as on insertion
+//
+// Parameters:
+//   - value:	the condition value
+//   - format:	the formatter of the value
+//
+// Returns:
+//   - string:	the formatted value
+//   - error:	error if occured
+func bindConditionValue(value any, format valueFormatter) (string, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		// Format the nil interface
+		return format(reflect.ValueOf(&value).Elem()), nil
+	}
+	if !utils.ValidateCustomStruct(v.Type()) {
+		return format(v), nil
+	}
+
+	if utils.ValidateDefaultStruct(v.Type()) && v.FieldByName("Default").Bool() {
+		return "", ErrDefaultCondition
+	}
+
+	return parseCustomStruct(v.Type(), v, format)
+}
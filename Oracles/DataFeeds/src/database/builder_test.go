@@ -0,0 +1,186 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+type BuildQueryInput struct {
+	Builder *QueryBuilder
+	Delete  bool
+	Correct string
+	Args    []any
+	Err     error
+}
+
+var BUILD_QUERIES = []BuildQueryInput{
+	{
+		Builder: NewQueryBuilder(types.Price{}),
+		Correct: `SELECT * FROM Price`,
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).
+			Select(types.PriceAssetId, types.PricePrice).
+			Where(
+				types.Where(types.PriceAssetId, types.In, 1, 2, 3),
+				types.Where(types.PricePrice, types.Between, 10, 20),
+			).
+			OrderBy(types.PriceAssetId, false).
+			OrderBy(types.PriceTimestamp, true).
+			Limit(5).
+			Offset(10),
+		Correct: `SELECT asset_id, price FROM Price
+WHERE asset_id IN ($1, $2, $3) AND price BETWEEN $4 AND $5
+ORDER BY asset_id ASC, timestamp DESC
+LIMIT 5
+OFFSET 10`,
+		Args: []any{1, 2, 3, 10, 20},
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).
+			Where(
+				types.Where(types.PriceAssetId, types.NotEqual, 1),
+				types.AnyOf(
+					types.Where(types.PricePrice, types.Less, 10),
+					types.AllOf(
+						types.Where(types.PricePrice, types.GreaterEqual, 100),
+						types.Where(types.PriceTimestamp, types.Greater, types.Timestamp{Unix: 1724440501}),
+					),
+				),
+			),
+		Correct: `SELECT * FROM Price
+WHERE asset_id <> $1 AND (price < $2 OR (price >= $3 AND timestamp > TO_TIMESTAMP($4)))`,
+		Args: []any{1, 10, 100, 1724440501},
+	},
+	{
+		Builder: NewQueryBuilder(types.Asset{}).
+			Select(types.AssetSource).
+			Where(
+				types.Where(types.AssetTicker, types.Like, "BTC%"),
+				types.Where(types.AssetSource, types.IsNotNull),
+			).
+			GroupBy(types.AssetSource),
+		Correct: `SELECT source FROM Asset
+WHERE ticker LIKE $1 AND source IS NOT NULL
+GROUP BY source`,
+		Args: []any{"BTC%"},
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).Where(types.Where(types.PricePrice, types.Between, 1)),
+		Err:     ErrInvalidCondition,
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).Where(types.Where(types.PricePrice, types.IsNull, 1)),
+		Err:     ErrInvalidCondition,
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).Where(types.Where(types.PricePrice, "~", 1)),
+		Err:     ErrInvalidCondition,
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).Where(types.WhereCondition{}),
+		Err:     ErrInvalidCondition,
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).OrderBy(types.AssetTicker, true),
+		Err:     ErrColumnTable,
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).Where(types.Where(types.PriceId, types.Equal, types.Default[int64]{Default: true})),
+		Err:     ErrDefaultCondition,
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).
+			Where(
+				types.Where(types.PriceAssetId, types.Equal, 1),
+				types.Where(types.PriceTimestamp, types.Less, types.Timestamp{Now: true}),
+			),
+		Delete: true,
+		Correct: `DELETE FROM Price
+WHERE asset_id = $1 AND timestamp < NOW()`,
+		Args: []any{1},
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}),
+		Delete:  true,
+		Err:     ErrNoConditions,
+	},
+	{
+		Builder: NewQueryBuilder(types.Price{}).Where(types.Where(types.PriceAssetId, types.Equal, 1)).Limit(1),
+		Delete:  true,
+		Err:     ErrDeleteClause,
+	},
+}
+
+func TestQueryBuilderFunc(t *testing.T) {
+	for _, bq := range BUILD_QUERIES {
+		var query string
+		var args []any
+		var err error
+		if bq.Delete {
+			query, args, err = bq.Builder.BuildDelete()
+		} else {
+			query, args, err = bq.Builder.Build()
+		}
+
+		if !errors.Is(err, bq.Err) {
+			t.Errorf("wrong error: given %v, wanted %v", err, bq.Err)
+			continue
+		}
+
+		if query != bq.Correct {
+			t.Errorf("incorrect query:\n%v\n%v", query, bq.Correct)
+		}
+
+		if !reflect.DeepEqual(args, bq.Args) {
+			t.Errorf("incorrect arguments:\ngiven %v\nwanted %v", args, bq.Args)
+		}
+	}
+}
+
+// Select and delete through the builder
+func TestSelectWhereFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{}, types.Price{})
+
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+	_, errs := InsertEntries(db, buildBulkPrices(1, 10))
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("error inserting the prices: %v", err)
+	}
+
+	qb := NewQueryBuilder(types.Price{}).
+		Where(types.AnyOf(
+			types.Where(types.PricePrice, types.Less, 1002),
+			types.Where(types.PricePrice, types.Between, 1007, 1008),
+		)).
+		OrderBy(types.PricePrice, true)
+	prices, err := ScanRows[types.Price](SelectWhere(db, qb))
+	if err != nil {
+		t.Fatalf("error selecting the prices: %v", err)
+	}
+
+	correct := []int{1008, 1007, 1001, 1000}
+	if len(prices) != len(correct) {
+		t.Fatalf("wrong number of prices: given %d, wanted %d", len(prices), len(correct))
+	}
+	for i, p := range prices {
+		if p.Price != correct[i] {
+			t.Errorf("wrong price: given %d, wanted %d", p.Price, correct[i])
+		}
+	}
+
+	result, err := DeleteWhere(db, NewQueryBuilder(types.Price{}).Where(types.Where(types.PricePrice, types.GreaterEqual, 1005)))
+	if err != nil {
+		t.Fatalf("error deleting the prices: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 5 {
+		t.Errorf("wrong number of deleted rows: %d", affected)
+	}
+}
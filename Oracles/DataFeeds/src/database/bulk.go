@@ -0,0 +1,331 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrBulkNotStruct   = errors.New("bulk insertion rows are not structs")
+	ErrBulkMixedTables = errors.New("bulk insertion rows belong to different tables")
+	ErrCopyDefaults    = errors.New("rows mix DEFAULT and explicit values in the same column")
+)
+
+const (
+	// Number of rows from which BulkInsertEntries uses COPY
+	BulkCopyThreshold = 1000
+	// Maximum number of rows of a multi-row insertion query
+	BulkInsertChunkSize = 500
+	// Maximum number of parameters postgres accepts in a query
+	maxQueryParameters = 65535
+)
+
+// Takes multiple rows of the same table and inserts them in bulk. Small
+// batches are inserted with multi-row INSERT queries of BulkInsertChunkSize
+// rows, batches of BulkCopyThreshold rows or more are streamed with COPY.
+// If a chunk fails its rows are inserted one by one to report the failing rows.
+// Every row must belong to the same table
+//
+// Parameters:
+//   - db:		the database struct
+//   - data:	the structs rows
+//
+// Returns:
+//   - int64:	the number of inserted rows
+//   - []error:	an array of errors of the same length as data, nil for inserted rows
+func BulkInsertEntries[T any](db Executor, data []T) (int64, []error) {
+	return BulkInsertEntriesContext(context.Background(), db, data)
+}
+
+// Takes multiple rows of the same table and inserts them in bulk
+// The insertion is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - data:	the structs rows
+//
+// Returns:
+//   - int64:	the number of inserted rows
+//   - []error:	an array of errors of the same length as data, nil for inserted rows
+func BulkInsertEntriesContext[T any](ctx context.Context, db Executor, data []T) (int64, []error) {
+	errs := make([]error, len(data))
+	if len(data) == 0 {
+		return 0, errs
+	}
+
+	// Interface rows are inserted into the table of their values
+	tt := reflect.TypeFor[T]()
+	if tt.Kind() == reflect.Interface {
+		tt = reflect.TypeOf(data[0])
+	}
+	if !utils.ValidateStruct(tt) {
+		return 0, fillErrors(errs, ErrBulkNotStruct)
+	}
+	for _, d := range data {
+		if reflect.TypeOf(d) != tt {
+			return 0, fillErrors(errs, ErrBulkMixedTables)
+		}
+	}
+
+	values := make([]reflect.Value, len(data))
+	for i, d := range data {
+		values[i] = reflect.ValueOf(d)
+	}
+
+	// Stream large batches
+	if len(data) >= BulkCopyThreshold {
+		inserted, err := copyEntries(ctx, db, tt, values)
+		if err == nil {
+			return inserted, errs
+		}
+		if ctx.Err() != nil {
+			return 0, fillErrors(errs, err)
+		}
+	}
+
+	// Insert in chunks, the chunk size never exceeds the parameters limit
+	chunkSize := BulkInsertChunkSize
+	if maxRows := maxQueryParameters / tt.NumField(); maxRows < chunkSize {
+		chunkSize = maxRows
+	}
+
+	var inserted int64
+	for start := 0; start < len(values); start += chunkSize {
+		end := min(start+chunkSize, len(values))
+
+		n, err := insertEntriesChunk(ctx, db, tt, values[start:end])
+		if err == nil {
+			inserted += n
+			continue
+		}
+		if ctx.Err() != nil {
+			fillErrors(errs[start:], err)
+			return inserted, errs
+		}
+
+		// Find the failing rows
+		for i := start; i < end; i++ {
+			_, errs[i] = insertEntriesChunk(ctx, db, tt, values[i:i+1])
+			if errs[i] == nil {
+				inserted++
+			}
+		}
+	}
+
+	return inserted, errs
+}
+
+// Inserts the rows with a single multi-row query inside a transaction,
+// or a savepoint if db is already a transaction
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - tt:		the rows type
+//   - values:	the rows values
+//
+// Returns:
+//   - int64:	the number of inserted rows
+//   - error:	if any error occured
+func insertEntriesChunk(ctx context.Context, db Executor, tt reflect.Type, values []reflect.Value) (int64, error) {
+	var qa queryArgs
+
+	query, err := parseStructsToEntries(tt, values, qa.parameter)
+	if err != nil {
+		return 0, err
+	}
+
+	var inserted int64
+	err = WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+		result, err := MakeQueryContext(ctx, tx, query, qa.values...)
+		if err != nil {
+			return err
+		}
+
+		inserted, err = result.RowsAffected()
+		return err
+	})
+
+	return inserted, err
+}
+
+// Streams the rows with COPY inside a transaction, or a savepoint if db
+// is already a transaction. Defaulted columns are left to the database
+// when every row defaults them, timestamps are computed in the session
+// time zone to match NOW() and TO_TIMESTAMP()
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - tt:		the rows type
+//   - values:	the rows values
+//
+// Returns:
+//   - int64:	the number of inserted rows
+//   - error:	if any error occured
+func copyEntries(ctx context.Context, db Executor, tt reflect.Type, values []reflect.Value) (int64, error) {
+	columns, fields, err := parseStructsToCopyColumns(tt, values)
+	if err != nil {
+		return 0, err
+	}
+
+	var inserted int64
+	err = WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+		// Session clock and time zone
+		var now time.Time
+		var zone string
+		err := tx.QueryRowContext(ctx, "SELECT NOW()::timestamp, current_setting('TimeZone')").Scan(&now, &zone)
+		if err != nil {
+			return err
+		}
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			location = time.UTC
+		}
+
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(strings.ToLower(utils.BaseTypeName(tt)), columns...))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, v := range values {
+			row, err := parseStructToCopyRow(tt, v, fields, now, location)
+			if err != nil {
+				return err
+			}
+
+			if _, err := stmt.ExecContext(ctx, row...); err != nil {
+				return err
+			}
+		}
+
+		// Flush the copied rows
+		result, err := stmt.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		inserted, err = result.RowsAffected()
+		return err
+	})
+
+	return inserted, err
+}
+
+// Selects the columns streamed with COPY, a Default column is left out
+// when every row defaults it
+//
+// Parameters:
+//   - tt:		the rows type
+//   - values:	the rows values
+//
+// Returns:
+//   - []string:	the columns names
+//   - []int:		the fields indexes of the columns
+//   - error:		ErrCopyDefaults if rows mix defaults and values in a column
+func parseStructsToCopyColumns(tt reflect.Type, values []reflect.Value) ([]string, []int, error) {
+	var columns []string
+	var fields []int
+
+	for i := 0; i < tt.NumField(); i++ {
+		f := tt.Field(i)
+
+		name, err := utils.GetFieldNameDB(f)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if utils.ValidateDefaultStruct(f.Type) {
+			defaults := 0
+			for _, v := range values {
+				if v.Field(i).FieldByName("Default").Bool() {
+					defaults++
+				}
+			}
+
+			if defaults == len(values) {
+				continue
+			}
+			if defaults != 0 {
+				return nil, nil, ErrCopyDefaults
+			}
+		}
+
+		columns = append(columns, name)
+		fields = append(fields, i)
+	}
+
+	return columns, fields, nil
+}
+
+// Parses the struct into a COPY row
+//
+// Parameters:
+//   - tt:			the row type
+//   - v:			the row value
+//   - fields:		the fields indexes to copy
+//   - now:			the session time used for Timestamp.Now
+//   - location:	the session time zone
+//
+// Returns:
+//   - []any:	the row values
+//   - error:	if any error occured
+func parseStructToCopyRow(tt reflect.Type, v reflect.Value, fields []int, now time.Time, location *time.Location) ([]any, error) {
+	row := make([]any, len(fields))
+
+	for i, index := range fields {
+		f := tt.Field(index)
+		fv := v.Field(index)
+
+		switch {
+		case utils.ValidateDefaultStruct(f.Type):
+			row[i] = driverValue(fv.FieldByName("Value"))
+		case utils.ValidateNullStruct(f.Type):
+			if fv.FieldByName("Null").Bool() {
+				row[i] = nil
+				continue
+			}
+			row[i] = driverValue(fv.FieldByName("Value"))
+		case utils.ValidateTimestampStruct(f.Type):
+			if fv.FieldByName("Now").Bool() {
+				row[i] = now
+				continue
+			}
+
+			// Wall clock of the unix time in the session time zone
+			t := time.Unix(fv.FieldByName("Unix").Int(), 0).In(location)
+			row[i] = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+		case utils.ValidateStruct(f.Type) && !utils.ValidateValueStruct(f.Type):
+			return nil, fmt.Errorf("cannot have nested not custom struct as tables: %v", f)
+		default:
+			row[i] = driverValue(fv)
+		}
+	}
+
+	return row, nil
+}
+
+// Sets err on every element of errs
+//
+// Parameters:
+//   - errs:	the errors array
+//   - err:		the error
+//
+// Returns:
+//   - []error:	the errors array
+func fillErrors(errs []error, err error) []error {
+	for i := range errs {
+		errs[i] = err
+	}
+
+	return errs
+}
@@ -0,0 +1,271 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Builds n prices of the asset with distinct timestamps
+func buildBulkPrices(assetId int, n int) []types.Price {
+	prices := make([]types.Price, n)
+	for i := range prices {
+		prices[i] = types.Price{
+			Id: types.Default[int64]{
+				Default: true,
+			},
+			Asset_id: assetId,
+			Price:    1000 + i,
+			Timestamp: types.Timestamp{
+				Now:  false,
+				Unix: 1724440501 + i,
+			},
+		}
+	}
+
+	return prices
+}
+
+var BULK_ASSET = types.Asset{
+	Id: types.Default[uint64]{
+		Default: true,
+	},
+	Ticker:   "BTC",
+	Source:   "Binance",
+	Decimals: 18,
+}
+
+// Parse structs to entries
+func TestParseStructsToEntriesFunc(t *testing.T) {
+	var qa queryArgs
+	prices := buildBulkPrices(1, 2)
+	prices[1].Timestamp.Now = true
+
+	query, err := parseStructsToEntries(types.PRICE, []reflect.Value{reflect.ValueOf(prices[0]), reflect.ValueOf(prices[1])}, qa.parameter)
+	if err != nil {
+		t.Fatalf("error during parsing: %v", err)
+	}
+
+	correct := `INSERT INTO Price (id, asset_id, price, timestamp)
+VALUES (DEFAULT, $1, $2, TO_TIMESTAMP($3)),
+(DEFAULT, $4, $5, NOW())`
+	if query != correct {
+		t.Errorf("incorrect parsing:\n%v\n%v", query, correct)
+	}
+
+	args := []any{1, 1000, 1724440501, 1, 1001}
+	if !reflect.DeepEqual(qa.values, args) {
+		t.Errorf("incorrect arguments:\ngiven %v\nwanted %v", qa.values, args)
+	}
+}
+
+// Copy columns
+type CopyColumnsInput struct {
+	Rows    []types.Price
+	Columns []string
+	Err     error
+}
+
+var COPY_COLUMNS = []CopyColumnsInput{
+	{
+		Rows:    buildBulkPrices(1, 3),
+		Columns: []string{"asset_id", "price", "timestamp"},
+		Err:     nil,
+	},
+	{
+		Rows: []types.Price{
+			{Id: types.Default[int64]{Default: false, Value: 1}},
+			{Id: types.Default[int64]{Default: false, Value: 2}},
+		},
+		Columns: []string{"id", "asset_id", "price", "timestamp"},
+		Err:     nil,
+	},
+	{
+		Rows: []types.Price{
+			{Id: types.Default[int64]{Default: true}},
+			{Id: types.Default[int64]{Default: false, Value: 2}},
+		},
+		Columns: nil,
+		Err:     ErrCopyDefaults,
+	},
+}
+
+func TestParseStructsToCopyColumnsFunc(t *testing.T) {
+	for _, cc := range COPY_COLUMNS {
+		values := make([]reflect.Value, len(cc.Rows))
+		for i, r := range cc.Rows {
+			values[i] = reflect.ValueOf(r)
+		}
+
+		columns, _, err := parseStructsToCopyColumns(types.PRICE, values)
+		if !errors.Is(err, cc.Err) {
+			t.Errorf("wrong error: given %v, wanted %v", err, cc.Err)
+		}
+
+		if !reflect.DeepEqual(columns, cc.Columns) {
+			t.Errorf("wrong copy columns: given %v, wanted %v", columns, cc.Columns)
+		}
+	}
+}
+
+func TestParseStructToCopyRowFunc(t *testing.T) {
+	now := time.Date(2024, 8, 24, 10, 0, 0, 0, time.UTC)
+	rows := []struct {
+		Input   types.Price
+		Correct []any
+	}{
+		{
+			Input:   buildBulkPrices(3, 1)[0],
+			Correct: []any{3, 1000, time.Date(2024, 8, 23, 19, 15, 1, 0, time.UTC)},
+		},
+		{
+			Input: types.Price{
+				Asset_id:  3,
+				Price:     10,
+				Timestamp: types.Timestamp{Now: true},
+			},
+			Correct: []any{3, 10, now},
+		},
+	}
+
+	for _, r := range rows {
+		row, err := parseStructToCopyRow(types.PRICE, reflect.ValueOf(r.Input), []int{1, 2, 3}, now, time.UTC)
+		if err != nil {
+			t.Errorf("error parsing copy row: %v", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(row, r.Correct) {
+			t.Errorf("wrong copy row:\ngiven %v\nwanted %v", row, r.Correct)
+		}
+	}
+}
+
+// Bulk insertion
+func TestBulkInsertEntriesFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{}, types.Price{})
+
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	// Multi-row insertion, the row of a missing asset fails alone
+	prices := buildBulkPrices(1, 10)
+	prices[4].Asset_id = 404
+
+	inserted, errs := BulkInsertEntries(db, prices)
+	if inserted != 9 {
+		t.Errorf("wrong number of inserted rows: %d", inserted)
+	}
+	for i, err := range errs {
+		if (i == 4) != (err != nil) {
+			t.Errorf("wrong error for row %d: %v", i, err)
+		}
+	}
+
+	// Copy insertion
+	prices = buildBulkPrices(1, BulkCopyThreshold+10)
+	for i := range prices {
+		prices[i].Timestamp.Unix += 100000
+	}
+
+	inserted, errs = BulkInsertEntries(db, prices)
+	if err := errors.Join(errs...); err != nil {
+		t.Errorf("error copying rows: %v", err)
+	}
+	if inserted != int64(len(prices)) {
+		t.Errorf("wrong number of copied rows: %d", inserted)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Price WHERE asset_id = 1").Scan(&count); err != nil {
+		t.Fatalf("error counting rows: %v", err)
+	}
+	if count != 9+len(prices) {
+		t.Errorf("wrong number of stored rows: %d", count)
+	}
+
+	// Copied timestamps match TO_TIMESTAMP
+	var matching bool
+	err := db.QueryRow("SELECT timestamp = TO_TIMESTAMP($1)::timestamp FROM Price WHERE price = $2 AND asset_id = 1 ORDER BY id DESC LIMIT 1", prices[0].Timestamp.Unix, prices[0].Price).Scan(&matching)
+	if err != nil || !matching {
+		t.Errorf("copied timestamp differs from TO_TIMESTAMP: %v", err)
+	}
+
+	// Rows of different tables are refused in bulk and inserted in runs
+	mixed := []any{BULK_ASSET, buildBulkPrices(2, 1)[0], buildBulkPrices(2, 1)[0]}
+	if _, errs := BulkInsertEntries(db, mixed); !errors.Is(errs[0], ErrBulkMixedTables) {
+		t.Errorf("rows of different tables inserted in bulk: %v", errs[0])
+	}
+	mixed[2] = BULK_ASSET
+	results, errs := InsertEntries(db, mixed)
+	if err := errors.Join(errs...); err != nil {
+		t.Errorf("error inserting the rows of different tables: %v", err)
+	}
+	for i, r := range results {
+		if n, err := r.RowsAffected(); err != nil || n != 1 {
+			t.Errorf("wrong result for row %d: %d %v", i, n, err)
+		}
+	}
+}
+
+// Benchmarks
+const BENCHMARK_BATCH_SIZE = 500
+
+// Row by row insertion, the baseline of the bulk insertion
+func BenchmarkInsertEntry(b *testing.B) {
+	db := dbtest.DB(b)
+	ensureSchema(b, db, types.Asset{}, types.Price{})
+
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		b.Fatalf("error inserting the asset: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		prices := buildBulkPrices(1, BENCHMARK_BATCH_SIZE)
+		for j := range prices {
+			prices[j].Timestamp.Unix += i * BENCHMARK_BATCH_SIZE
+		}
+
+		for _, p := range prices {
+			if _, err := InsertEntry(db, p); err != nil {
+				b.Fatalf("error inserting the row: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkBulkInsertEntries(b *testing.B) {
+	benchmarkBulkInsertEntries(b, BENCHMARK_BATCH_SIZE)
+}
+
+func BenchmarkBulkInsertEntriesCopy(b *testing.B) {
+	benchmarkBulkInsertEntries(b, BulkCopyThreshold)
+}
+
+func benchmarkBulkInsertEntries(b *testing.B, size int) {
+	db := dbtest.DB(b)
+	ensureSchema(b, db, types.Asset{}, types.Price{})
+
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		b.Fatalf("error inserting the asset: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		prices := buildBulkPrices(1, size)
+		for j := range prices {
+			prices[j].Timestamp.Unix += i * size
+		}
+
+		if _, errs := BulkInsertEntries(db, prices); errors.Join(errs...) != nil {
+			b.Fatalf("error inserting rows: %v", errors.Join(errs...))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+var (
+	ErrColumnTable = errors.New("column does not belong to the table")
+)
+
+// Column of a table known only by its reflect type
+type fieldColumn struct {
+	table reflect.Type
+	name  string
+}
+
+func (c fieldColumn) ColumnName() string {
+	return c.name
+}
+
+func (c fieldColumn) TableType() reflect.Type {
+	return c.table
+}
+
+// Resolves the column against the table db tags
+//
+// Parameters:
+//   - tt:		the reflect table type
+//   - column:	the column reference
+//
+// Returns:
+//   - int:		the index of the column field
+//   - string:	the column db name
+//   - error:	if the column does not belong to the table
+func resolveColumn(tt reflect.Type, column types.ColumnRef) (int, string, error) {
+	if column == nil {
+		return 0, "", fmt.Errorf("%w: nil column", ErrUnknownColumn)
+	}
+
+	if column.TableType() != tt {
+		return 0, "", fmt.Errorf("%w: %s is a %s column, not %s", ErrColumnTable, column.ColumnName(), utils.BaseTypeName(column.TableType()), utils.BaseTypeName(tt))
+	}
+
+	for i := 0; i < tt.NumField(); i++ {
+		name, err := utils.GetFieldNameDB(tt.Field(i))
+		if err != nil {
+			return 0, "", err
+		}
+
+		if strings.EqualFold(name, column.ColumnName()) {
+			return i, name, nil
+		}
+	}
+
+	return 0, "", fmt.Errorf("%w: %s in %s", ErrUnknownColumn, column.ColumnName(), utils.BaseTypeName(tt))
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+type ResolveColumnInput struct {
+	Column types.ColumnRef
+	Index  int
+	Name   string
+	Err    error
+}
+
+var RESOLVE_COLUMNS = []ResolveColumnInput{
+	{
+		Column: types.PriceTimestamp,
+		Index:  3,
+		Name:   "timestamp",
+	},
+	{
+		Column: types.Col[types.Price]("ASSET_ID"),
+		Index:  1,
+		Name:   "asset_id",
+	},
+	{
+		Column: types.Col[types.Price]("volume"),
+		Err:    ErrUnknownColumn,
+	},
+	{
+		Column: types.AssetTicker,
+		Err:    ErrColumnTable,
+	},
+	{
+		Column: nil,
+		Err:    ErrUnknownColumn,
+	},
+}
+
+func TestResolveColumnFunc(t *testing.T) {
+	for _, rc := range RESOLVE_COLUMNS {
+		index, name, err := resolveColumn(types.PRICE, rc.Column)
+		if !errors.Is(err, rc.Err) {
+			t.Errorf("wrong error: given %v, wanted %v", err, rc.Err)
+			continue
+		}
+
+		if index != rc.Index || name != rc.Name {
+			t.Errorf("incorrect column: given %d %s, wanted %d %s", index, name, rc.Index, rc.Name)
+		}
+	}
+}
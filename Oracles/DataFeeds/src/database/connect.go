@@ -0,0 +1,326 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/config"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+var (
+	ErrNoDatabaseConfig = errors.New("neither DB_URL nor DB_IP is set")
+	ErrStatsPublished   = errors.New("expvar name is already published")
+)
+
+// Guards the check and the publishing of the expvar names
+var publishMu sync.Mutex
+
+// Default connection settings
+const (
+	DefaultMaxOpenConns    = 10
+	DefaultMaxIdleConns    = 5
+	DefaultConnMaxLifetime = 30 * time.Minute
+	DefaultConnMaxIdleTime = 5 * time.Minute
+	DefaultPingAttempts    = 6
+	DefaultPingBackoff     = 500 * time.Millisecond
+	DefaultReadRetries     = 2
+	DefaultReadBackoff     = 100 * time.Millisecond
+)
+
+// Longest wait between two pings
+const maxPingBackoff = 10 * time.Second
+
+// Database connection and pool configuration
+type Config struct {
+	// Connection URL, it takes precedence over the fields below
+	URL string
+	// Connection fields, used when URL is empty
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+	SSLMode  string
+
+	// Pool settings, see sql.DB
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Pings made at startup, the wait doubles after each failed ping
+	PingAttempts int
+	PingBackoff  time.Duration
+
+	// Retries of the reads failing on a lost connection, the wait is
+	// multiplied by the attempt number
+	ReadRetries int
+	ReadBackoff time.Duration
+}
+
+// Returns the default configuration, without any connection settings
+//
+// Returns:
+//   - Config:	the configuration
+func DefaultConfig() Config {
+	return Config{
+		Port:            "5432",
+		User:            "postgres",
+		Database:        "postgres",
+		SSLMode:         "disable",
+		MaxOpenConns:    DefaultMaxOpenConns,
+		MaxIdleConns:    DefaultMaxIdleConns,
+		ConnMaxLifetime: DefaultConnMaxLifetime,
+		ConnMaxIdleTime: DefaultConnMaxIdleTime,
+		PingAttempts:    DefaultPingAttempts,
+		PingBackoff:     DefaultPingBackoff,
+		ReadRetries:     DefaultReadRetries,
+		ReadBackoff:     DefaultReadBackoff,
+	}
+}
+
+// Returns the default configuration completed by the environment, DB_URL
+// or the DB_IP, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME and DB_SSL_MODE keys,
+// and the DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME
+// pool settings
+//
+// Returns:
+//   - Config:	the configuration
+//   - error:	ErrNoDatabaseConfig if no connection is set, error if a
+//     setting is malformed
+func ConfigFromEnv() (Config, error) {
+	c := DefaultConfig()
+	c.URL = os.Getenv(config.DB_URL)
+	c.Host = os.Getenv(config.DB_IP)
+	c.Password = os.Getenv(config.DB_PASSWORD)
+	setFromEnv(&c.Port, config.DB_PORT)
+	setFromEnv(&c.User, config.DB_USER)
+	setFromEnv(&c.Database, config.DB_NAME)
+	setFromEnv(&c.SSLMode, config.DB_SSL_MODE)
+
+	if c.URL == "" && c.Host == "" {
+		return c, ErrNoDatabaseConfig
+	}
+
+	var err error
+	if value := os.Getenv(config.DB_MAX_OPEN_CONNS); value != "" {
+		if c.MaxOpenConns, err = strconv.Atoi(value); err != nil {
+			return c, err
+		}
+	}
+	if value := os.Getenv(config.DB_MAX_IDLE_CONNS); value != "" {
+		if c.MaxIdleConns, err = strconv.Atoi(value); err != nil {
+			return c, err
+		}
+	}
+	if value := os.Getenv(config.DB_CONN_MAX_LIFETIME); value != "" {
+		if c.ConnMaxLifetime, err = time.ParseDuration(value); err != nil {
+			return c, err
+		}
+	}
+
+	return c, nil
+}
+
+// Sets the field to the environment key if it is set
+func setFromEnv(field *string, key string) {
+	if value := os.Getenv(key); value != "" {
+		*field = value
+	}
+}
+
+// Returns the data source name of the configuration
+//
+// Returns:
+//   - string:	the connection URL
+func (c Config) DSN() string {
+	if c.URL != "" {
+		return c.URL
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, c.Port),
+		Path:     "/" + c.Database,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+	return dsn.String()
+}
+
+// Database pool retrying the reads failing on a lost connection, such as a
+// reset connection or an administrator shutdown. Reads are single SELECT
+// queries made outside of a transaction, queries made through WithTx or a
+// pinned *sql.Conn are never retried
+type DB struct {
+	*sql.DB
+	ReadRetries int
+	ReadBackoff time.Duration
+}
+
+// Opens and configures the database pool, then pings it with backoff
+// until it is reachable
+//
+// Parameters:
+//   - ctx:	the context bounding the pings
+//   - c:	the configuration
+//
+// Returns:
+//   - *DB:		the database pool
+//   - error:	the last ping error if the database is unreachable
+func Open(ctx context.Context, c Config) (*DB, error) {
+	db, err := sql.Open("postgres", c.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+
+	if err := PingWithBackoff(ctx, db, c.PingAttempts, c.PingBackoff); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &DB{DB: db, ReadRetries: c.ReadRetries, ReadBackoff: c.ReadBackoff}, nil
+}
+
+// Pings the database until it answers, the wait doubles after each
+// failed ping up to 10 seconds
+//
+// Parameters:
+//   - ctx:			the context bounding the pings
+//   - db:			the database struct
+//   - attempts:	the maximum number of pings, at least one is made
+//   - backoff:		the wait after the first failed ping
+//
+// Returns:
+//   - error:	the last ping error, or the context error
+func PingWithBackoff(ctx context.Context, db *sql.DB, attempts int, backoff time.Duration) error {
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil || attempt >= attempts || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxPingBackoff)
+	}
+}
+
+// Publishes the pool statistics of db, as returned by db.Stats, under the
+// expvar name, served on /debug/vars by the default HTTP mux. As expvar
+// names are global a name can only be published once
+//
+// Parameters:
+//   - name:	the expvar name
+//   - db:		the database struct
+//
+// Returns:
+//   - error:	ErrStatsPublished if the name is already published
+func PublishStats(name string, db *sql.DB) error {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("%w: %s", ErrStatsPublished, name)
+	}
+
+	expvar.Publish(name, expvar.Func(func() any {
+		return db.Stats()
+	}))
+	return nil
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if !isReadQuery(query) {
+		return db.DB.QueryContext(ctx, query, args...)
+	}
+
+	var rows *sql.Rows
+	err := retryRead(ctx, db.ReadRetries, db.ReadBackoff, func() error {
+		var err error
+		rows, err = db.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if !isReadQuery(query) {
+		return db.DB.QueryRowContext(ctx, query, args...)
+	}
+
+	var row *sql.Row
+	retryRead(ctx, db.ReadRetries, db.ReadBackoff, func() error {
+		row = db.DB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+// Runs read until it succeeds, fails on anything but a lost connection or
+// is out of retries
+//
+// Parameters:
+//   - ctx:		the context bounding the retries
+//   - retries:	the number of retries
+//   - backoff:	the wait before each retry, multiplied by the attempt number
+//   - read:	the read to run
+//
+// Returns:
+//   - error:	the last read error
+func retryRead(ctx context.Context, retries int, backoff time.Duration, read func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := read()
+		if err == nil || attempt >= retries || !isConnectionLost(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+// Reports whether the query only reads, so that it can be retried
+func isReadQuery(query string) bool {
+	statement, err := utils.ClassifySQL(query)
+	return err == nil && statement.Kind == utils.StatementRead && !statement.Locking
+}
+
+// Reports whether err comes from a connection lost while querying
+func isConnectionLost(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	return errors.Is(wrapError(err), ErrConnectionLost) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
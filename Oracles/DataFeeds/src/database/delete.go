@@ -1,17 +1,21 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"reflect"
 	"strings"
 
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+	"github.com/lib/pq"
 )
 
 var (
-	ErrNotValidTable = errors.New("not a valid table passed in the function")
+	ErrNotValidTable  = errors.New("not a valid table passed in the function")
+	ErrNoMatchColumns = errors.New("no columns to match the deleted rows")
 )
 
 // Takes a table and a SELECT query, using that query it then eliminates all
@@ -20,33 +24,158 @@ var (
 // Parameters:
 //   - db:			the database driver
 //   - table:		the struct table
-//   - selectQuery:	the SELECT query
+//   - selectQuery:	the SELECT query, values are referenced as $1..$n
+//   - args:		the values bound to the selectQuery placeholders
 //
 // Returns:
 //   - sql.Result:	an array of results of the same length as entries
 //   - error:		if an error occured during the process
-func DeleteRowsByPrimaryKeyWithSelectionQuery(db *sql.DB, table types.Table, selectQuery string) (sql.Result, error) {
+func DeleteRowsByPrimaryKeyWithSelectionQuery(db Executor, table types.Table, selectQuery string, args ...any) (sql.Result, error) {
+	return DeleteRowsByPrimaryKeyWithSelectionQueryContext(context.Background(), db, table, selectQuery, args...)
+}
+
+// Takes a table and a SELECT query, using that query it then eliminates all
+// results based on their unique primary key. The selection and the deletion
+// run in the same transaction, the selection inside a read only savepoint,
+// see WithReadOnlyTx, so that it cannot modify data. The deletion is aborted
+// as soon as ctx is done
+//
+// Parameters:
+//   - ctx:			the context bounding the query
+//   - db:			the database driver
+//   - table:		the struct table
+//   - selectQuery:	the SELECT query of the primary keys, values are referenced as $1..$n
+//   - args:		the values bound to the selectQuery placeholders
+//
+// Returns:
+//   - sql.Result:	an array of results of the same length as entries
+//   - error:		if an error occured during the process
+func DeleteRowsByPrimaryKeyWithSelectionQueryContext(ctx context.Context, db Executor, table types.Table, selectQuery string, args ...any) (sql.Result, error) {
 	tt := reflect.TypeOf(table)
-	if utils.ValidateDefaultStruct(tt) {
+	if !utils.ValidateStruct(tt) || utils.ValidateCustomStruct(tt) {
 		return nil, ErrNotValidTable
 	}
+	if err := validateStatement(selectQuery, utils.StatementRead); err != nil {
+		return nil, err
+	}
 
 	table_name := utils.BaseTypeName(tt)
+	table_id, err := table.GetPrimaryKeyNameDB()
+	if err != nil {
+		return nil, err
+	}
+
 	var builder strings.Builder
 	builder.WriteString("DELETE FROM ")
 	builder.WriteString(table_name)
 	builder.WriteString("\n")
+	builder.WriteString("WHERE ")
+	builder.WriteString(table_id)
+	builder.WriteString(" = ANY($1)")
 
-	table_id, err := table.GetPrimaryKeyNameDB()
+	var result sql.Result
+	err = WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+		// Select the primary keys, as text so that any key type is matched
+		var ids []string
+		err := MakeReadOnlyQueryWithResultContext(ctx, tx, func(rows *sql.Rows) error {
+			for rows.Next() {
+				var id string
+				if err := rows.Scan(&id); err != nil {
+					return wrapError(err)
+				}
+				ids = append(ids, id)
+			}
+			return nil
+		}, selectQuery, args...)
+		if err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			result = driver.RowsAffected(0)
+			return nil
+		}
+
+		result, err = MakeQueryContext(ctx, tx, builder.String(), pq.Array(ids))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	builder.WriteString("WHERE ")
-	builder.WriteString(table_id)
-	builder.WriteString(" IN (\n")
-	builder.WriteString(selectQuery)
-	builder.WriteString("\n);")
+	return result, nil
+}
+
+// Deletes the table rows whose columns are equal to the match values
+//
+// Parameters:
+//   - db:				the database driver
+//   - table:			the struct table
+//   - matchColumns:	the columns to be matched with a value
+//   - matchValues:		values that are going to be matched with
+//
+// Returns:
+//   - sql.Result:	the deletion result
+//   - error:		if an error occured during the process
+func DeleteRowsByMatch(db Executor, table types.Table, matchColumns []types.ColumnRef, matchValues []any) (sql.Result, error) {
+	return DeleteRowsByMatchContext(context.Background(), db, table, matchColumns, matchValues)
+}
+
+// Deletes the table rows whose columns are equal to the match values
+// The deletion is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:				the context bounding the query
+//   - db:				the database driver
+//   - table:			the struct table
+//   - matchColumns:	the columns to be matched with a value
+//   - matchValues:		values that are going to be matched with
+//
+// Returns:
+//   - sql.Result:	the deletion result
+//   - error:		if an error occured during the process
+func DeleteRowsByMatchContext(ctx context.Context, db Executor, table types.Table, matchColumns []types.ColumnRef, matchValues []any) (sql.Result, error) {
+	if len(matchColumns) == 0 {
+		return nil, ErrNoMatchColumns
+	}
+
+	conditions, err := buildMatchConditions(matchColumns, matchValues)
+	if err != nil {
+		return nil, err
+	}
+
+	return DeleteWhereContext(ctx, db, NewQueryBuilder(table).Where(conditions...))
+}
+
+// Deletes the table rows matching the query builder conditions
+//
+// Parameters:
+//   - db:	the database driver
+//   - qb:	the query builder, holding only where conditions
+//
+// Returns:
+//   - sql.Result:	the deletion result
+//   - error:		if an error occured during the process
+func DeleteWhere(db Executor, qb *QueryBuilder) (sql.Result, error) {
+	return DeleteWhereContext(context.Background(), db, qb)
+}
+
+// Deletes the table rows matching the query builder conditions
+// The deletion is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:	the context bounding the query
+//   - db:	the database driver
+//   - qb:	the query builder, holding only where conditions
+//
+// Returns:
+//   - sql.Result:	the deletion result
+//   - error:		if an error occured during the process
+func DeleteWhereContext(ctx context.Context, db Executor, qb *QueryBuilder) (sql.Result, error) {
+	query, args, err := qb.BuildDelete()
+	if err != nil {
+		return nil, err
+	}
 
-	return MakeQuery(db, builder.String())
+	return MakeQueryContext(ctx, db, query, args...)
 }
@@ -1,9 +1,11 @@
 package database
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 )
 
@@ -75,11 +77,8 @@ var DELETE_ROWS_PRIMARY_KEY_SELECTION = DeleteRowsPrimaryKey{
 }
 
 func TestDeleteRowsByPrimaryKeyWithSelectionQueryFunc(t *testing.T) {
-	_, db, cleanup, err := InitMockSqlDB()
-	if err != nil {
-		t.Fatalf("mock db didnt initialized properly: %v", err)
-	}
-	defer cleanup()
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{}, types.Price{})
 
 	for _, el := range DELETE_ROWS_PRIMARY_KEY_SELECTION.InitElem {
 		_, err := InsertEntry(db, el)
@@ -88,7 +87,7 @@ func TestDeleteRowsByPrimaryKeyWithSelectionQueryFunc(t *testing.T) {
 		}
 	}
 
-	rows, err := SelectAllWhereAssetIdOrderedRow(db, types.Price{}, 1, 2, 3, false)
+	rows, err := SelectAllWhereAssetIdOrderedRow(db, types.Price{}, 1, types.PricePrice, 3, false)
 	if err != nil {
 		t.Errorf("error when selecting rows, %v", err)
 	}
@@ -111,15 +110,16 @@ func TestDeleteRowsByPrimaryKeyWithSelectionQueryFunc(t *testing.T) {
 		}
 	}
 
-	strWAI, err := buildSelectWhereAssetIdOrderedRowConditions(types.PRICE, 1, 2, 1, false)
-	if err != nil {
-		t.Errorf("error when building select where asset id ordered row conditions, %v", err)
-	}
-	str, err := buildSelectConditionsQuery(types.PRICE, []int{0}, strWAI...)
+	str, args, err := NewQueryBuilder(types.Price{}).
+		Select(types.PriceId).
+		Where(types.Where(types.PriceAssetId, types.Equal, 1)).
+		OrderBy(types.PricePrice, false).
+		Limit(1).
+		Build()
 	if err != nil {
-		t.Errorf("error when building select conditions query, %v", err)
+		t.Errorf("error when building the selection query, %v", err)
 	}
-	rowsAffected, err := DeleteRowsByPrimaryKeyWithSelectionQuery(db, types.Price{}, str)
+	rowsAffected, err := DeleteRowsByPrimaryKeyWithSelectionQuery(db, types.Price{}, str, args...)
 	if err != nil {
 		t.Errorf("error deleting rows: %v", err)
 		return
@@ -130,7 +130,7 @@ func TestDeleteRowsByPrimaryKeyWithSelectionQueryFunc(t *testing.T) {
 	}
 	t.Logf("rows affected: %d", ra)
 
-	rows, err = SelectAllWhereAssetIdOrderedRow(db, types.Price{}, 1, 2, 3, false)
+	rows, err = SelectAllWhereAssetIdOrderedRow(db, types.Price{}, 1, types.PricePrice, 3, false)
 	if err != nil {
 		t.Errorf("error when selecting rows, %v", err)
 	}
@@ -153,4 +153,12 @@ func TestDeleteRowsByPrimaryKeyWithSelectionQueryFunc(t *testing.T) {
 			t.Errorf("error retriving correct rows, wanted: %d, given: %d\n", id, resultPricesTwo[i].Id.Value)
 		}
 	}
+
+	// The selection can neither hold other statements nor write
+	if _, err := DeleteRowsByPrimaryKeyWithSelectionQuery(db, types.Price{}, "SELECT id FROM Price; DROP TABLE Price"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("selection with multiple statements should be invalid, given: %v", err)
+	}
+	if _, err := DeleteRowsByPrimaryKeyWithSelectionQuery(db, types.Price{}, "SELECT id FROM Price WHERE nextval('price_id_seq') > 0"); !errors.Is(err, ErrReadOnlyViolation) {
+		t.Errorf("writing selection should violate the read only transaction, given: %v", err)
+	}
 }
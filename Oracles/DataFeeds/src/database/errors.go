@@ -0,0 +1,144 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotFound             = errors.New("no rows found")
+	ErrInvalidQuery         = errors.New("query is not valid")
+	ErrInvalidData          = errors.New("data format is wrong")
+	ErrUniqueViolation      = errors.New("unique constraint violated")
+	ErrForeignKeyViolation  = errors.New("foreign key constraint violated")
+	ErrCheckViolation       = errors.New("check constraint violated")
+	ErrNotNullViolation     = errors.New("not null constraint violated")
+	ErrSerializationFailure = errors.New("transaction serialization failure")
+	ErrConnectionLost       = errors.New("database connection lost")
+	ErrReadOnlyViolation    = errors.New("write in a read only transaction")
+)
+
+// SQLSTATE codes of the typed errors
+var sqlStateErrors = map[pq.ErrorCode]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrForeignKeyViolation,
+	"23514": ErrCheckViolation,
+	"23502": ErrNotNullViolation,
+	"40001": ErrSerializationFailure,
+	"40P01": ErrSerializationFailure,
+	"08000": ErrConnectionLost,
+	"08003": ErrConnectionLost,
+	"08006": ErrConnectionLost,
+	"57P01": ErrConnectionLost,
+	"57P02": ErrConnectionLost,
+	"57P03": ErrConnectionLost,
+	"25006": ErrReadOnlyViolation,
+	"42601": ErrInvalidQuery,
+	"42703": ErrInvalidQuery,
+	"42P01": ErrInvalidQuery,
+}
+
+// Columns of a constraint violation detail: Key (asset_id, timestamp)=(...)
+var detailColumnsRegex = regexp.MustCompile(`^Key \(([^)]*)\)`)
+
+// Error of a failed query. It matches its typed error and the driver
+// error through errors.Is and errors.As:
+//
+//	if errors.Is(err, database.ErrUniqueViolation) { ... }
+type Error struct {
+	// Typed error, nil if the driver error has none
+	Kind error
+	// SQLSTATE code, empty for non database errors
+	Code string
+	// Table, columns and constraint concerned, when known
+	Table      string
+	Column     string
+	Constraint string
+	// Driver error
+	Err error
+}
+
+func (e *Error) Error() string {
+	var builder strings.Builder
+	if e.Kind != nil {
+		builder.WriteString(e.Kind.Error())
+		builder.WriteString(": ")
+	}
+
+	if e.Table != "" {
+		builder.WriteString(e.Table)
+		if e.Column != "" {
+			builder.WriteString(" (")
+			builder.WriteString(e.Column)
+			builder.WriteString(")")
+		}
+		if e.Constraint != "" {
+			builder.WriteString(" ")
+			builder.WriteString(e.Constraint)
+		}
+		builder.WriteString(": ")
+	}
+
+	builder.WriteString(e.Err.Error())
+	return builder.String()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+
+	return []error{e.Kind, e.Err}
+}
+
+// Maps the driver errors to the typed errors, every query error of the
+// package goes through it. Errors already mapped are returned as they are
+//
+// Parameters:
+//   - err:	the error
+//
+// Returns:
+//   - error:	the *Error wrapping err, err if it is not a driver error
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var mapped *Error
+	if errors.As(err, &mapped) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Kind: ErrNotFound, Err: err}
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	column := pqErr.Column
+	if match := detailColumnsRegex.FindStringSubmatch(pqErr.Detail); column == "" && match != nil {
+		column = match[1]
+	}
+
+	return &Error{
+		Kind:       sqlStateErrors[pqErr.Code],
+		Code:       string(pqErr.Code),
+		Table:      pqErr.Table,
+		Column:     column,
+		Constraint: pqErr.Constraint,
+		Err:        err,
+	}
+}
+
+// Returns ErrInvalidData describing the value
+func invalidData(value any) error {
+	return fmt.Errorf("%w: %T is not a struct", ErrInvalidData, value)
+}
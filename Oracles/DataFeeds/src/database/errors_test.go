@@ -0,0 +1,182 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+	"github.com/lib/pq"
+)
+
+type ErrorSample struct {
+	Input  error
+	Kind   error
+	Table  string
+	Column string
+}
+
+var WRAP_ERROR_SAMPLES = []ErrorSample{
+	{
+		Input: &pq.Error{
+			Code:       "23505",
+			Table:      "price",
+			Constraint: "price_asset_id_timestamp_key",
+			Detail:     "Key (asset_id, \"timestamp\")=(1, 2024-01-01 00:00:00) already exists.",
+		},
+		Kind:   ErrUniqueViolation,
+		Table:  "price",
+		Column: "asset_id, \"timestamp\"",
+	},
+	{
+		Input: fmt.Errorf("wrapped: %w", &pq.Error{
+			Code:       "23503",
+			Table:      "price",
+			Constraint: "price_asset_id_fkey",
+			Detail:     "Key (asset_id)=(42) is not present in table \"asset\".",
+		}),
+		Kind:   ErrForeignKeyViolation,
+		Table:  "price",
+		Column: "asset_id",
+	},
+	{
+		Input: &pq.Error{Code: "23514", Table: "asset", Constraint: "asset_decimals_check"},
+		Kind:  ErrCheckViolation,
+		Table: "asset",
+	},
+	{
+		Input:  &pq.Error{Code: "23502", Table: "asset", Column: "ticker"},
+		Kind:   ErrNotNullViolation,
+		Table:  "asset",
+		Column: "ticker",
+	},
+	{
+		Input: &pq.Error{Code: "42601"},
+		Kind:  ErrInvalidQuery,
+	},
+	{
+		Input: &pq.Error{Code: "25006"},
+		Kind:  ErrReadOnlyViolation,
+	},
+	{
+		Input: sql.ErrNoRows,
+		Kind:  ErrNotFound,
+	},
+}
+
+func TestWrapErrorFunc(t *testing.T) {
+	if wrapError(nil) != nil {
+		t.Errorf("nil error has been wrapped")
+	}
+
+	for _, s := range WRAP_ERROR_SAMPLES {
+		err := wrapError(s.Input)
+		if !errors.Is(err, s.Kind) {
+			t.Errorf("error %v should match %v", err, s.Kind)
+		}
+		if !errors.Is(err, s.Input) {
+			t.Errorf("error %v should match the driver error", err)
+		}
+
+		var dbErr *Error
+		if !errors.As(err, &dbErr) {
+			t.Fatalf("error %v is not a database error", err)
+		}
+		if dbErr.Table != s.Table || dbErr.Column != s.Column {
+			t.Errorf("wrong context, wanted %q (%q), given %q (%q)", s.Table, s.Column, dbErr.Table, dbErr.Column)
+		}
+
+		// Wrapping is idempotent
+		if wrapError(err) != err {
+			t.Errorf("error %v has been wrapped twice", err)
+		}
+	}
+
+	// Unmapped codes keep their driver error only
+	err := wrapError(&pq.Error{Code: "53300"})
+	var dbErr *Error
+	if !errors.As(err, &dbErr) || dbErr.Kind != nil || dbErr.Code != "53300" {
+		t.Errorf("unmapped code wrongly wrapped: %#v", err)
+	}
+
+	// Non driver errors are left untouched
+	plain := errors.New("plain")
+	if wrapError(plain) != plain {
+		t.Errorf("non driver error has been wrapped")
+	}
+}
+
+func TestInvalidQueryFunc(t *testing.T) {
+	if _, err := MakeQuery(nil, "SELECT * FROM Asset"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("query with result should be invalid, given: %v", err)
+	}
+	if _, err := MakeQueryWithResult(nil, "DELETE FROM Asset"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("query without result should be invalid, given: %v", err)
+	}
+	if _, err := MakeQueryWithResult(nil, "SELECT 1; DROP TABLE Asset"); !errors.Is(err, ErrInvalidQuery) || !errors.Is(err, utils.ErrMultipleStatements) {
+		t.Errorf("multiple statements should be invalid, given: %v", err)
+	}
+	if _, err := MakeQuery(nil, "SELECT * FROM Asset WHERE ticker = 'UPDATE'"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("query with result mentioning a write should be invalid, given: %v", err)
+	}
+	if _, err := InsertEntry(nil, 1); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("non struct data should be invalid, given: %v", err)
+	}
+}
+
+func TestTypedErrorsFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	models, err := NewRegistry(types.Asset{}, types.Price{})
+	if err != nil {
+		t.Fatalf("error creating the registry: %v", err)
+	}
+	if err := models.EnsureSchema(db); err != nil {
+		t.Fatalf("error creating the schema: %v", err)
+	}
+
+	// decimals >= 0 check
+	_, err = InsertEntry(db, TX_ASSETS[2])
+	if !errors.Is(err, ErrCheckViolation) {
+		t.Errorf("negative decimals should violate the check, given: %v", err)
+	}
+
+	if _, err := InsertEntry(db, TX_ASSETS[0]); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	price := types.Price{
+		Id:        types.Default[int64]{Default: true},
+		Asset_id:  1,
+		Price:     100,
+		Timestamp: types.Timestamp{Unix: 1704067200},
+	}
+	if _, err := InsertEntry(db, price); err != nil {
+		t.Fatalf("error inserting the price: %v", err)
+	}
+
+	_, err = InsertEntry(db, price)
+	var dbErr *Error
+	if !errors.Is(err, ErrUniqueViolation) || !errors.As(err, &dbErr) {
+		t.Errorf("duplicate price should violate the unique constraint, given: %v", err)
+	} else if dbErr.Table == "" {
+		t.Errorf("unique violation has no table context: %v", err)
+	}
+
+	price.Asset_id = 42
+	if _, err := InsertEntry(db, price); !errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("unknown asset should violate the foreign key, given: %v", err)
+	}
+
+	_, err = SelectOne[types.Asset](db, "SELECT * FROM Asset WHERE id = $1", 42)
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("missing asset should not be found, given: %v", err)
+	}
+
+	if _, err := MakeQueryWithResult(db, "SELECT * FROM Missing"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("unknown table should be an invalid query, given: %v", err)
+	}
+}
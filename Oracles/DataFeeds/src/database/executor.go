@@ -0,0 +1,269 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNoTransaction = errors.New("executor cannot begin a transaction")
+)
+
+// Executor runs the package queries, it is satisfied by *sql.DB, *sql.Conn
+// and *sql.Tx so that every helper can run inside a transaction
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// Executor able to begin a new transaction, satisfied by *sql.DB and *sql.Conn
+type txBeginner interface {
+	Executor
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Transaction executor handed to WithTx functions, it keeps track of the
+// savepoints nesting depth
+type txExecutor struct {
+	*sql.Tx
+	depth int
+}
+
+// Transaction configuration
+//
+// Note that retries are only made by the outermost transaction, as a
+// serialization failure aborts the whole transaction
+type TxConfig struct {
+	// Isolation level and read only mode, nil for the driver defaults
+	Options *sql.TxOptions
+	// Number of times the transaction is retried on serialization failures
+	Retries int
+	// Wait before each retry, multiplied by the attempt number
+	Backoff time.Duration
+}
+
+// Runs fn inside a transaction, it is committed if fn returns nil and
+// rolled back otherwise. If db is already a transaction fn runs inside a
+// savepoint, that is released or rolled back in the same way
+//
+// Parameters:
+//   - db:	the database or transaction executor
+//   - fn:	the function to run, it must make its queries through tx
+//
+// Returns:
+//   - error:	the fn error or the transaction error if occured
+func WithTx(db Executor, fn func(tx Executor) error) error {
+	return WithTxContext(context.Background(), db, TxConfig{}, fn)
+}
+
+// Runs fn inside a transaction configured by config, it is committed if fn
+// returns nil and rolled back otherwise. If db is already a transaction
+// fn runs inside a savepoint, that is released or rolled back in the same way
+//
+// Parameters:
+//   - ctx:		the context bounding the transaction
+//   - db:		the database or transaction executor
+//   - config:	the transaction configuration
+//   - fn:		the function to run, it must make its queries through tx
+//
+// Returns:
+//   - error:	the fn error or the transaction error if occured
+func WithTxContext(ctx context.Context, db Executor, config TxConfig, fn func(tx Executor) error) error {
+	switch e := db.(type) {
+	case *txExecutor:
+		return withSavepoint(ctx, e, fn)
+	case *sql.Tx:
+		return withSavepoint(ctx, &txExecutor{Tx: e}, fn)
+	case txBeginner:
+		for attempt := 0; ; attempt++ {
+			err := withTransaction(ctx, e, config.Options, fn)
+			if err == nil || attempt >= config.Retries || !isSerializationFailure(err) {
+				return err
+			}
+
+			// Wait before retrying
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(config.Backoff * time.Duration(attempt+1)):
+			}
+		}
+	default:
+		return ErrNoTransaction
+	}
+}
+
+// Runs fn inside a read only transaction, so that its queries cannot modify
+// data. If db is already a transaction fn runs inside a savepoint switched
+// to read only, that is always rolled back to restore the transaction mode
+//
+// Parameters:
+//   - db:	the database or transaction executor
+//   - fn:	the function to run, it must make its queries through tx
+//
+// Returns:
+//   - error:	the fn error or the transaction error if occured
+func WithReadOnlyTx(db Executor, fn func(tx Executor) error) error {
+	return WithReadOnlyTxContext(context.Background(), db, fn)
+}
+
+// Runs fn inside a read only transaction, so that its queries cannot modify
+// data. If db is already a transaction fn runs inside a savepoint switched
+// to read only, that is always rolled back to restore the transaction mode.
+// If db is a *DB the whole transaction is retried as its reads are, so fn
+// may run more than once
+//
+// Parameters:
+//   - ctx:	the context bounding the transaction
+//   - db:	the database or transaction executor
+//   - fn:	the function to run, it must make its queries through tx
+//
+// Returns:
+//   - error:	the fn error, ErrReadOnlyViolation if it tried to write, or the transaction error if occured
+func WithReadOnlyTxContext(ctx context.Context, db Executor, fn func(tx Executor) error) error {
+	options := &sql.TxOptions{ReadOnly: true}
+
+	switch e := db.(type) {
+	case *txExecutor:
+		return withReadOnlySavepoint(ctx, e, fn)
+	case *sql.Tx:
+		return withReadOnlySavepoint(ctx, &txExecutor{Tx: e}, fn)
+	case *DB:
+		return retryRead(ctx, e.ReadRetries, e.ReadBackoff, func() error {
+			return withTransaction(ctx, e, options, fn)
+		})
+	case txBeginner:
+		return withTransaction(ctx, e, options, fn)
+	default:
+		return ErrNoTransaction
+	}
+}
+
+// Runs fn inside a new transaction
+//
+// Parameters:
+//   - ctx:		the context bounding the transaction
+//   - db:		the executor beginning the transaction
+//   - options:	the transaction options
+//   - fn:		the function to run
+//
+// Returns:
+//   - error:	the fn error or the transaction error if occured
+func withTransaction(ctx context.Context, db txBeginner, options *sql.TxOptions, fn func(tx Executor) error) error {
+	tx, err := db.BeginTx(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	// Rollback on panic and propagate it
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&txExecutor{Tx: tx}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	return wrapError(tx.Commit())
+}
+
+// Runs fn inside a new savepoint of the transaction
+//
+// Parameters:
+//   - ctx:	the context bounding the savepoint
+//   - tx:	the transaction executor
+//   - fn:	the function to run
+//
+// Returns:
+//   - error:	the fn error or the savepoint error if occured
+func withSavepoint(ctx context.Context, tx *txExecutor, fn func(tx Executor) error) error {
+	nested := &txExecutor{Tx: tx.Tx, depth: tx.depth + 1}
+	savepoint := fmt.Sprintf("sp_%d", nested.depth)
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	// Rollback on panic and propagate it
+	defer func() {
+		if p := recover(); p != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(p)
+		}
+	}()
+
+	if err := fn(nested); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	return err
+}
+
+// Runs fn inside a new savepoint of the transaction switched to read only.
+// Releasing the savepoint would keep the transaction read only, so it is
+// rolled back in any case, fn has nothing to keep anyway
+//
+// Parameters:
+//   - ctx:	the context bounding the savepoint
+//   - tx:	the transaction executor
+//   - fn:	the function to run
+//
+// Returns:
+//   - error:	the fn error or the savepoint error if occured
+func withReadOnlySavepoint(ctx context.Context, tx *txExecutor, fn func(tx Executor) error) error {
+	nested := &txExecutor{Tx: tx.Tx, depth: tx.depth + 1}
+	savepoint := fmt.Sprintf("sp_%d", nested.depth)
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	// Rollback on panic and propagate it
+	defer func() {
+		if p := recover(); p != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(p)
+		}
+	}()
+
+	_, err := tx.ExecContext(ctx, "SET LOCAL transaction_read_only = on")
+	if err == nil {
+		err = fn(nested)
+	}
+
+	if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rbErr != nil {
+		return errors.Join(err, rbErr)
+	}
+	if _, relErr := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); relErr != nil {
+		return errors.Join(err, relErr)
+	}
+
+	return wrapError(err)
+}
+
+// Checks whether the error is a postgres serialization failure or deadlock,
+// which are solved by retrying the transaction
+//
+// Parameters:
+//   - err:	the error
+//
+// Returns:
+//   - bool:	if the transaction can be retried
+func isSerializationFailure(err error) bool {
+	return errors.Is(wrapError(err), ErrSerializationFailure)
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/lib/pq"
+)
+
+var TX_ASSETS = []types.Asset{
+	{
+		Id: types.Default[uint64]{
+			Default: true,
+		},
+		Ticker:   "BTC",
+		Source:   "Binance",
+		Decimals: 18,
+	},
+	{
+		Id: types.Default[uint64]{
+			Default: true,
+		},
+		Ticker:   "ETH",
+		Source:   "Binance",
+		Decimals: 18,
+	},
+	{
+		Id: types.Default[uint64]{
+			Default: true,
+		},
+		Ticker:   "SOL",
+		Source:   "Binance",
+		Decimals: -1,
+	},
+}
+
+// Counts the rows of the asset table
+func countAssets(t *testing.T, db Executor) int {
+	var count int
+	if err := db.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM Asset").Scan(&count); err != nil {
+		t.Fatalf("error counting assets: %v", err)
+	}
+	return count
+}
+
+var SERIALIZATION_FAILURE_SAMPLES = []TestInput{
+	{Input: &pq.Error{Code: "40001"}, Correct: true},
+	{Input: &pq.Error{Code: "40P01"}, Correct: true},
+	{Input: fmt.Errorf("wrapped: %w", &pq.Error{Code: "40001"}), Correct: true},
+	{Input: &pq.Error{Code: "23505"}, Correct: false},
+	{Input: errors.New("40001"), Correct: false},
+}
+
+func TestIsSerializationFailureFunc(t *testing.T) {
+	for _, s := range SERIALIZATION_FAILURE_SAMPLES {
+		if isSerializationFailure(s.Input.(error)) != s.Correct {
+			t.Errorf("wrong serialization failure detection for %v, wanted %v", s.Input, s.Correct)
+		}
+	}
+}
+
+func TestWithTxFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	if _, err := CreateTable(db, types.Asset{}); err != nil {
+		t.Fatalf("error creating the table: %v", err)
+	}
+
+	// A failing row rolls back the whole batch
+	err := WithTx(db, func(tx Executor) error {
+		_, errs := InsertEntries(tx, TX_ASSETS)
+		return errors.Join(errs...)
+	})
+	if err == nil {
+		t.Errorf("batch with an invalid row has been committed")
+	}
+	if count := countAssets(t, db); count != 0 {
+		t.Errorf("rolled back batch left %d rows", count)
+	}
+
+	// A valid batch is committed
+	err = WithTx(db, func(tx Executor) error {
+		_, errs := InsertEntries(tx, TX_ASSETS[:2])
+		return errors.Join(errs...)
+	})
+	if err != nil {
+		t.Errorf("error committing the batch: %v", err)
+	}
+	if count := countAssets(t, db); count != 2 {
+		t.Errorf("committed batch has %d rows, wanted 2", count)
+	}
+
+	// A failing nested transaction only rolls back its savepoint
+	err = WithTx(db, func(tx Executor) error {
+		if _, err := InsertEntry(tx, TX_ASSETS[0]); err != nil {
+			return err
+		}
+
+		nestedErr := WithTx(tx, func(nested Executor) error {
+			_, err := InsertEntry(nested, TX_ASSETS[2])
+			return err
+		})
+		if nestedErr == nil {
+			return fmt.Errorf("nested invalid row has been inserted")
+		}
+
+		_, err := InsertEntry(tx, TX_ASSETS[1])
+		return err
+	})
+	if err != nil {
+		t.Errorf("error committing the outer transaction: %v", err)
+	}
+	if count := countAssets(t, db); count != 4 {
+		t.Errorf("outer transaction has %d rows, wanted 4", count)
+	}
+}
+
+func TestWithTxRetryFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	attempts := 0
+	err := WithTxContext(context.Background(), db, TxConfig{Retries: 2}, func(tx Executor) error {
+		attempts++
+		if attempts < 3 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("transaction not retried correctly, attempts: %d, error: %v", attempts, err)
+	}
+
+	attempts = 0
+	err = WithTxContext(context.Background(), db, TxConfig{Retries: 2}, func(tx Executor) error {
+		attempts++
+		return &pq.Error{Code: "23505"}
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("non serialization failure has been retried, attempts: %d", attempts)
+	}
+}
+
+func TestWithReadOnlyTxFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{})
+
+	if _, err := InsertEntry(db, TX_ASSETS[0]); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	// Reads succeed and writes are rejected
+	err := WithReadOnlyTx(db, func(tx Executor) error {
+		if count := countAssets(t, tx); count != 1 {
+			t.Errorf("read only transaction sees %d rows, wanted 1", count)
+		}
+		_, err := MakeQuery(tx, "DELETE FROM Asset")
+		return err
+	})
+	if !errors.Is(err, ErrReadOnlyViolation) {
+		t.Errorf("read only transaction has written, given: %v", err)
+	}
+
+	// Inside a transaction the read only savepoint restores the write mode
+	err = WithTx(db, func(tx Executor) error {
+		roErr := WithReadOnlyTx(tx, func(ro Executor) error {
+			_, err := MakeQuery(ro, "UPDATE Asset SET decimals = 8")
+			return err
+		})
+		if !errors.Is(roErr, ErrReadOnlyViolation) {
+			return fmt.Errorf("read only savepoint has written, given: %v", roErr)
+		}
+
+		_, err := InsertEntry(tx, TX_ASSETS[1])
+		return err
+	})
+	if err != nil {
+		t.Errorf("error writing after the read only savepoint: %v", err)
+	}
+	if count := countAssets(t, db); count != 2 {
+		t.Errorf("wrong assets after the transactions: %d, wanted 2", count)
+	}
+}
@@ -1,36 +1,67 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"strings"
 
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
 )
 
-// Takes multiple rows to add to the table, it adds them
-// respecting the order
+// Takes multiple rows to add to their tables, it inserts them in bulk, see
+// BulkInsertEntries, and in order
 //
 // Parameters:
 //   - db:		the database struct
 //   - data:	the structs rows
 //
 // Returns:
-//   - []sql.Result:	an array of results of the same length as entries
-//   - []error:			an array of errors of the queries
-func InsertEntries[T any](db *sql.DB, data []T) ([]sql.Result, []error) {
-	var results []sql.Result
-	var errors []error
+//   - []sql.Result:	an array of results of the same length as entries, nil for failed rows
+//   - []error:			an array of errors of the same length as entries, nil for inserted rows
+func InsertEntries[T any](db Executor, data []T) ([]sql.Result, []error) {
+	return InsertEntriesContext(context.Background(), db, data)
+}
+
+// Takes multiple rows to add to their tables, it inserts them in bulk, see
+// BulkInsertEntries, and in order. The insertion is aborted as soon as ctx
+// is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - data:	the structs rows
+//
+// Returns:
+//   - []sql.Result:	an array of results of the same length as entries, nil for failed rows
+//   - []error:			an array of errors of the same length as entries, nil for inserted rows
+func InsertEntriesContext[T any](ctx context.Context, db Executor, data []T) ([]sql.Result, []error) {
+	results := make([]sql.Result, len(data))
+	errs := make([]error, len(data))
+
+	// Rows of different tables are inserted a run of the same table at a time
+	for start := 0; start < len(data); {
+		tt := reflect.TypeOf(data[start])
+		end := start + 1
+		for end < len(data) && reflect.TypeOf(data[end]) == tt {
+			end++
+		}
 
-	for _, d := range data {
-		result, err := InsertEntry(db, d)
+		_, runErrs := BulkInsertEntriesContext(ctx, db, data[start:end])
+		copy(errs[start:end], runErrs)
+		start = end
+	}
 
-		results = append(results, result)
-		errors = append(errors, err)
+	for i, err := range errs {
+		if err == nil {
+			results[i] = driver.RowsAffected(1)
+		}
 	}
 
-	return results, errors
+	return results, errs
 }
 
 // Takes multiple queries, it checks and performs them
@@ -42,34 +73,89 @@ func InsertEntries[T any](db *sql.DB, data []T) ([]sql.Result, []error) {
 // Returns:
 //   - sql.Result:	an array of results of the same length as entries
 //   - error:		if an error occured during the process
-func InsertEntry(db *sql.DB, data any) (sql.Result, error) {
+func InsertEntry(db Executor, data any) (sql.Result, error) {
+	return InsertEntryContext(context.Background(), db, data)
+}
+
+// Takes a struct row and inserts it into its table, which must exist
+// The insertion is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - data:	the struct row
+//
+// Returns:
+//   - sql.Result:	the insertion result
+//   - error:		if an error occured during the process
+func InsertEntryContext(ctx context.Context, db Executor, data any) (sql.Result, error) {
 	// Check if it is a struct
 	ty := reflect.TypeOf(data)
 	if !utils.ValidateStruct(ty) {
-		return nil, fmt.Errorf("data format is wrong")
+		return nil, invalidData(data)
 	}
 
-	// Create table if it doesn't exist
-	exists, err := CheckIfTableExists(db, data)
-	if err != nil && err != ErrTableExists {
+	// Build SQL query
+	query, args, err := ParseStructToEntryWithArgs(ty, reflect.ValueOf(data))
+	if err != nil {
 		return nil, err
 	}
-	if err != ErrTableExists && !exists {
-		CreateTable(db, data)
+
+	// Make Query
+	return MakeQueryContext(ctx, db, query, args...)
+}
+
+// Takes a table row, it inserts it into its table, which must exist, and
+// returns the primary key the row was given
+//
+// Parameters:
+//   - db:		the database struct
+//   - data:	the table row
+//
+// Returns:
+//   - int64:	the row primary key
+//   - error:	if an error occured during the process
+func InsertEntryReturningId(db Executor, data types.Table) (int64, error) {
+	return InsertEntryReturningIdContext(context.Background(), db, data)
+}
+
+// Takes a table row, it inserts it into its table, which must exist, and
+// returns the primary key the row was given. The insertion is aborted as
+// soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - data:	the table row
+//
+// Returns:
+//   - int64:	the row primary key
+//   - error:	if an error occured during the process
+func InsertEntryReturningIdContext(ctx context.Context, db Executor, data types.Table) (int64, error) {
+	ty := reflect.TypeOf(data)
+	if !utils.ValidateStruct(ty) {
+		return 0, invalidData(data)
 	}
 
-	// Build SQL query
-	query, err := ParseStructToEntry(ty, reflect.ValueOf(data))
+	primaryKey, err := data.GetPrimaryKeyNameDB()
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	// Make Query
-	return MakeQuery(db, query)
+	query, args, err := ParseStructToEntryWithArgs(ty, reflect.ValueOf(data))
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = db.QueryRowContext(ctx, query+"\nRETURNING "+primaryKey, args...).Scan(&id)
+	return id, wrapError(err)
 }
 
 // Parses the value to the correct SQL formatting based on type
-// Correctly supports: string, bool, integers, unsigned integers
+// Correctly supports: string (quotes are escaped), bool, integers, unsigned integers
+// and the types mapped by driverValue: time.Time, pointers, []byte, *big.Int,
+// types.Decimal, json.RawMessage, maps and slices
 // Doesn't support: runes (retunred as digit)
 // Other types will be converted to string through their interface
 //
@@ -80,9 +166,19 @@ func InsertEntry(db *sql.DB, data any) (sql.Result, error) {
 //   - string:	the formatted SQL value
 //   - error:	if any error occured during parsing
 func ParseValueToEntry(value reflect.Value) string {
+	// Interface, format the held value
+	if value.Kind() == reflect.Interface && !value.IsNil() {
+		return ParseValueToEntry(value.Elem())
+	}
+
+	// Mapped types
+	if isMappedValue(value) {
+		return literalValue(value)
+	}
+
 	// String
 	if value.Kind() == reflect.String {
-		return fmt.Sprintf(`'%s'`, value.String())
+		return fmt.Sprintf(`'%s'`, strings.ReplaceAll(value.String(), "'", "''"))
 	}
 
 	// Bool
@@ -98,6 +194,8 @@ func ParseValueToEntry(value reflect.Value) string {
 }
 
 // Parses the struct into a insertion query with its current parameters
+// Values are written as literals, use ParseStructToEntryWithArgs to
+// build the query sent to the database
 //
 // Parameters:
 //   - data:	the struct to be inserted in the database
@@ -106,6 +204,56 @@ func ParseValueToEntry(value reflect.Value) string {
 //   - string:	the insertion query
 //   - error:	if any error occured during parsing
 func ParseStructToEntry(data reflect.Type, value reflect.Value) (string, error) {
+	return parseStructToEntry(data, value, ParseValueToEntry)
+}
+
+// Parses the struct into a parameterized insertion query with its current
+// parameters, values are referenced as $1..$n placeholders
+//
+// Parameters:
+//   - data:	the struct to be inserted in the database
+//
+// Returns:
+//   - string:	the insertion query
+//   - []any:	the values bound to the query placeholders
+//   - error:	if any error occured during parsing
+func ParseStructToEntryWithArgs(data reflect.Type, value reflect.Value) (string, []any, error) {
+	var qa queryArgs
+
+	query, err := parseStructToEntry(data, value, qa.parameter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return query, qa.values, nil
+}
+
+// Parses the struct into a insertion query, values are written with format
+//
+// Parameters:
+//   - data:	the struct to be inserted in the database
+//   - value:	the struct value
+//   - format:	the formatter of every value
+//
+// Returns:
+//   - string:	the insertion query
+//   - error:	if any error occured during parsing
+func parseStructToEntry(data reflect.Type, value reflect.Value, format valueFormatter) (string, error) {
+	return parseStructsToEntries(data, []reflect.Value{value}, format)
+}
+
+// Parses many structs of the same type into a single multi-row insertion
+// query, values are written with format
+//
+// Parameters:
+//   - data:	the struct to be inserted in the database
+//   - values:	the structs values, one row each
+//   - format:	the formatter of every value
+//
+// Returns:
+//   - string:	the insertion query
+//   - error:	if any error occured during parsing
+func parseStructsToEntries(data reflect.Type, values []reflect.Value, format valueFormatter) (string, error) {
 	// Check it is a struct
 	if !utils.ValidateStruct(data) {
 		return "", fmt.Errorf("cannot parse non-struct into insertion query: %v", data)
@@ -124,7 +272,7 @@ func ParseStructToEntry(data reflect.Type, value reflect.Value) (string, error)
 		f := data.Field(i)
 
 		// Check if there is a nested struct other than types.Default
-		if utils.ValidateStruct(f.Type) && !utils.ValidateCustomStruct(f.Type) {
+		if utils.ValidateStruct(f.Type) && !utils.ValidateCustomStruct(f.Type) && !utils.ValidateValueStruct(f.Type) {
 			return "", fmt.Errorf("cannot have nested not custom struct as tables: %v", f)
 		}
 
@@ -143,16 +291,48 @@ func ParseStructToEntry(data reflect.Type, value reflect.Value) (string, error)
 	}
 
 	builder.WriteString(")\n")
-	builder.WriteString("VALUES (")
+	builder.WriteString("VALUES ")
 
 	// Add Values
+	for i, value := range values {
+		row, err := parseStructToValues(data, value, format)
+		if err != nil {
+			return "", err
+		}
+
+		builder.WriteString(row)
+
+		if i == len(values)-1 {
+			continue
+		}
+		builder.WriteString(",\n")
+	}
+
+	return builder.String(), nil
+}
+
+// Parses the struct values into a row of an insertion query
+//
+// Parameters:
+//   - data:	the struct type
+//   - value:	the struct value
+//   - format:	the formatter of every value
+//
+// Returns:
+//   - string:	the row values between parentheses
+//   - error:	if any error occured during parsing
+func parseStructToValues(data reflect.Type, value reflect.Value, format valueFormatter) (string, error) {
+	var builder strings.Builder
+	builder.WriteString("(")
+
+	numFields := data.NumField()
 	for i := 0; i < numFields; i++ {
 		f := data.Field(i)
 		v := value.Field(i)
 
 		// Check if there is a nested struct other than types.Default
-		if utils.ValidateStruct(f.Type) {
-			val, err := ParseCustomStruct(f.Type, v)
+		if utils.ValidateStruct(f.Type) && !utils.ValidateValueStruct(f.Type) {
+			val, err := parseCustomStruct(f.Type, v, format)
 			if err != nil {
 				return "", err
 			}
@@ -168,7 +348,7 @@ func ParseStructToEntry(data reflect.Type, value reflect.Value) (string, error)
 		}
 
 		// Write value
-		builder.WriteString(ParseValueToEntry(v))
+		builder.WriteString(format(v))
 
 		if i == numFields-1 {
 			continue
@@ -181,7 +361,7 @@ func ParseStructToEntry(data reflect.Type, value reflect.Value) (string, error)
 }
 
 // Parses the custom struct into the correct insertion query parameter
-// Supported structs: Default, Null
+// Supported structs: Default, Null, Timestamp
 //
 // Parameters:
 //   - t:	the reflect.Type of the struct
@@ -191,6 +371,21 @@ func ParseStructToEntry(data reflect.Type, value reflect.Value) (string, error)
 //   - string:	the insertion query parameter
 //   - error:	if any error occured during parsing
 func ParseCustomStruct(t reflect.Type, v reflect.Value) (string, error) {
+	return parseCustomStruct(t, v, ParseValueToEntry)
+}
+
+// Parses the custom struct into the correct insertion query parameter,
+// its value is written with format
+//
+// Parameters:
+//   - t:		the reflect.Type of the struct
+//   - v:		the reflect.Value of the struct
+//   - format:	the formatter of the struct value
+//
+// Returns:
+//   - string:	the insertion query parameter
+//   - error:	if any error occured during parsing
+func parseCustomStruct(t reflect.Type, v reflect.Value, format valueFormatter) (string, error) {
 	if utils.ValidateDefaultStruct(t) {
 		defaultField := v.FieldByName("Default")
 		valueField := v.FieldByName("Value")
@@ -200,7 +395,7 @@ func ParseCustomStruct(t reflect.Type, v reflect.Value) (string, error) {
 			return "DEFAULT", nil
 		} else {
 			// Write value
-			return fmt.Sprint(valueField.Interface()), nil
+			return format(valueField), nil
 		}
 	}
 
@@ -213,7 +408,7 @@ func ParseCustomStruct(t reflect.Type, v reflect.Value) (string, error) {
 			return "NULL", nil
 		} else {
 			// Write value
-			return fmt.Sprint(valueField.Interface()), nil
+			return format(valueField), nil
 		}
 	}
 
@@ -226,7 +421,7 @@ func ParseCustomStruct(t reflect.Type, v reflect.Value) (string, error) {
 			return "NOW()", nil
 		} else {
 			// Write value
-			return fmt.Sprintf("TO_TIMESTAMP(%d)", unixField.Interface()), nil
+			return fmt.Sprintf("TO_TIMESTAMP(%s)", format(unixField)), nil
 		}
 	}
 
@@ -4,8 +4,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
-	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
 )
 
 // Parse struct to entry
@@ -114,6 +114,107 @@ func TestParseStructToEntry(t *testing.T) {
 	}
 }
 
+// Parse struct to parameterized entry
+var PARSING_TO_ENTRY_WITH_ARGS = []TestInputWithArgs{
+	{
+		Input: types.Asset{
+			Id: types.Default[uint64]{
+				Default: true,
+				Value:   1,
+			},
+			Ticker:   "BTC'); DROP TABLE Asset; --",
+			Source:   "Binance",
+			Decimals: 18,
+		},
+		Correct: `INSERT INTO Asset (id, ticker, source, decimals)
+VALUES (DEFAULT, $1, $2, $3)`,
+		Args: []any{"BTC'); DROP TABLE Asset; --", "Binance", int8(18)},
+	},
+	{
+		Input: types.Price{
+			Id: types.Default[int64]{
+				Default: false,
+				Value:   7,
+			},
+			Asset_id: 12,
+			Price:    444,
+			Timestamp: types.Timestamp{
+				Now:  false,
+				Unix: 1724440501,
+			},
+		},
+		Correct: `INSERT INTO Price (id, asset_id, price, timestamp)
+VALUES ($1, $2, $3, TO_TIMESTAMP($4))`,
+		Args: []any{int64(7), 12, 444, 1724440501},
+	},
+	{
+		Input: types.Price{
+			Id: types.Default[int64]{
+				Default: true,
+				Value:   0,
+			},
+			Asset_id: 12,
+			Price:    444,
+			Timestamp: types.Timestamp{
+				Now:  true,
+				Unix: 1,
+			},
+		},
+		Correct: `INSERT INTO Price (id, asset_id, price, timestamp)
+VALUES (DEFAULT, $1, $2, NOW())`,
+		Args: []any{12, 444},
+	},
+}
+
+func TestParseStructToEntryWithArgsFunc(t *testing.T) {
+	for _, ps := range PARSING_TO_ENTRY_WITH_ARGS {
+		str, args, err := ParseStructToEntryWithArgs(reflect.TypeOf(ps.Input), reflect.ValueOf(ps.Input))
+		if err != nil {
+			t.Errorf("error during parsing: %v", err)
+			continue
+		}
+
+		if str != ps.Correct {
+			t.Errorf("incorrect parsing:\n%v\n%v", str, ps.Correct)
+		}
+
+		if !reflect.DeepEqual(args, ps.Args) {
+			t.Errorf("incorrect arguments:\ngiven %v\nwanted %v", args, ps.Args)
+		}
+	}
+}
+
+func TestInsertEntryQuotedValueFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{})
+
+	asset := PARSING_TO_ENTRY_WITH_ARGS[0].Input.(types.Asset)
+	if _, err := InsertEntry(db, asset); err != nil {
+		t.Fatalf("error when adding entry: %v", err)
+	}
+
+	rows, err := SelectTableByMatchColumns(db, types.Asset{}, nil, []types.ColumnRef{types.AssetTicker}, []any{asset.Ticker}, -1)
+	if err != nil {
+		t.Fatalf("error when selecting rows: %v", err)
+	}
+	defer rows.Close()
+
+	var assets []types.Asset
+	for rows.Next() {
+		a := types.Asset{}
+
+		err := ScanRowToStruct(rows, reflect.ValueOf(&a).Elem())
+		if err != nil {
+			t.Errorf("error when scanning row to struct: %v", err)
+		}
+		assets = append(assets, a)
+	}
+
+	if len(assets) != 1 || assets[0].Ticker != asset.Ticker {
+		t.Errorf("quoted ticker retrived incorrectly: %v", assets)
+	}
+}
+
 // Add entry
 var INSERT_ENTRY = []TestAddEntryInput{
 	{
@@ -170,14 +271,10 @@ var INSERT_ENTRY = []TestAddEntryInput{
 
 func TestInsertEntryFunc(t *testing.T) {
 	// Start Mock db
-	_, db, cleanup, err := InitMockSqlDB()
-	if err != nil {
-		utils.HandleFatalError(err)
-	}
-	defer cleanup()
+	db := dbtest.DB(t)
 
 	// Check connection
-	err = db.Ping()
+	err := db.Ping()
 	if err != nil {
 		t.Errorf("Failed to connect to the database: %v", err)
 		return
@@ -231,6 +328,10 @@ var PARSING_VALUE_TO_ENTRY = []TestInput{
 		Input:   -123,
 		Correct: `-123`,
 	},
+	{
+		Input:   "it's",
+		Correct: "'it''s'",
+	},
 }
 
 func TestParseValueToEntryFunc(t *testing.T) {
@@ -298,3 +399,18 @@ func TestParseCustomStructFunc(t *testing.T) {
 		}
 	}
 }
+
+func TestInsertEntryReturningIdFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{})
+
+	for i := 1; i <= 2; i++ {
+		id, err := InsertEntryReturningId(db, BULK_ASSET)
+		if err != nil {
+			t.Fatalf("error inserting the asset: %v", err)
+		}
+		if id != int64(i) {
+			t.Errorf("wrong id: given %d, wanted %d", id, i)
+		}
+	}
+}
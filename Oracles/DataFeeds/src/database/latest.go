@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Refreshes the latest_price view, without blocking its readers. The view
+// is created by the migrations, see migrations/sql/0006_latest_price.up.sql
+//
+// Parameters:
+//   - db:	the database struct
+//
+// Returns:
+//   - error:	if an error occured
+func RefreshLatestPrices(db Executor) error {
+	return RefreshLatestPricesContext(context.Background(), db)
+}
+
+// Refreshes the latest_price view, without blocking its readers. The view
+// is created by the migrations, see migrations/sql/0006_latest_price.up.sql.
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:	the context bounding the query
+//   - db:	the database struct
+//
+// Returns:
+//   - error:	if an error occured
+func RefreshLatestPricesContext(ctx context.Context, db Executor) error {
+	_, err := db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY latest_price")
+	return wrapError(err)
+}
+
+// Selects the latest price of every asset from the latest_price view, as
+// of its last refresh
+//
+// Parameters:
+//   - db:	the database struct
+//
+// Returns:
+//   - []types.Price:	the prices ordered by asset id
+//   - error:			if an error occured
+func LatestPrices(db Executor) ([]types.Price, error) {
+	return LatestPricesContext(context.Background(), db)
+}
+
+// Selects the latest price of every asset from the latest_price view, as
+// of its last refresh. The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:	the context bounding the query
+//   - db:	the database struct
+//
+// Returns:
+//   - []types.Price:	the prices ordered by asset id
+//   - error:			if an error occured
+func LatestPricesContext(ctx context.Context, db Executor) ([]types.Price, error) {
+	return SelectIntoContext[types.Price](ctx, db, "SELECT * FROM latest_price ORDER BY asset_id")
+}
+
+// Refreshes the latest_price view every interval, starting immediately,
+// until ctx is done
+//
+// Parameters:
+//   - ctx:			the context stopping the schedule
+//   - db:			the database struct
+//   - interval:	the time between the refreshes
+//   - onRefresh:	called with the outcome of every refresh, it may be nil
+//
+// Returns:
+//   - error:	the ctx error
+func ScheduleLatestPricesRefresh(ctx context.Context, db Executor, interval time.Duration, onRefresh func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := RefreshLatestPricesContext(ctx, db)
+		if onRefresh != nil {
+			onRefresh(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refreshes the latest_price view on the prices inserted, notified by the
+// listener. The inserts of a debounce window are refreshed at once. As
+// prices inserted while the listener reconnects are not notified, it is
+// meant to be paired with a slower ScheduleLatestPricesRefresh
+//
+// Parameters:
+//   - ctx:			the context stopping the refreshes
+//   - db:			the database struct
+//   - listener:	the price listener
+//   - debounce:	the wait after an insert before refreshing
+//   - onRefresh:	called with the outcome of every refresh, it may be nil
+//
+// Returns:
+//   - error:	the ctx error, ErrListenerClosed if the listener is closed
+func RefreshLatestPricesOnInsert(ctx context.Context, db Executor, listener *PriceListener, debounce time.Duration, onRefresh func(error)) error {
+	prices, unsubscribe, err := listener.Subscribe(1)
+	if err != nil {
+		return err
+	}
+	defer unsubscribe()
+
+	return debounceRefresh(ctx, prices, debounce, func() error {
+		return RefreshLatestPricesContext(ctx, db)
+	}, onRefresh)
+}
+
+// Calls refresh once per debounce window holding received prices. A full
+// subscription buffer drops prices, which is harmless as any of them
+// triggers the same refresh
+//
+// Parameters:
+//   - ctx:			the context stopping the refreshes
+//   - prices:		the inserted prices
+//   - debounce:	the wait after a price before refreshing
+//   - refresh:		the refresh
+//   - onRefresh:	called with the outcome of every refresh, it may be nil
+//
+// Returns:
+//   - error:	the ctx error, ErrListenerClosed if prices is closed
+func debounceRefresh(ctx context.Context, prices <-chan types.Price, debounce time.Duration, refresh func() error, onRefresh func(error)) error {
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case _, ok := <-prices:
+			if !ok {
+				return ErrListenerClosed
+			}
+			if pending == nil {
+				pending = time.After(debounce)
+			}
+
+		case <-pending:
+			pending = nil
+			err := refresh()
+			if onRefresh != nil {
+				onRefresh(err)
+			}
+		}
+	}
+}
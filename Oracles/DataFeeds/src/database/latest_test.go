@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+func TestDebounceRefreshFunc(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	prices := make(chan types.Price)
+	refreshed := make(chan error, 8)
+	var refreshes atomic.Int32
+
+	done := make(chan error)
+	go func() {
+		done <- debounceRefresh(ctx, prices, 50*time.Millisecond, func() error {
+			refreshes.Add(1)
+			return nil
+		}, func(err error) {
+			refreshed <- err
+		})
+	}()
+
+	// A burst of inserts is refreshed once
+	for i := 0; i < 5; i++ {
+		prices <- types.Price{Price: i}
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatalf("burst has not been refreshed")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := refreshes.Load(); n != 1 {
+		t.Errorf("burst has been refreshed %d times", n)
+	}
+
+	// A later insert is refreshed again
+	prices <- types.Price{Price: 5}
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatalf("insert has not been refreshed")
+	}
+
+	// Closing the subscription stops the refreshes
+	close(prices)
+	if err := <-done; !errors.Is(err, ErrListenerClosed) {
+		t.Errorf("closed subscription should stop the refreshes, given: %v", err)
+	}
+}
+
+func TestLatestPricesFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	models, err := NewRegistry(types.Asset{}, types.Price{})
+	if err != nil {
+		t.Fatalf("error creating the registry: %v", err)
+	}
+	if err := models.EnsureSchema(db); err != nil {
+		t.Fatalf("error creating the schema: %v", err)
+	}
+	applyMigrations(t, db, 6)
+
+	const assets = 4
+	const pricesPerAsset = 50
+	for i := 0; i < assets; i++ {
+		if _, err := InsertEntry(db, TX_ASSETS[0]); err != nil {
+			t.Fatalf("error inserting the asset: %v", err)
+		}
+	}
+
+	if prices, err := LatestPrices(db); err != nil || len(prices) != 0 {
+		t.Fatalf("empty view has prices: %v, %v", prices, err)
+	}
+
+	// Every asset is written by its own goroutine while the view is
+	// refreshed concurrently
+	ctx, cancel := context.WithCancel(context.Background())
+	refreshing := make(chan struct{})
+	go func() {
+		defer close(refreshing)
+		ScheduleLatestPricesRefresh(ctx, db, 5*time.Millisecond, func(err error) {
+			if err != nil && ctx.Err() == nil {
+				t.Errorf("error refreshing the view: %v", err)
+			}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for a := 1; a <= assets; a++ {
+		wg.Add(1)
+		go func(assetId int) {
+			defer wg.Done()
+			for i := 1; i <= pricesPerAsset; i++ {
+				_, err := InsertEntry(db, types.Price{
+					Id:        types.Default[int64]{Default: true},
+					Asset_id:  assetId,
+					Price:     assetId*1000 + i,
+					Timestamp: types.Timestamp{Unix: 1704067200 + i*60},
+				})
+				if err != nil {
+					t.Errorf("error inserting the price: %v", err)
+					return
+				}
+
+				// The view is never ahead of the inserts
+				latest, err := LatestPrices(db)
+				if err != nil {
+					t.Errorf("error selecting the latest prices: %v", err)
+					return
+				}
+				for _, p := range latest {
+					if p.Asset_id == assetId && p.Price > assetId*1000+i {
+						t.Errorf("view is ahead of the inserts: %+v", p)
+					}
+				}
+			}
+		}(a)
+	}
+	wg.Wait()
+	cancel()
+	<-refreshing
+
+	if err := RefreshLatestPrices(db); err != nil {
+		t.Fatalf("error refreshing the view: %v", err)
+	}
+
+	latest, err := LatestPrices(db)
+	if err != nil {
+		t.Fatalf("error selecting the latest prices: %v", err)
+	}
+	expected, err := SelectInto[types.Price](db, "SELECT DISTINCT ON (asset_id) * FROM Price ORDER BY asset_id, timestamp DESC")
+	if err != nil {
+		t.Fatalf("error selecting the expected prices: %v", err)
+	}
+
+	if len(latest) != assets || len(latest) != len(expected) {
+		t.Fatalf("wrong latest prices count: %d, wanted %d", len(latest), len(expected))
+	}
+	for i := range latest {
+		if latest[i] != expected[i] || latest[i].Price != (i+1)*1000+pricesPerAsset {
+			t.Errorf("wrong latest price of asset %d: %+v, wanted %+v", i+1, latest[i], expected[i])
+		}
+	}
+}
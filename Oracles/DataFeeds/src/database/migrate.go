@@ -0,0 +1,475 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrDirtyMigration        = errors.New("database is in a dirty migration state")
+	ErrDuplicateMigration    = errors.New("duplicate migration version")
+	ErrUnknownMigration      = errors.New("applied migration is unknown")
+	ErrIrreversibleMigration = errors.New("migration has no down step")
+)
+
+// Advisory lock key held while migrating, shared by every instance
+const MigrationLockKey int64 = 7346915620218457
+
+// Ledger of the applied migrations
+const migrationsTable = "schema_migrations"
+
+// SQL migration file names: <version>_<name>.<up|down>.sql
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration step, running inside a transaction
+type MigrationFunc func(ctx context.Context, tx Executor) error
+
+// Numbered schema migration, Down is optional
+type Migration struct {
+	Version int64
+	Name    string
+	Up      MigrationFunc
+	Down    MigrationFunc
+}
+
+// Status of a migration in the ledger
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations tracked in the schema_migrations ledger,
+// every operation runs under the MigrationLockKey advisory lock
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// Returns a migration running the up and down SQL statements,
+// an empty down makes the migration irreversible
+//
+// Parameters:
+//   - version:	the migration version
+//   - name:	the migration name
+//   - up:		the up statements
+//   - down:	the down statements
+//
+// Returns:
+//   - Migration:	the migration
+func SQLMigration(version int64, name string, up string, down string) Migration {
+	m := Migration{
+		Version: version,
+		Name:    name,
+		Up:      execMigration(up),
+	}
+	if down != "" {
+		m.Down = execMigration(down)
+	}
+
+	return m
+}
+
+// Returns a migration step executing the statements
+func execMigration(statements string) MigrationFunc {
+	return func(ctx context.Context, tx Executor) error {
+		_, err := tx.ExecContext(ctx, statements)
+		return err
+	}
+}
+
+// Loads the SQL migrations of the directory, files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+// Parameters:
+//   - fsys:	the file system, usually an embed.FS
+//   - dir:		the migrations directory
+//
+// Returns:
+//   - []Migration:	the migrations sorted by version
+//   - error:		if any file is malformed or an up step is missing
+func LoadSQLMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type files struct {
+		name string
+		up   string
+		down string
+	}
+	found := make(map[int64]*files)
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		match := migrationFileRegex.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		f, ok := found[version]
+		if !ok {
+			f = &files{name: match[2]}
+			found[version] = f
+		}
+		if f.name != match[2] {
+			return nil, fmt.Errorf("%w: %d is both %s and %s", ErrDuplicateMigration, version, f.name, match[2])
+		}
+
+		if match[3] == "up" {
+			f.up = string(content)
+		} else {
+			f.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(found))
+	for version, f := range found {
+		if f.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up step", version, f.name)
+		}
+		migrations = append(migrations, SQLMigration(version, f.name, f.up, f.down))
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Returns a migrator of the migrations
+//
+// Parameters:
+//   - db:			the database struct
+//   - migrations:	the migrations, versions must be positive and unique
+//
+// Returns:
+//   - *Migrator:	the migrator
+//   - error:		if the migrations are not valid
+func NewMigrator(db *sql.DB, migrations ...Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %s has a non positive version %d", m.Name, m.Version)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d_%s has no up step", m.Version, m.Name)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateMigration, m.Version)
+		}
+	}
+
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+// Applies every pending migration in version order
+//
+// Parameters:
+//   - ctx:	the context bounding the migration
+//
+// Returns:
+//   - int:		the number of applied migrations
+//   - error:	if the database is dirty or a migration failed
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		ledger, err := readLedger(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(ledger); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := ledger[migration.Version]; ok {
+				continue
+			}
+
+			if err := applyMigration(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Reverts the last applied migrations
+//
+// Parameters:
+//   - ctx:		the context bounding the migration
+//   - steps:	the number of migrations to revert
+//
+// Returns:
+//   - int:		the number of reverted migrations
+//   - error:	if the database is dirty or a migration failed
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		ledger, err := readLedger(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(ledger); err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(ledger))
+		for v := range ledger {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i] > versions[j]
+		})
+
+		for _, v := range versions {
+			if reverted == steps {
+				break
+			}
+
+			migration, ok := m.find(v)
+			if !ok {
+				return fmt.Errorf("%w: %d", ErrUnknownMigration, v)
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversibleMigration, v, migration.Name)
+			}
+
+			if err := applyMigration(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			reverted++
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Returns the status of every known or applied migration
+//
+// Parameters:
+//   - ctx:	the context bounding the query
+//
+// Returns:
+//   - []MigrationStatus:	the statuses sorted by version
+//   - error:				if an error occured
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		ledger, err := readLedger(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status, ok := ledger[migration.Version]
+			if !ok {
+				status = MigrationStatus{Version: migration.Version, Name: migration.Name}
+			}
+			delete(ledger, migration.Version)
+			statuses = append(statuses, status)
+		}
+
+		// Applied migrations unknown to the migrator
+		for _, status := range ledger {
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, err
+}
+
+// Forces the ledger state of a migration, to recover from a dirty state
+// once the database has been fixed by hand
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - version:	the migration version
+//   - applied:	whether the migration is recorded as applied or removed
+//
+// Returns:
+//   - error:	if an error occured
+func (m *Migrator) Force(ctx context.Context, version int64, applied bool) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		if !applied {
+			_, err := conn.ExecContext(ctx, "DELETE FROM "+migrationsTable+" WHERE version = $1", version)
+			return err
+		}
+
+		name := ""
+		if migration, ok := m.find(version); ok {
+			name = migration.Name
+		}
+
+		_, err := conn.ExecContext(ctx, "INSERT INTO "+migrationsTable+" (version, name, dirty)\nVALUES ($1, $2, FALSE)\nON CONFLICT (version) DO UPDATE SET dirty = FALSE", version, name)
+		return err
+	})
+}
+
+// Returns the known migration of the version
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// Runs fn on a connection holding the migration advisory lock,
+// the ledger table is created if missing
+//
+// Parameters:
+//   - ctx:	the context bounding the operation
+//   - fn:	the operation
+//
+// Returns:
+//   - error:	if an error occured
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", MigrationLockKey); err != nil {
+		return err
+	}
+	defer func() {
+		// Unlock even if ctx is done
+		_, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", MigrationLockKey)
+		err = errors.Join(err, unlockErr)
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+migrationsTable+` (
+	version BIGINT PRIMARY KEY,
+	name TEXT NOT NULL,
+	dirty BOOLEAN NOT NULL DEFAULT FALSE,
+	applied_at TIMESTAMP DEFAULT NOW() NOT NULL
+)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// Reads the ledger rows by version
+func readLedger(ctx context.Context, conn *sql.Conn) (map[int64]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, dirty, applied_at FROM "+migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ledger := make(map[int64]MigrationStatus)
+	for rows.Next() {
+		status := MigrationStatus{Applied: true}
+		if err := rows.Scan(&status.Version, &status.Name, &status.Dirty, &status.AppliedAt); err != nil {
+			return nil, err
+		}
+		ledger[status.Version] = status
+	}
+
+	return ledger, rows.Err()
+}
+
+// Returns ErrDirtyMigration if any ledger row is dirty
+func checkDirty(ledger map[int64]MigrationStatus) error {
+	for _, status := range ledger {
+		if status.Dirty {
+			return fmt.Errorf("%w: migration %d_%s did not complete, fix the database and force its state", ErrDirtyMigration, status.Version, status.Name)
+		}
+	}
+
+	return nil
+}
+
+// Applies a migration step. The ledger row is marked dirty before the
+// step runs, and cleaned in the step transaction, so a failure leaves the
+// database dirty until forced
+//
+// Parameters:
+//   - ctx:			the context bounding the migration
+//   - conn:		the locked connection
+//   - migration:	the migration
+//   - up:			whether the up or down step runs
+//
+// Returns:
+//   - error:	if an error occured
+func applyMigration(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	var err error
+	if up {
+		_, err = conn.ExecContext(ctx, "INSERT INTO "+migrationsTable+" (version, name, dirty)\nVALUES ($1, $2, TRUE)", migration.Version, migration.Name)
+	} else {
+		_, err = conn.ExecContext(ctx, "UPDATE "+migrationsTable+" SET dirty = TRUE WHERE version = $1", migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = WithTxContext(ctx, conn, TxConfig{}, func(tx Executor) error {
+		if up {
+			if err := migration.Up(ctx, tx); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, "UPDATE "+migrationsTable+" SET dirty = FALSE, applied_at = NOW() WHERE version = $1", migration.Version)
+			return err
+		}
+
+		if err := migration.Down(ctx, tx); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM "+migrationsTable+" WHERE version = $1", migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+)
+
+// Load SQL migrations
+type LoadMigrationsInput struct {
+	Files    fstest.MapFS
+	Versions []int64
+	Err      bool
+}
+
+var LOAD_MIGRATIONS = []LoadMigrationsInput{
+	{
+		Files: fstest.MapFS{
+			"sql/0002_add_volume.up.sql":   {Data: []byte("ALTER TABLE Price ADD COLUMN volume BIGINT;")},
+			"sql/0001_create.up.sql":       {Data: []byte("CREATE TABLE Volume (id SERIAL PRIMARY KEY);")},
+			"sql/0001_create.down.sql":     {Data: []byte("DROP TABLE Volume;")},
+			"sql/README.md":                {Data: []byte("not a migration")},
+			"sql/0002_add_volume.down.sql": {Data: []byte("ALTER TABLE Price DROP COLUMN volume;")},
+		},
+		Versions: []int64{1, 2},
+	},
+	{
+		Files: fstest.MapFS{
+			"sql/0001_create.down.sql": {Data: []byte("DROP TABLE Volume;")},
+		},
+		Err: true,
+	},
+	{
+		Files: fstest.MapFS{
+			"sql/0001_create.up.sql": {Data: []byte("CREATE TABLE Volume (id SERIAL PRIMARY KEY);")},
+			"sql/0001_other.up.sql":  {Data: []byte("CREATE TABLE Other (id SERIAL PRIMARY KEY);")},
+		},
+		Err: true,
+	},
+}
+
+func TestLoadSQLMigrationsFunc(t *testing.T) {
+	for _, lm := range LOAD_MIGRATIONS {
+		migrations, err := LoadSQLMigrations(lm.Files, "sql")
+		if (err != nil) != lm.Err {
+			t.Errorf("wrong error: %v", err)
+			continue
+		}
+
+		if len(migrations) != len(lm.Versions) {
+			t.Errorf("wrong number of migrations: given %d, wanted %d", len(migrations), len(lm.Versions))
+			continue
+		}
+		for i, m := range migrations {
+			if m.Version != lm.Versions[i] || m.Up == nil || m.Down == nil {
+				t.Errorf("wrong migration: %v", m)
+			}
+		}
+	}
+}
+
+func TestNewMigratorFunc(t *testing.T) {
+	if _, err := NewMigrator(nil, SQLMigration(1, "a", "SELECT 1", ""), SQLMigration(1, "b", "SELECT 1", "")); !errors.Is(err, ErrDuplicateMigration) {
+		t.Errorf("wrong error: given %v, wanted %v", err, ErrDuplicateMigration)
+	}
+
+	if _, err := NewMigrator(nil, SQLMigration(0, "a", "SELECT 1", "")); err == nil {
+		t.Errorf("non positive version accepted")
+	}
+
+	if _, err := NewMigrator(nil, Migration{Version: 1, Name: "a"}); err == nil {
+		t.Errorf("migration without up step accepted")
+	}
+}
+
+// Migrate up and down
+var TEST_MIGRATIONS = []Migration{
+	SQLMigration(1, "create_volume", "CREATE TABLE Volume (id SERIAL PRIMARY KEY);", "DROP TABLE Volume;"),
+	SQLMigration(2, "add_amount", "ALTER TABLE Volume ADD COLUMN amount BIGINT NOT NULL DEFAULT 0;", "ALTER TABLE Volume DROP COLUMN amount;"),
+}
+
+func TestMigratorFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(db, TEST_MIGRATIONS...)
+	if err != nil {
+		t.Fatalf("error creating the migrator: %v", err)
+	}
+
+	// Up
+	applied, err := migrator.Up(ctx)
+	if err != nil || applied != 2 {
+		t.Fatalf("error migrating up: applied %d, %v", applied, err)
+	}
+	if _, err := db.Exec("INSERT INTO Volume (amount) VALUES (1)"); err != nil {
+		t.Errorf("migrated schema is wrong: %v", err)
+	}
+
+	// Idempotent
+	if applied, err := migrator.Up(ctx); err != nil || applied != 0 {
+		t.Errorf("error migrating up again: applied %d, %v", applied, err)
+	}
+
+	// Down
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil || reverted != 1 {
+		t.Fatalf("error migrating down: reverted %d, %v", reverted, err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("error reading the status: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("wrong status: %v", statuses)
+	}
+
+	// Failing migration leaves the database dirty
+	failing, err := NewMigrator(db, append(TEST_MIGRATIONS, SQLMigration(3, "broken", "ALTER TABLE Missing ADD COLUMN a INTEGER;", ""))...)
+	if err != nil {
+		t.Fatalf("error creating the migrator: %v", err)
+	}
+	if _, err := failing.Up(ctx); err == nil {
+		t.Fatalf("broken migration applied")
+	}
+	if _, err := failing.Up(ctx); !errors.Is(err, ErrDirtyMigration) {
+		t.Errorf("wrong error: given %v, wanted %v", err, ErrDirtyMigration)
+	}
+
+	// Forced recovery
+	if err := failing.Force(ctx, 3, false); err != nil {
+		t.Fatalf("error forcing the migration state: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Errorf("error migrating up after recovery: %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrListenerClosed        = errors.New("price listener is closed")
+	ErrNotificationsDisabled = errors.New("price notifications are not enabled")
+)
+
+// Channel the inserted prices are notified on
+const PriceChannel = "price"
+
+// Default reconnection waits of the price listener
+const (
+	DefaultMinReconnectInterval = time.Second
+	DefaultMaxReconnectInterval = time.Minute
+)
+
+// Idle time after which the listener pings the connection, so that a
+// silently dropped connection is detected
+const listenerPingInterval = 90 * time.Second
+
+// Payload of a price notification
+type priceNotification struct {
+	Id        int64 `json:"id"`
+	AssetId   int   `json:"asset_id"`
+	Price     int   `json:"price"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Checks that every inserted price is notified on PriceChannel. The
+// trigger is created by the migrations, see
+// migrations/sql/0005_price_notify.up.sql
+//
+// Parameters:
+//   - db:	the database struct
+//
+// Returns:
+//   - error:	ErrNotificationsDisabled if the trigger is missing, error if occured
+func CheckPriceNotifications(db Executor) error {
+	return CheckPriceNotificationsContext(context.Background(), db)
+}
+
+// Checks that every inserted price is notified on PriceChannel. The
+// trigger is created by the migrations, see
+// migrations/sql/0005_price_notify.up.sql.
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:	the context bounding the query
+//   - db:	the database struct
+//
+// Returns:
+//   - error:	ErrNotificationsDisabled if the trigger is missing, error if occured
+func CheckPriceNotificationsContext(ctx context.Context, db Executor) error {
+	exists, err := triggerExistsContext(ctx, db, "price", "price_notify")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotificationsDisabled
+	}
+
+	return nil
+}
+
+// Price listener configuration
+type ListenerConfig struct {
+	// Waits before reconnecting, doubling from min to max, the defaults
+	// are used if zero
+	MinReconnectInterval time.Duration
+	MaxReconnectInterval time.Duration
+	// Called after the connection has been reestablished, it must not block
+	OnReconnect func()
+	// Called on connection and payload errors, it must not block
+	OnError func(err error)
+}
+
+// Listener of the inserted prices, fanning them out to its subscribers.
+// The connection is reestablished automatically, prices inserted while it
+// is down are not notified so OnReconnect should resynchronise the state.
+// Subscribers not keeping up miss the prices their buffer cannot hold
+type PriceListener struct {
+	listener *pq.Listener
+	config   ListenerConfig
+
+	mu          sync.Mutex
+	subscribers map[int]chan types.Price
+	nextId      int
+	dropped     uint64
+	closed      bool
+
+	// Set while an idle ping is in flight
+	pinging atomic.Bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// Opens a listener of the inserted prices, it blocks until the first
+// connection is established
+//
+// Parameters:
+//   - dsn:		the data source name, see Config.DSN
+//   - config:	the listener configuration
+//
+// Returns:
+//   - *PriceListener:	the listener
+//   - error:			if the channel cannot be listened
+func NewPriceListener(dsn string, config ListenerConfig) (*PriceListener, error) {
+	if config.MinReconnectInterval == 0 {
+		config.MinReconnectInterval = DefaultMinReconnectInterval
+	}
+	if config.MaxReconnectInterval == 0 {
+		config.MaxReconnectInterval = DefaultMaxReconnectInterval
+	}
+
+	l := &PriceListener{
+		config:      config,
+		subscribers: make(map[int]chan types.Price),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+
+	l.listener = pq.NewListener(dsn, config.MinReconnectInterval, config.MaxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			l.reportError(err)
+		}
+	})
+
+	if err := l.listener.Listen(PriceChannel); err != nil {
+		l.listener.Close()
+		return nil, err
+	}
+
+	go l.run()
+	return l, nil
+}
+
+// Subscribes to the inserted prices
+//
+// Parameters:
+//   - buffer:	the channel buffer, prices not fitting are dropped
+//
+// Returns:
+//   - <-chan types.Price:	the prices, closed by unsubscribe and Close
+//   - func():				the unsubscribe function
+//   - error:				ErrListenerClosed if the listener is closed
+func (l *PriceListener) Subscribe(buffer int) (<-chan types.Price, func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, nil, ErrListenerClosed
+	}
+
+	id := l.nextId
+	l.nextId++
+	prices := make(chan types.Price, buffer)
+	l.subscribers[id] = prices
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			if _, ok := l.subscribers[id]; ok {
+				delete(l.subscribers, id)
+				close(prices)
+			}
+		})
+	}
+
+	return prices, unsubscribe, nil
+}
+
+// Returns the number of prices dropped by subscribers not keeping up
+//
+// Returns:
+//   - uint64:	the dropped prices
+func (l *PriceListener) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.dropped
+}
+
+// Stops listening and closes every subscriber channel
+//
+// Returns:
+//   - error:	if the connection cannot be closed
+func (l *PriceListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	close(l.done)
+	<-l.stopped
+	err := l.listener.Close()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, prices := range l.subscribers {
+		delete(l.subscribers, id)
+		close(prices)
+	}
+
+	return err
+}
+
+// Receives the notifications until the listener is closed
+func (l *PriceListener) run() {
+	defer close(l.stopped)
+
+	for {
+		select {
+		case <-l.done:
+			return
+
+		case n := <-l.listener.Notify:
+			// A nil notification follows a reconnection
+			if n == nil {
+				if l.config.OnReconnect != nil {
+					l.config.OnReconnect()
+				}
+				continue
+			}
+
+			price, err := decodePriceNotification(n.Extra)
+			if err != nil {
+				l.reportError(err)
+				continue
+			}
+			l.publish(price)
+
+		case <-time.After(listenerPingInterval):
+			// The ping waits for the notifications to be received, it runs
+			// aside so that they keep being read, one at a time
+			if l.pinging.CompareAndSwap(false, true) {
+				go l.ping()
+			}
+		}
+	}
+}
+
+// Pings the listener connection, so that a dead one is reconnected
+func (l *PriceListener) ping() {
+	defer l.pinging.Store(false)
+
+	if err := l.listener.Ping(); err != nil {
+		l.reportError(err)
+	}
+}
+
+// Sends the price to every subscriber with room for it
+func (l *PriceListener) publish(price types.Price) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, prices := range l.subscribers {
+		select {
+		case prices <- price:
+		default:
+			l.dropped++
+		}
+	}
+}
+
+// Reports the error to OnError if set
+func (l *PriceListener) reportError(err error) {
+	if l.config.OnError != nil {
+		l.config.OnError(err)
+	}
+}
+
+// Decodes a price notification payload
+//
+// Parameters:
+//   - payload:	the notification payload
+//
+// Returns:
+//   - types.Price:	the inserted price
+//   - error:		if the payload is malformed
+func decodePriceNotification(payload string) (types.Price, error) {
+	var n priceNotification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return types.Price{}, err
+	}
+
+	return types.Price{
+		Id:       types.Default[int64]{Value: n.Id},
+		Asset_id: n.AssetId,
+		Price:    n.Price,
+		Timestamp: types.Timestamp{
+			Datetime: time.Unix(n.Timestamp, 0).UTC().Format(time.RFC3339Nano),
+			Unix:     int(n.Timestamp),
+		},
+	}, nil
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+var PRICE_NOTIFICATION_SAMPLES = []TestInput{
+	{
+		Input: `{"id" : 7, "asset_id" : 2, "price" : 4200, "timestamp" : 1704067200}`,
+		Correct: types.Price{
+			Id:        types.Default[int64]{Value: 7},
+			Asset_id:  2,
+			Price:     4200,
+			Timestamp: types.Timestamp{Datetime: "2024-01-01T00:00:00Z", Unix: 1704067200},
+		},
+	},
+	{
+		Input:   `{"id" : "7"}`,
+		Correct: nil,
+	},
+	{
+		Input:   `not json`,
+		Correct: nil,
+	},
+}
+
+func TestDecodePriceNotificationFunc(t *testing.T) {
+	for _, s := range PRICE_NOTIFICATION_SAMPLES {
+		price, err := decodePriceNotification(s.Input.(string))
+		if s.Correct == nil {
+			if err == nil {
+				t.Errorf("malformed payload %s has been decoded: %+v", s.Input, price)
+			}
+			continue
+		}
+
+		if err != nil || price != s.Correct {
+			t.Errorf("wrong price decoded from %s, wanted %+v, given %+v: %v", s.Input, s.Correct, price, err)
+		}
+	}
+}
+
+func TestPriceListenerFanOutFunc(t *testing.T) {
+	l := &PriceListener{subscribers: make(map[int]chan types.Price)}
+
+	first, unsubscribeFirst, err := l.Subscribe(2)
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+	second, _, err := l.Subscribe(1)
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+
+	l.publish(types.Price{Price: 1})
+	l.publish(types.Price{Price: 2})
+
+	// The second subscriber buffer only holds the first price
+	if p := <-first; p.Price != 1 {
+		t.Errorf("wrong first price: %d", p.Price)
+	}
+	if p := <-first; p.Price != 2 {
+		t.Errorf("wrong second price: %d", p.Price)
+	}
+	if p := <-second; p.Price != 1 {
+		t.Errorf("wrong first price: %d", p.Price)
+	}
+	if dropped := l.Dropped(); dropped != 1 {
+		t.Errorf("wrong dropped prices: %d", dropped)
+	}
+
+	// Unsubscribing closes the channel, twice is a no-op
+	unsubscribeFirst()
+	unsubscribeFirst()
+	if _, ok := <-first; ok {
+		t.Errorf("unsubscribed channel is still open")
+	}
+
+	l.publish(types.Price{Price: 3})
+	if p := <-second; p.Price != 3 {
+		t.Errorf("wrong price after unsubscribing: %d", p.Price)
+	}
+}
+
+func TestPriceListenerFunc(t *testing.T) {
+	test := dbtest.New(t)
+	db := test.DB
+
+	models, err := NewRegistry(types.Asset{}, types.Price{})
+	if err != nil {
+		t.Fatalf("error creating the registry: %v", err)
+	}
+	if err := models.EnsureSchema(db); err != nil {
+		t.Fatalf("error creating the schema: %v", err)
+	}
+	if err := CheckPriceNotifications(db); !errors.Is(err, ErrNotificationsDisabled) {
+		t.Errorf("notifications enabled before the migration: %v", err)
+	}
+	applyMigrations(t, db, 5)
+	if err := CheckPriceNotifications(db); err != nil {
+		t.Fatalf("notifications not enabled by the migration: %v", err)
+	}
+	if _, err := InsertEntry(db, TX_ASSETS[0]); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	listener, err := NewPriceListener(test.DSN, ListenerConfig{})
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer listener.Close()
+
+	first, _, err := listener.Subscribe(8)
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+	second, _, err := listener.Subscribe(8)
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+
+	// Wait for the listener connection
+	if err := listener.listener.Ping(); err != nil {
+		t.Fatalf("listener is not connected: %v", err)
+	}
+
+	// The notified timestamp does not depend on the session time zone
+	err = WithTx(db, func(tx Executor) error {
+		if _, err := MakeQuery(tx, "SET LOCAL TIME ZONE 'America/New_York'"); err != nil {
+			return err
+		}
+		_, err := InsertEntry(tx, types.Price{
+			Id:        types.Default[int64]{Default: true},
+			Asset_id:  1,
+			Price:     4200,
+			Timestamp: types.Timestamp{Unix: 1704067200},
+		})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("error inserting the price: %v", err)
+	}
+
+	for _, prices := range []<-chan types.Price{first, second} {
+		select {
+		case p := <-prices:
+			if p.Asset_id != 1 || p.Price != 4200 || p.Timestamp.Unix != 1704067200 || p.Id.Value == 0 {
+				t.Errorf("wrong notified price: %+v", p)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("price has not been notified")
+		}
+	}
+
+	// Closing ends every subscription
+	if err := listener.Close(); err != nil {
+		t.Errorf("error closing the listener: %v", err)
+	}
+	if _, ok := <-first; ok {
+		t.Errorf("subscription is still open after closing")
+	}
+	if _, _, err := listener.Subscribe(1); !errors.Is(err, ErrListenerClosed) {
+		t.Errorf("closed listener accepted a subscription, given: %v", err)
+	}
+}
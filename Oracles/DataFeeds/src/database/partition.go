@@ -0,0 +1,377 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+var (
+	ErrNotPartitioned        = errors.New("table is not range partitioned")
+	ErrUnsupportedPartitions = errors.New("unsupported partition interval")
+)
+
+// Default number of partitions created ahead of the current one
+const DefaultPartitionsAhead = 3
+
+// Layout of the partition time bounds
+const partitionBoundLayout = "2006-01-02 15:04:05"
+
+// Time span of each partition
+type PartitionInterval string
+
+const (
+	PartitionDaily   PartitionInterval = "daily"
+	PartitionMonthly PartitionInterval = "monthly"
+)
+
+// Range partition of a table, holding the rows within [From, To)
+type Partition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// PartitionManager creates the range partitions of a table ahead of time
+// and drops the expired ones. The table must be partitioned by RANGE over
+// a single TIMESTAMP column through its `partition` tag
+//
+// Bounds follow the wall clock of the timestamp column, as read from the
+// database session
+type PartitionManager struct {
+	table    reflect.Type
+	name     string
+	column   string
+	interval PartitionInterval
+	pattern  *regexp.Regexp
+
+	// Partitions created ahead of the current one
+	Ahead int
+}
+
+// Returns the partition manager of the table
+//
+// Parameters:
+//   - table:		the struct table
+//   - interval:	the time span of each partition
+//
+// Returns:
+//   - *PartitionManager:	the partition manager
+//   - error:				if the table is not range partitioned or the interval not supported
+func NewPartitionManager(table types.Table, interval PartitionInterval) (*PartitionManager, error) {
+	tt := reflect.TypeOf(table)
+	if !utils.ValidateStruct(tt) {
+		return nil, ErrNotValidTable
+	}
+
+	p, err := getPartitioning(tt)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || p.Method != "RANGE" || len(p.Columns) != 1 {
+		return nil, ErrNotPartitioned
+	}
+
+	if _, err := interval.layout(); err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(utils.BaseTypeName(tt))
+	return &PartitionManager{
+		table:    tt,
+		name:     name,
+		column:   p.Columns[0],
+		interval: interval,
+		pattern:  regexp.MustCompile(`^` + name + `_p(\d+)$`),
+		Ahead:    DefaultPartitionsAhead,
+	}, nil
+}
+
+// Returns the layout of the partition name suffix
+func (i PartitionInterval) layout() (string, error) {
+	switch i {
+	case PartitionDaily:
+		return "20060102", nil
+	case PartitionMonthly:
+		return "200601", nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedPartitions, i)
+}
+
+// Returns the partition holding t
+//
+// Parameters:
+//   - t:	the wall clock time
+//
+// Returns:
+//   - Partition:	the partition
+func (m *PartitionManager) PartitionOf(t time.Time) Partition {
+	var from, to time.Time
+	switch m.interval {
+	case PartitionMonthly:
+		from = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		to = from.AddDate(0, 1, 0)
+	default:
+		from = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		to = from.AddDate(0, 0, 1)
+	}
+
+	layout, _ := m.interval.layout()
+	return Partition{
+		Name: m.name + "_p" + from.Format(layout),
+		From: from,
+		To:   to,
+	}
+}
+
+// Creates the current partition and the Ahead following ones if missing
+//
+// Parameters:
+//   - db:	the database struct
+//
+// Returns:
+//   - int:		the created partitions
+//   - error:	if an error occured
+func (m *PartitionManager) EnsurePartitions(db Executor) (int, error) {
+	return m.EnsurePartitionsContext(context.Background(), db)
+}
+
+// Creates the current partition and the Ahead following ones if missing.
+// Each partition is created detached, it takes over the rows of its range
+// from the default partition and it is then attached, inside a transaction
+//
+// Parameters:
+//   - ctx:	the context bounding the queries
+//   - db:	the database struct
+//
+// Returns:
+//   - int:		the created partitions
+//   - error:	if an error occured
+func (m *PartitionManager) EnsurePartitionsContext(ctx context.Context, db Executor) (int, error) {
+	existing, err := m.PartitionsContext(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	names := make(map[string]bool)
+	for _, p := range existing {
+		names[p.Name] = true
+	}
+
+	now, err := localNow(ctx, db, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	p := m.PartitionOf(now)
+	for i := 0; i <= m.Ahead; i++ {
+		if !names[p.Name] {
+			if err := m.createPartition(ctx, db, p); err != nil {
+				return created, err
+			}
+			created++
+		}
+		p = m.PartitionOf(p.To)
+	}
+
+	return created, nil
+}
+
+// Returns the partitions of the table, ordered by range
+//
+// Parameters:
+//   - db:	the database struct
+//
+// Returns:
+//   - []Partition:	the partitions
+//   - error:		if the table is not partitioned or an error occured
+func (m *PartitionManager) Partitions(db Executor) ([]Partition, error) {
+	return m.PartitionsContext(context.Background(), db)
+}
+
+// Returns the partitions of the table, ordered by range. Only the
+// partitions named by the manager are listed
+//
+// Parameters:
+//   - ctx:	the context bounding the queries
+//   - db:	the database struct
+//
+// Returns:
+//   - []Partition:	the partitions
+//   - error:		if the table is not partitioned or an error occured
+func (m *PartitionManager) PartitionsContext(ctx context.Context, db Executor) ([]Partition, error) {
+	var partitioned bool
+	err := db.QueryRowContext(ctx, `SELECT EXISTS (
+	SELECT 1 FROM pg_class
+	WHERE relname = $1 AND relkind = 'p' AND relnamespace = current_schema()::regnamespace
+)`, m.name).Scan(&partitioned)
+	if err != nil {
+		return nil, err
+	}
+	if !partitioned {
+		return nil, ErrNotPartitioned
+	}
+
+	children, err := readLiveNames(ctx, db, `SELECT c.relname
+FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+JOIN pg_class p ON p.oid = i.inhparent
+WHERE p.relname = $1 AND p.relnamespace = current_schema()::regnamespace`, m.name)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, _ := m.interval.layout()
+	var partitions []Partition
+	for _, child := range children {
+		match := m.pattern.FindStringSubmatch(child)
+		if match == nil {
+			continue
+		}
+
+		from, err := time.Parse(layout, match[1])
+		if err != nil {
+			continue
+		}
+		partitions = append(partitions, m.PartitionOf(from))
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].From.Before(partitions[j].From)
+	})
+	return partitions, nil
+}
+
+// Returns the partitions whose whole range is older than retention
+//
+// Parameters:
+//   - ctx:			the context bounding the queries
+//   - db:			the database struct
+//   - retention:	the age after which rows expire
+//
+// Returns:
+//   - []Partition:	the expired partitions
+//   - error:		if an error occured
+func (m *PartitionManager) ExpiredPartitionsContext(ctx context.Context, db Executor, retention time.Duration) ([]Partition, error) {
+	partitions, err := m.PartitionsContext(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff, err := localNow(ctx, db, retention)
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []Partition
+	for _, p := range partitions {
+		if !p.To.After(cutoff) {
+			expired = append(expired, p)
+		}
+	}
+
+	return expired, nil
+}
+
+// Drops the partitions whose whole range is older than retention
+//
+// Parameters:
+//   - db:			the database struct
+//   - retention:	the age after which rows expire
+//
+// Returns:
+//   - int:		the dropped partitions
+//   - error:	if an error occured
+func (m *PartitionManager) DropExpiredPartitions(db Executor, retention time.Duration) (int, error) {
+	return m.DropExpiredPartitionsContext(context.Background(), db, retention)
+}
+
+// Drops the partitions whose whole range is older than retention, the
+// queries are aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:			the context bounding the queries
+//   - db:			the database struct
+//   - retention:	the age after which rows expire
+//
+// Returns:
+//   - int:		the dropped partitions
+//   - error:	if an error occured
+func (m *PartitionManager) DropExpiredPartitionsContext(ctx context.Context, db Executor, retention time.Duration) (int, error) {
+	expired, err := m.ExpiredPartitionsContext(ctx, db, retention)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, p := range expired {
+		if err := m.DropPartitionContext(ctx, db, p); err != nil {
+			return i, err
+		}
+	}
+
+	return len(expired), nil
+}
+
+// Drops the partition with its rows
+//
+// Parameters:
+//   - ctx:	the context bounding the query
+//   - db:	the database struct
+//   - p:	the partition
+//
+// Returns:
+//   - error:	if an error occured
+func (m *PartitionManager) DropPartitionContext(ctx context.Context, db Executor, p Partition) error {
+	_, err := MakeQueryContext(ctx, db, "DROP TABLE IF EXISTS "+p.Name)
+	return err
+}
+
+// Creates the partition, moving the rows of its range out of the default
+// partition before attaching it
+func (m *PartitionManager) createPartition(ctx context.Context, db Executor, p Partition) error {
+	defaultName := m.name + "_default"
+	from, to := p.From.Format(partitionBoundLayout), p.To.Format(partitionBoundLayout)
+
+	return WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+		_, err := MakeQueryContext(ctx, tx, fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS)", p.Name, m.name))
+		if err != nil {
+			return err
+		}
+
+		var hasDefault bool
+		if err := tx.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", defaultName).Scan(&hasDefault); err != nil {
+			return err
+		}
+		if hasDefault {
+			bounds := fmt.Sprintf("%s >= $1 AND %s < $2", m.column, m.column)
+			_, err := MakeQueryContext(ctx, tx, fmt.Sprintf("INSERT INTO %s SELECT * FROM %s WHERE %s", p.Name, defaultName, bounds), from, to)
+			if err != nil {
+				return err
+			}
+			_, err = MakeQueryContext(ctx, tx, fmt.Sprintf("DELETE FROM %s WHERE %s", defaultName, bounds), from, to)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = MakeQueryContext(ctx, tx, fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')", m.name, p.Name, from, to))
+		return err
+	})
+}
+
+// Returns the wall clock of the database session, minus age
+func localNow(ctx context.Context, db Executor, age time.Duration) (time.Time, error) {
+	var now time.Time
+	err := db.QueryRowContext(ctx, "SELECT LOCALTIMESTAMP - make_interval(secs => $1)", age.Seconds()).Scan(&now)
+
+	return now, err
+}
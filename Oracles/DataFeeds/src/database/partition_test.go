@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+type TestPartitionedStruct struct {
+	Id  types.Default[int64] `json:"id"  db:"id SERIAL PRIMARY KEY"`
+	Day types.Timestamp      `json:"day" db:"day TIMESTAMP NOT NULL" partition:"LIST (day)"`
+}
+
+func (t TestPartitionedStruct) GetPrimaryKeyNameDB() (string, error) {
+	return "id", nil
+}
+
+type TestMalformedPartitionStruct struct {
+	Id  types.Default[int64] `json:"id"  db:"id SERIAL PRIMARY KEY"`
+	Day types.Timestamp      `json:"day" db:"day TIMESTAMP NOT NULL" partition:"RANGE day"`
+}
+
+func (t TestMalformedPartitionStruct) GetPrimaryKeyNameDB() (string, error) {
+	return "id", nil
+}
+
+func TestNewPartitionManagerFunc(t *testing.T) {
+	if _, err := NewPartitionManager(types.Price{}, PartitionDaily); err != nil {
+		t.Errorf("price table not partitioned: %v", err)
+	}
+	if _, err := NewPartitionManager(types.Asset{}, PartitionDaily); !errors.Is(err, ErrNotPartitioned) {
+		t.Errorf("unpartitioned table accepted: %v", err)
+	}
+	if _, err := NewPartitionManager(TestPartitionedStruct{}, PartitionDaily); !errors.Is(err, ErrNotPartitioned) {
+		t.Errorf("list partitioned table accepted: %v", err)
+	}
+	if _, err := NewPartitionManager(TestMalformedPartitionStruct{}, PartitionDaily); err == nil {
+		t.Errorf("malformed partition tag accepted")
+	}
+	if _, err := NewPartitionManager(types.Price{}, "weekly"); !errors.Is(err, ErrUnsupportedPartitions) {
+		t.Errorf("unsupported interval accepted: %v", err)
+	}
+}
+
+var PARTITION_TIME = time.Date(2024, 8, 25, 12, 30, 0, 0, time.UTC)
+
+var PARTITIONS = map[PartitionInterval]Partition{
+	PartitionDaily: {
+		Name: "price_p20240825",
+		From: time.Date(2024, 8, 25, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 8, 26, 0, 0, 0, 0, time.UTC),
+	},
+	PartitionMonthly: {
+		Name: "price_p202408",
+		From: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+func TestPartitionOfFunc(t *testing.T) {
+	for interval, correct := range PARTITIONS {
+		m, err := NewPartitionManager(types.Price{}, interval)
+		if err != nil {
+			t.Fatalf("error creating the manager: %v", err)
+		}
+
+		if p := m.PartitionOf(PARTITION_TIME); p != correct {
+			t.Errorf("wrong %s partition: given %v, wanted %v", interval, p, correct)
+		}
+		if p := m.PartitionOf(correct.To.Add(-time.Nanosecond)); p != correct {
+			t.Errorf("wrong %s partition of the range end: %v", interval, p)
+		}
+	}
+}
+
+func TestParsePartitionedStructToTableFunc(t *testing.T) {
+	query, err := ParseStructToTable(reflect.TypeOf(TestPartitionedStruct{}))
+	if err != nil {
+		t.Fatalf("error parsing the struct: %v", err)
+	}
+
+	correct := `CREATE TABLE IF NOT EXISTS TestPartitionedStruct (
+	id SERIAL,
+	day TIMESTAMP NOT NULL,
+	PRIMARY KEY (id, day)
+) PARTITION BY LIST (day);
+CREATE TABLE IF NOT EXISTS TestPartitionedStruct_default PARTITION OF TestPartitionedStruct DEFAULT;`
+	if query != correct {
+		t.Errorf("incorrect parsing:\n%v\n%v", query, correct)
+	}
+
+	if _, err := ParseStructToTable(reflect.TypeOf(TestMalformedPartitionStruct{})); err == nil {
+		t.Errorf("malformed partition tag accepted")
+	}
+}
+
+func TestPartitionManagerFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	models, err := NewRegistry(types.Asset{}, types.Price{})
+	if err != nil {
+		t.Fatalf("error creating the registry: %v", err)
+	}
+	if err := models.EnsureSchema(db); err != nil {
+		t.Fatalf("error creating the schema: %v", err)
+	}
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	// Lands in the default partition
+	if _, err := InsertEntry(db, types.Price{Id: types.Default[int64]{Default: true}, Asset_id: 1, Price: 10, Timestamp: types.Timestamp{Now: true}}); err != nil {
+		t.Fatalf("error inserting the price: %v", err)
+	}
+
+	m, err := NewPartitionManager(types.Price{}, PartitionDaily)
+	if err != nil {
+		t.Fatalf("error creating the manager: %v", err)
+	}
+	m.Ahead = 1
+
+	created, err := m.EnsurePartitions(db)
+	if err != nil || created != 2 {
+		t.Fatalf("wrong partitions creation: %d %v", created, err)
+	}
+	if created, err := m.EnsurePartitions(db); err != nil || created != 0 {
+		t.Errorf("partitions created twice: %d %v", created, err)
+	}
+
+	partitions, err := m.Partitions(db)
+	if err != nil || len(partitions) != 2 || partitions[0].To != partitions[1].From {
+		t.Fatalf("wrong partitions: %v %v", partitions, err)
+	}
+
+	// The price moved out of the default partition
+	var moved int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + partitions[0].Name).Scan(&moved); err != nil || moved != 1 {
+		t.Errorf("price not moved into its partition: %d %v", moved, err)
+	}
+
+	dropped, err := m.DropExpiredPartitions(db, time.Hour)
+	if err != nil || dropped != 0 {
+		t.Errorf("current partitions dropped: %d %v", dropped, err)
+	}
+	dropped, err = m.DropExpiredPartitionsContext(context.Background(), db, -48*time.Hour)
+	if err != nil || dropped != 2 {
+		t.Errorf("wrong dropped partitions: %d %v", dropped, err)
+	}
+
+	prices, err := SelectInto[types.Price](db, "SELECT * FROM Price")
+	if err != nil || len(prices) != 0 {
+		t.Errorf("prices of the dropped partitions kept: %v %v", prices, err)
+	}
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Origin of the candle buckets, buckets of every resolution are aligned to it
+const candleOrigin = "TIMESTAMP '2000-01-01 00:00:00'"
+
+// Selects the prices of the asset within [from, to), ordered by timestamp.
+// The query is served by the (asset_id, timestamp) unique index
+//
+// Parameters:
+//   - db:		the database struct
+//   - assetId:	the asset id
+//   - from:	the first included instant
+//   - to:		the first excluded instant
+//
+// Returns:
+//   - []types.Price:	the prices
+//   - error:			error if occured
+func SelectPricesInRange(db Executor, assetId int, from time.Time, to time.Time) ([]types.Price, error) {
+	return SelectPricesInRangeContext(context.Background(), db, assetId, from, to)
+}
+
+// Selects the prices of the asset within [from, to), ordered by timestamp.
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - assetId:	the asset id
+//   - from:	the first included instant
+//   - to:		the first excluded instant
+//
+// Returns:
+//   - []types.Price:	the prices
+//   - error:			error if occured
+func SelectPricesInRangeContext(ctx context.Context, db Executor, assetId int, from time.Time, to time.Time) ([]types.Price, error) {
+	// Instants are bound as on insertion, through TO_TIMESTAMP
+	qb := NewQueryBuilder(types.Price{}).
+		Where(
+			types.Where(types.PriceAssetId, types.Equal, assetId),
+			types.Where(types.PriceTimestamp, types.GreaterEqual, types.Timestamp{Unix: int(from.Unix())}),
+			types.Where(types.PriceTimestamp, types.Less, types.Timestamp{Unix: int(to.Unix())}),
+		).
+		OrderBy(types.PriceTimestamp, false)
+
+	return ScanRows[types.Price](SelectWhereContext(ctx, db, qb))
+}
+
+// Aggregates the prices of the asset within [from, to) into OHLC candles
+// of the resolution, ordered by bucket. Buckets without prices are omitted
+//
+// Parameters:
+//   - db:			the database struct
+//   - assetId:		the asset id
+//   - resolution:	the candle resolution
+//   - from:		the first included instant
+//   - to:			the first excluded instant
+//
+// Returns:
+//   - []types.Candle:	the candles
+//   - error:			error if occured
+func SelectCandles(db Executor, assetId int, resolution types.Resolution, from time.Time, to time.Time) ([]types.Candle, error) {
+	return SelectCandlesContext(context.Background(), db, assetId, resolution, from, to)
+}
+
+// Aggregates the prices of the asset within [from, to) into OHLC candles
+// of the resolution, ordered by bucket. Buckets without prices are omitted.
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:			the context bounding the query
+//   - db:			the database struct
+//   - assetId:		the asset id
+//   - resolution:	the candle resolution
+//   - from:		the first included instant
+//   - to:			the first excluded instant
+//
+// Returns:
+//   - []types.Candle:	the candles
+//   - error:			error if occured
+func SelectCandlesContext(ctx context.Context, db Executor, assetId int, resolution types.Resolution, from time.Time, to time.Time) ([]types.Candle, error) {
+	query, err := buildCandlesQuery(resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	return SelectIntoContext[types.Candle](ctx, db, query, assetId, from.Unix(), to.Unix())
+}
+
+// Builds the candles query of the resolution, it binds the asset id,
+// from and to unix timestamps
+//
+// Parameters:
+//   - resolution:	the candle resolution
+//
+// Returns:
+//   - string:	the query
+//   - error:	if the resolution is not supported
+func buildCandlesQuery(resolution types.Resolution) (string, error) {
+	query, err := buildCandlesSelect(resolution, "Price", "timestamp >= TO_TIMESTAMP($2) AND timestamp < TO_TIMESTAMP($3)")
+	if err != nil {
+		return "", err
+	}
+
+	return query + "\nORDER BY bucket ASC", nil
+}
+
+// Builds the aggregation of the prices of the asset bound as $1 into
+// candles of the resolution, the columns follow the Candle table order
+//
+// Parameters:
+//   - resolution:	the candle resolution
+//   - source:		the prices table or partition
+//   - bounds:		the timestamp condition
+//
+// Returns:
+//   - string:	the query
+//   - error:	if the resolution is not supported
+func buildCandlesSelect(resolution types.Resolution, source string, bounds string) (string, error) {
+	duration, err := resolution.Duration()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`SELECT asset_id, '%s' AS resolution, date_bin('%d seconds', timestamp, %s) AS bucket,
+	(array_agg(price ORDER BY timestamp ASC))[1] AS open,
+	MAX(price) AS high,
+	MIN(price) AS low,
+	(array_agg(price ORDER BY timestamp DESC))[1] AS close,
+	COUNT(*) AS count,
+	MIN(timestamp) AS opened_at,
+	MAX(timestamp) AS closed_at
+FROM %s
+WHERE asset_id = $1 AND %s
+GROUP BY asset_id, bucket`, resolution, int(duration.Seconds()), candleOrigin, source, bounds), nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Build candles query
+func TestBuildCandlesQueryFunc(t *testing.T) {
+	query, err := buildCandlesQuery(types.Resolution5m)
+	if err != nil {
+		t.Fatalf("error building the query: %v", err)
+	}
+
+	correct := `SELECT asset_id, '5m' AS resolution, date_bin('300 seconds', timestamp, TIMESTAMP '2000-01-01 00:00:00') AS bucket,
+	(array_agg(price ORDER BY timestamp ASC))[1] AS open,
+	MAX(price) AS high,
+	MIN(price) AS low,
+	(array_agg(price ORDER BY timestamp DESC))[1] AS close,
+	COUNT(*) AS count,
+	MIN(timestamp) AS opened_at,
+	MAX(timestamp) AS closed_at
+FROM Price
+WHERE asset_id = $1 AND timestamp >= TO_TIMESTAMP($2) AND timestamp < TO_TIMESTAMP($3)
+GROUP BY asset_id, bucket
+ORDER BY bucket ASC`
+	if query != correct {
+		t.Errorf("incorrect query:\n%v\n%v", query, correct)
+	}
+
+	if _, err := buildCandlesQuery("2m"); err == nil {
+		t.Errorf("unsupported resolution accepted")
+	}
+}
+
+// Range and candles
+var CANDLE_START = time.Date(2024, 8, 25, 12, 0, 0, 0, time.UTC)
+
+// Prices every 30 seconds over 3 minutes
+var CANDLE_PRICES = []int{100, 120, 90, 110, 130, 80}
+
+var CANDLES = []types.Candle{
+	{Asset_id: 1, Resolution: types.Resolution1m, Open: 100, High: 120, Low: 100, Close: 120, Count: 2},
+	{Asset_id: 1, Resolution: types.Resolution1m, Open: 90, High: 110, Low: 90, Close: 110, Count: 2},
+	{Asset_id: 1, Resolution: types.Resolution1m, Open: 130, High: 130, Low: 80, Close: 80, Count: 2},
+}
+
+func TestSelectCandlesFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{}, types.Price{})
+
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	prices := make([]types.Price, len(CANDLE_PRICES))
+	for i, p := range CANDLE_PRICES {
+		prices[i] = types.Price{
+			Id:        types.Default[int64]{Default: true},
+			Asset_id:  1,
+			Price:     p,
+			Timestamp: types.Timestamp{Unix: int(CANDLE_START.Unix()) + 30*i},
+		}
+	}
+	_, errs := InsertEntries(db, prices)
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("error inserting the prices: %v", err)
+	}
+
+	// Range
+	ranged, err := SelectPricesInRange(db, 1, CANDLE_START.Add(30*time.Second), CANDLE_START.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("error selecting the range: %v", err)
+	}
+	if len(ranged) != 3 || ranged[0].Price != 120 || ranged[2].Price != 110 {
+		t.Errorf("wrong range: %v", ranged)
+	}
+
+	// Candles
+	candles, err := SelectCandles(db, 1, types.Resolution1m, CANDLE_START, CANDLE_START.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("error selecting the candles: %v", err)
+	}
+	if len(candles) != len(CANDLES) {
+		t.Fatalf("wrong number of candles: given %d, wanted %d", len(candles), len(CANDLES))
+	}
+	for i, c := range candles {
+		if c.Bucket.Unix-candles[0].Bucket.Unix != 60*i {
+			t.Errorf("wrong bucket: %v", c.Bucket)
+		}
+
+		if c.Opened_at.Unix != c.Bucket.Unix || c.Closed_at.Unix != c.Bucket.Unix+30 {
+			t.Errorf("wrong candle range: %v %v", c.Opened_at, c.Closed_at)
+		}
+
+		c.Bucket, c.Opened_at, c.Closed_at = types.Timestamp{}, types.Timestamp{}, types.Timestamp{}
+		if c != CANDLES[i] {
+			t.Errorf("wrong candle:\ngiven %v\nwanted %v", c, CANDLES[i])
+		}
+	}
+
+	hourly, err := SelectCandles(db, 1, types.Resolution1h, CANDLE_START, CANDLE_START.Add(time.Hour))
+	if err != nil || len(hourly) != 1 || hourly[0].Count != len(CANDLE_PRICES) || hourly[0].High != 130 || hourly[0].Low != 80 {
+		t.Errorf("wrong hourly candles: %v %v", hourly, err)
+	}
+}
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
+	"strconv"
 
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
@@ -16,11 +19,24 @@ import (
 //
 // Returns:
 //   - QueriesResult:	an array of results of the same length as entries
-func MakeQueries(db *sql.DB, queries []string) []types.QueryResult {
+func MakeQueries(db Executor, queries []string) []types.QueryResult {
+	return MakeQueriesContext(context.Background(), db, queries)
+}
+
+// Takes multiple queries, it checks and performs them until ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - queries:	the queries strings
+//
+// Returns:
+//   - QueriesResult:	an array of results of the same length as entries
+func MakeQueriesContext(ctx context.Context, db Executor, queries []string) []types.QueryResult {
 	results := make([]types.QueryResult, len(queries))
 
 	for i, query := range queries {
-		result, err := MakeQuery(db, query)
+		result, err := MakeQueryContext(ctx, db, query)
 		results[i] = types.QueryResult{
 			Result: result,
 			Error:  err,
@@ -31,21 +47,40 @@ func MakeQueries(db *sql.DB, queries []string) []types.QueryResult {
 }
 
 // Checks the validity of the query and, if it pases, it makes it
-// Valid queries are: INSERT, UPDATE, DELETE
+// Valid queries are single statements modifying data or schema, e.g.
+// INSERT, UPDATE, DELETE or CREATE. Multiple statements are rejected
 //
 // Parameters:
 //   - db:		the database struct
-//   - query:	the query string
+//   - query:	the query string, values are referenced as $1..$n
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - Result:	the query result
+//   - error:	an error if occured
+func MakeQuery(db Executor, query string, args ...any) (sql.Result, error) {
+	return MakeQueryContext(context.Background(), db, query, args...)
+}
+
+// Checks the validity of the query and, if it pases, it makes it
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - query:	the query string, values are referenced as $1..$n
+//   - args:	the values bound to the query placeholders
 //
 // Returns:
 //   - Result:	the query result
 //   - error:	an error if occured
-func MakeQuery(db *sql.DB, query string) (sql.Result, error) {
-	if !utils.ValidateQuery(query) {
-		return nil, fmt.Errorf("query is not valid")
+func MakeQueryContext(ctx context.Context, db Executor, query string, args ...any) (sql.Result, error) {
+	if err := validateStatement(query, utils.StatementWrite); err != nil {
+		return nil, err
 	}
 
-	return db.Exec(query)
+	result, err := db.ExecContext(ctx, query, args...)
+	return result, wrapError(err)
 }
 
 // Takes multiple queries, it checks and performs them
@@ -56,11 +91,24 @@ func MakeQuery(db *sql.DB, query string) (sql.Result, error) {
 //
 // Returns:
 //   - QueriesResult:	an array of results of the same length as entries
-func MakeQueriesWithResult(db *sql.DB, queries []string) []types.QueryRows {
+func MakeQueriesWithResult(db Executor, queries []string) []types.QueryRows {
+	return MakeQueriesWithResultContext(context.Background(), db, queries)
+}
+
+// Takes multiple queries, it checks and performs them until ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - queries:	the queries strings
+//
+// Returns:
+//   - QueriesResult:	an array of results of the same length as entries
+func MakeQueriesWithResultContext(ctx context.Context, db Executor, queries []string) []types.QueryRows {
 	results := make([]types.QueryRows, len(queries))
 
 	for i, query := range queries {
-		result, err := MakeQueryWithResult(db, query)
+		result, err := MakeQueryWithResultContext(ctx, db, query)
 		results[i] = types.QueryRows{
 			Result: result,
 			Error:  err,
@@ -71,20 +119,154 @@ func MakeQueriesWithResult(db *sql.DB, queries []string) []types.QueryRows {
 }
 
 // Checks the validity of the query with a result and, if it pases,
-// it makes it. Valid queries are: SELECT
-// Notice query must be complete
+// it makes it. Valid queries are single statements only reading, e.g.
+// SELECT or WITH ... SELECT. Multiple statements are rejected
+// Notice query must be complete, and that the rows are streamed from db
+// as is: run it through MakeReadOnlyQueryWithResult, or SelectInto, to
+// make sure it cannot modify data
+//
+// Parameters:
+//   - db:		the database struct
+//   - query:	the query string, values are referenced as $1..$n
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - *sql.Rows:	the query rows
+//   - error:		an error if occured
+func MakeQueryWithResult(db Executor, query string, args ...any) (*sql.Rows, error) {
+	return MakeQueryWithResultContext(context.Background(), db, query, args...)
+}
+
+// Checks the validity of the query with a result and, if it pases,
+// it makes it. The query and the rows scanning are aborted as soon
+// as ctx is done
 //
 // Parameters:
+//   - ctx:		the context bounding the query
 //   - db:		the database struct
+//   - query:	the query string, values are referenced as $1..$n
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - *sql.Rows:	the query rows
+//   - error:		an error if occured
+func MakeQueryWithResultContext(ctx context.Context, db Executor, query string, args ...any) (*sql.Rows, error) {
+	if err := validateStatement(query, utils.StatementRead); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	return rows, wrapError(err)
+}
+
+// Checks the validity of the query with a result and, if it pases,
+// it makes it inside a read only transaction, see WithReadOnlyTx, so
+// that it cannot modify data. The rows are handed to fn while the
+// transaction is open, and closed once it returns
+//
+// Parameters:
+//   - db:		the database struct
+//   - fn:		the function reading the rows
+//   - query:	the query string, values are referenced as $1..$n
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - error:	ErrReadOnlyViolation if the query writes, the fn error or any error if occured
+func MakeReadOnlyQueryWithResult(db Executor, fn func(rows *sql.Rows) error, query string, args ...any) error {
+	return MakeReadOnlyQueryWithResultContext(context.Background(), db, fn, query, args...)
+}
+
+// Checks the validity of the query with a result and, if it pases,
+// it makes it inside a read only transaction, see WithReadOnlyTx, so
+// that it cannot modify data. The rows are handed to fn while the
+// transaction is open, and closed once it returns. The query and the
+// rows scanning are aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - fn:		the function reading the rows
+//   - query:	the query string, values are referenced as $1..$n
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - error:	ErrReadOnlyViolation if the query writes, the fn error or any error if occured
+func MakeReadOnlyQueryWithResultContext(ctx context.Context, db Executor, fn func(rows *sql.Rows) error, query string, args ...any) error {
+	if err := validateStatement(query, utils.StatementRead); err != nil {
+		return err
+	}
+
+	return WithReadOnlyTxContext(ctx, db, func(tx Executor) error {
+		rows, err := MakeQueryWithResultContext(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		if err := fn(rows); err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return wrapError(err)
+		}
+
+		return wrapError(rows.Close())
+	})
+}
+
+// Checks that the query is a single statement of the given kind
+//
+// Parameters:
 //   - query:	the query string
+//   - kind:	the statement kind
 //
 // Returns:
-//   - Result:	the query result
-//   - error:	an error if occured
-func MakeQueryWithResult(db *sql.DB, query string) (*sql.Rows, error) {
-	if !utils.ValidateQueryWithResult(query) {
-		return nil, fmt.Errorf("query is not valid")
+//   - error:	ErrInvalidQuery, joined with the tokenizing error if any
+func validateStatement(query string, kind utils.StatementKind) error {
+	statement, err := utils.ClassifySQL(query)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+	if statement.Kind != kind {
+		return ErrInvalidQuery
 	}
 
-	return db.Query(query)
+	return nil
+}
+
+// Formats a value into its query representation, either as a literal
+// or as a placeholder bound to the value
+type valueFormatter func(value reflect.Value) string
+
+// Positional query arguments, every added value is bound to
+// the next $n placeholder of the query
+type queryArgs struct {
+	values []any
+}
+
+// Adds a value to the arguments
+//
+// Parameters:
+//   - value:	the value to bind
+//
+// Returns:
+//   - string:	the placeholder referencing the value
+func (qa *queryArgs) add(value any) string {
+	qa.values = append(qa.values, value)
+	return "$" + strconv.Itoa(len(qa.values))
+}
+
+// Adds a reflected value to the arguments, it satisfies valueFormatter.
+// Values of mapped types are converted by driverValue
+//
+// Parameters:
+//   - value:	the reflected value to bind
+//
+// Returns:
+//   - string:	the placeholder referencing the value
+func (qa *queryArgs) parameter(value reflect.Value) string {
+	if isMappedValue(value) {
+		return qa.add(driverValue(value))
+	}
+	return qa.add(value.Interface())
 }
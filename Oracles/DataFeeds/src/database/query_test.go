@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+func TestMakeQueryWithResultContextFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	// Deadline reached while the query is running
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	rows, err := MakeQueryWithResultContext(ctx, db, "SELECT pg_sleep(10)")
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+		rows.Close()
+	}
+
+	if err == nil {
+		t.Errorf("query has not been aborted by the context deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("query has been aborted too late: %v", elapsed)
+	}
+
+	// Context cancelled before the query is made
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+
+	_, err = InsertEntryContext(cancelled, db, types.Asset{
+		Id: types.Default[uint64]{
+			Default: true,
+		},
+		Ticker:   "BTC",
+		Source:   "Binance",
+		Decimals: 18,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("insertion with a cancelled context should fail with context.Canceled, given: %v", err)
+	}
+
+	// Connection is still usable after the cancellation
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Errorf("connection not usable after cancellation: %v", err)
+	}
+}
+
+func TestMakeReadOnlyQueryWithResultFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{})
+
+	if _, err := MakeQuery(db, "CREATE SEQUENCE test_read_only_seq"); err != nil {
+		t.Fatalf("error creating the sequence: %v", err)
+	}
+	if _, err := InsertEntry(db, TX_ASSETS[0]); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	readAll := func(rows *sql.Rows) error {
+		for rows.Next() {
+		}
+		return nil
+	}
+
+	// Selections calling writing functions are rejected
+	if err := MakeReadOnlyQueryWithResult(db, readAll, "SELECT nextval('test_read_only_seq')"); !errors.Is(err, ErrReadOnlyViolation) {
+		t.Errorf("result query has written, given: %v", err)
+	}
+
+	// Inside a transaction the query runs in a read only savepoint
+	err := WithTx(db, func(tx Executor) error {
+		if err := MakeReadOnlyQueryWithResult(tx, readAll, "SELECT nextval('test_read_only_seq')"); !errors.Is(err, ErrReadOnlyViolation) {
+			t.Errorf("result query has written inside the transaction, given: %v", err)
+		}
+
+		_, err := InsertEntry(tx, TX_ASSETS[1])
+		return err
+	})
+	if err != nil {
+		t.Errorf("error writing after the result query: %v", err)
+	}
+
+	// Rows are read while the transaction is open
+	var assets []types.Asset
+	err = MakeReadOnlyQueryWithResult(db, func(rows *sql.Rows) error {
+		var err error
+		assets, err = ScanRows[types.Asset](rows, nil)
+		return err
+	}, "SELECT * FROM Asset ORDER BY id")
+	if err != nil || len(assets) != 2 || assets[1].Ticker != TX_ASSETS[1].Ticker {
+		t.Errorf("wrong assets: %v %v", assets, err)
+	}
+
+	// The fn error is returned as is
+	errRead := errors.New("read error")
+	if err := MakeReadOnlyQueryWithResult(db, func(rows *sql.Rows) error { return errRead }, "SELECT * FROM Asset"); !errors.Is(err, errRead) {
+		t.Errorf("wrong error: given %v, wanted %v", err, errRead)
+	}
+	if err := MakeReadOnlyQueryWithResult(db, readAll, "DELETE FROM Asset"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("wrong error: given %v, wanted %v", err, ErrInvalidQuery)
+	}
+}
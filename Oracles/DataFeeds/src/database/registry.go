@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+var (
+	ErrReferenceCycle       = errors.New("table references form a cycle")
+	ErrUnregisteredTable    = errors.New("referenced table is not registered")
+	ErrDuplicateRegistering = errors.New("table is already registered")
+)
+
+// Referenced table of a ref tag
+var referencedTableRegex = regexp.MustCompile(`(?i)REFERENCES\s+(\w+)`)
+
+// Registry of the table models, tables are created and dropped in the
+// order of their ref tags dependencies
+type Registry struct {
+	mu     sync.RWMutex
+	tables []reflect.Type
+}
+
+// Returns a registry of the tables
+//
+// Parameters:
+//   - tables:	the tables to register
+//
+// Returns:
+//   - *Registry:	the registry
+//   - error:		if a table is not valid or registered twice
+func NewRegistry(tables ...types.Table) (*Registry, error) {
+	r := &Registry{}
+	if err := r.Register(tables...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Registers the tables
+//
+// Parameters:
+//   - tables:	the tables to register
+//
+// Returns:
+//   - error:	if a table is not valid or registered twice
+func (r *Registry) Register(tables ...types.Table) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, table := range tables {
+		tt := reflect.TypeOf(table)
+		if tt == nil || !utils.ValidateStruct(tt) {
+			return ErrNotValidTable
+		}
+
+		for _, registered := range r.tables {
+			if strings.EqualFold(utils.BaseTypeName(registered), utils.BaseTypeName(tt)) {
+				return fmt.Errorf("%w: %s", ErrDuplicateRegistering, utils.BaseTypeName(tt))
+			}
+		}
+		r.tables = append(r.tables, tt)
+	}
+
+	return nil
+}
+
+// Returns the registered tables, every table after the tables it references
+//
+// Returns:
+//   - []reflect.Type:	the ordered tables
+//   - error:			if references form a cycle or a referenced table is not registered
+func (r *Registry) Order() ([]reflect.Type, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return orderTables(r.tables)
+}
+
+// Creates every missing registered table in dependency order, in a single
+// transaction. Insertions of the ensured tables on db skip the table
+// existence check
+//
+// Parameters:
+//   - db:	the database struct
+//
+// Returns:
+//   - error:	if an error occured
+func (r *Registry) EnsureSchema(db Executor) error {
+	return r.EnsureSchemaContext(context.Background(), db)
+}
+
+// Creates every missing registered table in dependency order, in a single
+// transaction. Insertions of the ensured tables on db skip the table
+// existence check. The creation is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:	the context bounding the queries
+//   - db:	the database struct
+//
+// Returns:
+//   - error:	if an error occured
+func (r *Registry) EnsureSchemaContext(ctx context.Context, db Executor) error {
+	order, err := r.Order()
+	if err != nil {
+		return err
+	}
+
+	return WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+		for _, tt := range order {
+			table := reflect.Zero(tt).Interface()
+
+			exists, err := CheckIfTableExistsContext(ctx, tx, table)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+
+			if _, err := CreateTableContext(ctx, tx, table); err != nil {
+				return fmt.Errorf("creating %s: %w", utils.BaseTypeName(tt), err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Drops every registered table in reverse dependency order, in a single
+// transaction
+//
+// Parameters:
+//   - db:	the database struct
+//
+// Returns:
+//   - error:	if an error occured
+func (r *Registry) DropSchema(db Executor) error {
+	return r.DropSchemaContext(context.Background(), db)
+}
+
+// Drops every registered table in reverse dependency order, in a single
+// transaction. The deletion is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:	the context bounding the queries
+//   - db:	the database struct
+//
+// Returns:
+//   - error:	if an error occured
+func (r *Registry) DropSchemaContext(ctx context.Context, db Executor) error {
+	order, err := r.Order()
+	if err != nil {
+		return err
+	}
+
+	return WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+		for i := len(order) - 1; i >= 0; i-- {
+			if _, err := MakeQueryContext(ctx, tx, "DROP TABLE IF EXISTS "+utils.BaseTypeName(order[i])); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Orders the tables after the tables they reference
+//
+// Parameters:
+//   - tables:	the tables in registration order
+//
+// Returns:
+//   - []reflect.Type:	the ordered tables
+//   - error:			if references form a cycle or a referenced table is not in tables
+func orderTables(tables []reflect.Type) ([]reflect.Type, error) {
+	index := make(map[string]int, len(tables))
+	for i, tt := range tables {
+		index[strings.ToLower(utils.BaseTypeName(tt))] = i
+	}
+
+	// Dependencies of each table
+	dependencies := make([][]int, len(tables))
+	for i, tt := range tables {
+		for _, name := range referencedTables(tt) {
+			j, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s references %s", ErrUnregisteredTable, utils.BaseTypeName(tt), name)
+			}
+			if j != i {
+				dependencies[i] = append(dependencies[i], j)
+			}
+		}
+	}
+
+	// Repeatedly take the first table whose dependencies are all ordered,
+	// so independent tables keep their registration order
+	ordered := make([]reflect.Type, 0, len(tables))
+	done := make([]bool, len(tables))
+	for len(ordered) < len(tables) {
+		next := -1
+		for i := range tables {
+			if done[i] {
+				continue
+			}
+
+			ready := true
+			for _, j := range dependencies[i] {
+				if !done[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+
+		if next < 0 {
+			var cycle []string
+			for i, tt := range tables {
+				if !done[i] {
+					cycle = append(cycle, utils.BaseTypeName(tt))
+				}
+			}
+			return nil, fmt.Errorf("%w: %s", ErrReferenceCycle, strings.Join(cycle, ", "))
+		}
+
+		done[next] = true
+		ordered = append(ordered, tables[next])
+	}
+
+	return ordered, nil
+}
+
+// Returns the lower case names of the tables referenced by the ref tags
+func referencedTables(tt reflect.Type) []string {
+	var names []string
+	for i := 0; i < tt.NumField(); i++ {
+		ref, ok := tt.Field(i).Tag.Lookup("ref")
+		if !ok {
+			continue
+		}
+
+		for _, match := range referencedTableRegex.FindAllStringSubmatch(ref, -1) {
+			names = append(names, strings.ToLower(match[1]))
+		}
+	}
+
+	return names
+}
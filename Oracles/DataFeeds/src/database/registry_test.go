@@ -0,0 +1,128 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Registry tables
+type TestRegistryShop struct {
+	Id types.Default[int64] `json:"id" db:"id SERIAL PRIMARY KEY"`
+}
+
+type TestRegistryItem struct {
+	Id      types.Default[int64] `json:"id"      db:"id SERIAL PRIMARY KEY"`
+	Shop_id int                  `json:"shop_id" db:"shop_id INTEGER NOT NULL" ref:"FOREIGN KEY (shop_id) REFERENCES testregistryshop(id)"`
+}
+
+type TestRegistryOrder struct {
+	Id        types.Default[int64] `json:"id"        db:"id SERIAL PRIMARY KEY"`
+	Item_id   int                  `json:"item_id"   db:"item_id INTEGER NOT NULL" ref:"FOREIGN KEY (item_id) REFERENCES testregistryitem(id)"`
+	Parent_id types.Null[int64]    `json:"parent_id" db:"parent_id INTEGER" ref:"FOREIGN KEY (parent_id) REFERENCES testregistryorder(id)"`
+}
+
+type TestRegistryCycleA struct {
+	Id   types.Default[int64] `json:"id"   db:"id SERIAL PRIMARY KEY"`
+	B_id int                  `json:"b_id" db:"b_id INTEGER NOT NULL" ref:"FOREIGN KEY (b_id) REFERENCES testregistrycycleb(id)"`
+}
+
+type TestRegistryCycleB struct {
+	Id   types.Default[int64] `json:"id"   db:"id SERIAL PRIMARY KEY"`
+	A_id int                  `json:"a_id" db:"a_id INTEGER NOT NULL" ref:"FOREIGN KEY (a_id) REFERENCES testregistrycyclea(id)"`
+}
+
+func (t TestRegistryShop) GetPrimaryKeyNameDB() (string, error)   { return "id", nil }
+func (t TestRegistryItem) GetPrimaryKeyNameDB() (string, error)   { return "id", nil }
+func (t TestRegistryOrder) GetPrimaryKeyNameDB() (string, error)  { return "id", nil }
+func (t TestRegistryCycleA) GetPrimaryKeyNameDB() (string, error) { return "id", nil }
+func (t TestRegistryCycleB) GetPrimaryKeyNameDB() (string, error) { return "id", nil }
+
+type RegistryOrderInput struct {
+	Tables  []types.Table
+	Correct []string
+	Err     error
+}
+
+var REGISTRY_ORDERS = []RegistryOrderInput{
+	{
+		Tables:  []types.Table{types.Price{}, types.Asset{}},
+		Correct: []string{"Asset", "Price"},
+	},
+	{
+		Tables:  []types.Table{TestRegistryOrder{}, types.Asset{}, TestRegistryItem{}, TestRegistryShop{}},
+		Correct: []string{"Asset", "TestRegistryShop", "TestRegistryItem", "TestRegistryOrder"},
+	},
+	{
+		Tables: []types.Table{TestRegistryCycleA{}, TestRegistryCycleB{}, types.Asset{}},
+		Err:    ErrReferenceCycle,
+	},
+	{
+		Tables: []types.Table{types.Price{}},
+		Err:    ErrUnregisteredTable,
+	},
+}
+
+func TestRegistryOrderFunc(t *testing.T) {
+	for _, ro := range REGISTRY_ORDERS {
+		registry, err := NewRegistry(ro.Tables...)
+		if err != nil {
+			t.Fatalf("error registering the tables: %v", err)
+		}
+
+		order, err := registry.Order()
+		if !errors.Is(err, ro.Err) {
+			t.Errorf("wrong error: given %v, wanted %v", err, ro.Err)
+			continue
+		}
+
+		var names []string
+		for _, tt := range order {
+			names = append(names, tt.Name())
+		}
+		if !reflect.DeepEqual(names, ro.Correct) {
+			t.Errorf("incorrect order:\ngiven %v\nwanted %v", names, ro.Correct)
+		}
+	}
+
+	if _, err := NewRegistry(types.Asset{}, types.Asset{}); !errors.Is(err, ErrDuplicateRegistering) {
+		t.Errorf("wrong error: given %v, wanted %v", err, ErrDuplicateRegistering)
+	}
+}
+
+// Ensure schema
+func TestEnsureSchemaFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	registry, err := NewRegistry(types.Price{}, types.Asset{})
+	if err != nil {
+		t.Fatalf("error registering the tables: %v", err)
+	}
+
+	if err := registry.EnsureSchema(db); err != nil {
+		t.Fatalf("error ensuring the schema: %v", err)
+	}
+	// Idempotent
+	if err := registry.EnsureSchema(db); err != nil {
+		t.Fatalf("error ensuring the schema again: %v", err)
+	}
+
+	if diffs, err := CheckSchema(db, types.Asset{}, types.Price{}); err != nil || len(diffs) != 0 {
+		t.Errorf("ensured schema differs: %v %v", diffs, err)
+	}
+
+	if err := registry.DropSchema(db); err != nil {
+		t.Fatalf("error dropping the schema: %v", err)
+	}
+	if exists, err := CheckIfTableExists(db, types.Asset{}); err != nil || exists {
+		t.Errorf("table not dropped: %v", err)
+	}
+
+	// Dropped tables are not created again on insertion
+	if _, err := InsertEntry(db, types.Asset{Id: types.Default[uint64]{Default: true}, Ticker: "BTC", Source: "binance", Decimals: 8}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("wrong error inserting into the dropped table: given %v, wanted %v", err, ErrInvalidQuery)
+	}
+}
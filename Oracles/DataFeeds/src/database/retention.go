@@ -0,0 +1,532 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+var (
+	ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+)
+
+// Advisory lock key held while enforcing the retention, shared by every instance
+const RetentionLockKey int64 = 7346915620218458
+
+// Default batching of the retention job
+const (
+	DefaultRetentionBatchWindow = 24 * time.Hour
+	DefaultRetentionBatchSize   = 10000
+)
+
+// Candle resolution kept by a retention policy
+type Rollup struct {
+	Resolution types.Resolution
+	// Age after which the candles are deleted, 0 keeps them forever
+	Retention time.Duration
+}
+
+// Retention policy of the prices of an asset
+//
+// Raw prices older than Raw are rolled up into the candles of every
+// rollup resolution and then deleted
+type RetentionPolicy struct {
+	// Age after which raw prices are rolled up and deleted, 0 keeps them forever
+	Raw     time.Duration
+	Rollups []Rollup
+}
+
+// Keeps raw prices for 7 days, 1 minute candles for 90 days and hourly
+// candles forever
+var DefaultRetentionPolicy = RetentionPolicy{
+	Raw: 7 * 24 * time.Hour,
+	Rollups: []Rollup{
+		{Resolution: types.Resolution1m, Retention: 90 * 24 * time.Hour},
+		{Resolution: types.Resolution1h},
+	},
+}
+
+// Outcome of a retention run
+type RetentionReport struct {
+	// The run was skipped as another instance was enforcing the retention
+	Skipped bool
+	// Raw prices rolled up and deleted
+	RolledUp int64
+	// Expired candles deleted
+	CandlesDeleted int64
+	// Expired partitions dropped
+	PartitionsDropped int
+}
+
+// RetentionJob enforces the retention policies of the assets, every run
+// holds the RetentionLockKey advisory lock so that it is safe to schedule it
+// on more instances
+//
+// With Partitions set, the prices expiring with the longest raw retention
+// are rolled up and dropped a whole partition at a time, only the rows of
+// the default partition and of the assets with shorter retentions are
+// deleted row by row
+type RetentionJob struct {
+	db       *sql.DB
+	policy   RetentionPolicy
+	policies map[int]RetentionPolicy
+
+	// Raw prices are rolled up in transactions spanning this window,
+	// rounded to the coarsest rollup resolution
+	BatchWindow time.Duration
+	// Expired candles are deleted in batches of this size
+	BatchSize int
+	// Partitions of the Price table, nil if it is not partitioned
+	Partitions *PartitionManager
+}
+
+// Checks that the policy is enforceable
+//
+// Returns:
+//   - error:	ErrInvalidRetentionPolicy if the policy is not valid
+func (p RetentionPolicy) Validate() error {
+	if p.Raw < 0 {
+		return fmt.Errorf("%w: negative raw retention", ErrInvalidRetentionPolicy)
+	}
+	if p.Raw == 0 && len(p.Rollups) > 0 {
+		return fmt.Errorf("%w: rollups need a raw retention", ErrInvalidRetentionPolicy)
+	}
+
+	seen := make(map[types.Resolution]bool)
+	for _, r := range p.Rollups {
+		duration, err := r.Resolution.Duration()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRetentionPolicy, err)
+		}
+		if seen[r.Resolution] {
+			return fmt.Errorf("%w: duplicate %s rollup", ErrInvalidRetentionPolicy, r.Resolution)
+		}
+		seen[r.Resolution] = true
+
+		if duration > p.Raw {
+			return fmt.Errorf("%w: raw retention shorter than the %s resolution", ErrInvalidRetentionPolicy, r.Resolution)
+		}
+		if r.Retention != 0 && r.Retention <= p.Raw {
+			return fmt.Errorf("%w: %s candles expire before the raw prices", ErrInvalidRetentionPolicy, r.Resolution)
+		}
+	}
+
+	return nil
+}
+
+// Returns the duration of the coarsest rollup resolution, a minute if the
+// policy has no rollups
+func (p RetentionPolicy) coarsest() time.Duration {
+	coarsest := time.Minute
+	for _, r := range p.Rollups {
+		if duration, err := r.Resolution.Duration(); err == nil && duration > coarsest {
+			coarsest = duration
+		}
+	}
+
+	return coarsest
+}
+
+// Returns a retention job applying the policy to every asset
+//
+// Parameters:
+//   - db:		the database struct
+//   - policy:	the default policy
+//
+// Returns:
+//   - *RetentionJob:	the retention job
+//   - error:			if the policy is not valid
+func NewRetentionJob(db *sql.DB, policy RetentionPolicy) (*RetentionJob, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &RetentionJob{
+		db:          db,
+		policy:      policy,
+		policies:    make(map[int]RetentionPolicy),
+		BatchWindow: DefaultRetentionBatchWindow,
+		BatchSize:   DefaultRetentionBatchSize,
+	}, nil
+}
+
+// Overrides the default policy for an asset
+//
+// Parameters:
+//   - assetId:	the asset id
+//   - policy:	the asset policy
+//
+// Returns:
+//   - error:	if the policy is not valid
+func (j *RetentionJob) SetPolicy(assetId int, policy RetentionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	j.policies[assetId] = policy
+	return nil
+}
+
+// Returns the policy of the asset
+func (j *RetentionJob) Policy(assetId int) RetentionPolicy {
+	if policy, ok := j.policies[assetId]; ok {
+		return policy
+	}
+
+	return j.policy
+}
+
+// Enforces the retention policies once. If another instance is already
+// running the job the run is skipped
+//
+// Parameters:
+//   - ctx:	the context bounding the run
+//
+// Returns:
+//   - RetentionReport:	the run outcome
+//   - error:			if an error occured, the batches already committed are kept
+func (j *RetentionJob) Run(ctx context.Context) (report RetentionReport, err error) {
+	conn, err := j.db.Conn(ctx)
+	if err != nil {
+		return report, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", RetentionLockKey).Scan(&locked); err != nil {
+		return report, err
+	}
+	if !locked {
+		report.Skipped = true
+		return report, nil
+	}
+	defer func() {
+		// Unlock even if ctx is done
+		_, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", RetentionLockKey)
+		err = errors.Join(err, unlockErr)
+	}()
+
+	// Partitions are kept ahead at every run
+	var partitionRetention time.Duration
+	if j.Partitions != nil {
+		if _, err := j.Partitions.EnsurePartitionsContext(ctx, conn); err != nil {
+			return report, err
+		}
+		partitionRetention = j.partitionRetention()
+	}
+
+	assetIds, err := selectIds(ctx, conn, "SELECT id FROM Asset ORDER BY id")
+	if err != nil {
+		return report, err
+	}
+
+	now := time.Now()
+	for _, assetId := range assetIds {
+		policy := j.Policy(assetId)
+
+		source := "Price"
+		if partitionRetention != 0 && policy.Raw == partitionRetention {
+			source = j.Partitions.name + "_default"
+		}
+
+		rolled, err := j.rollupPrices(ctx, conn, assetId, policy, now, source)
+		report.RolledUp += rolled
+		if err != nil {
+			return report, err
+		}
+
+		deleted, err := j.expireCandles(ctx, conn, assetId, policy, now)
+		report.CandlesDeleted += deleted
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if partitionRetention != 0 {
+		return report, j.dropPartitions(ctx, conn, partitionRetention, &report)
+	}
+
+	return report, nil
+}
+
+// Returns the longest raw retention of the policies, after which whole
+// partitions expire. It is 0 if any policy keeps the raw prices forever
+func (j *RetentionJob) partitionRetention() time.Duration {
+	longest := j.policy.Raw
+	for _, policy := range j.policies {
+		if policy.Raw == 0 {
+			return 0
+		}
+		if policy.Raw > longest {
+			longest = policy.Raw
+		}
+	}
+	if j.policy.Raw == 0 {
+		return 0
+	}
+
+	return longest
+}
+
+// Rolls the prices of the expired partitions up into the candles of each
+// asset policy and drops them, a partition per transaction
+//
+// Parameters:
+//   - ctx:			the context bounding the queries
+//   - db:			the database connection
+//   - retention:	the age after which partitions expire
+//   - report:		the run report to update
+//
+// Returns:
+//   - error:	if an error occured
+func (j *RetentionJob) dropPartitions(ctx context.Context, db Executor, retention time.Duration, report *RetentionReport) error {
+	expired, err := j.Partitions.ExpiredPartitionsContext(ctx, db, retention)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range expired {
+		err := WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+			assetIds, err := selectIds(ctx, tx, "SELECT DISTINCT asset_id FROM "+p.Name)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range assetIds {
+				for _, r := range j.Policy(id).Rollups {
+					query, err := buildRollupQuery(r.Resolution, p.Name)
+					if err != nil {
+						return err
+					}
+					if _, err := MakeQueryContext(ctx, tx, query, id, p.From, p.To); err != nil {
+						return err
+					}
+				}
+			}
+
+			var rolled int64
+			if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+p.Name).Scan(&rolled); err != nil {
+				return err
+			}
+			report.RolledUp += rolled
+
+			return j.Partitions.DropPartitionContext(ctx, tx, p)
+		})
+		if err != nil {
+			return err
+		}
+		report.PartitionsDropped++
+	}
+
+	return nil
+}
+
+// Runs the job every interval, starting immediately, until ctx is done
+//
+// Parameters:
+//   - ctx:			the context stopping the schedule
+//   - interval:	the time between the runs
+//   - onRun:		called with the outcome of every run, it may be nil
+//
+// Returns:
+//   - error:	the ctx error
+func (j *RetentionJob) Schedule(ctx context.Context, interval time.Duration, onRun func(RetentionReport, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := j.Run(ctx)
+		if onRun != nil {
+			onRun(report, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rolls the expired raw prices of the asset up into candles and deletes
+// them. Only whole buckets of the coarsest resolution are rolled up, each
+// batch window inside its own transaction
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database connection
+//   - assetId:	the asset id
+//   - policy:	the asset policy
+//   - now:		the run time
+//   - source:	the prices table or partition
+//
+// Returns:
+//   - int64:	the rolled up prices
+//   - error:	if an error occured
+func (j *RetentionJob) rollupPrices(ctx context.Context, db Executor, assetId int, policy RetentionPolicy, now time.Time, source string) (int64, error) {
+	if policy.Raw == 0 {
+		return 0, nil
+	}
+
+	coarsest := policy.coarsest()
+	queries := make([]string, len(policy.Rollups))
+	for i, r := range policy.Rollups {
+		query, err := buildRollupQuery(r.Resolution, source)
+		if err != nil {
+			return 0, err
+		}
+		queries[i] = query
+	}
+
+	// Bounds are computed by the database, in the timestamps time zone
+	var first sql.NullTime
+	var cutoff time.Time
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT date_bin('%[1]d seconds', MIN(timestamp), %[2]s), date_bin('%[1]d seconds', TO_TIMESTAMP($2)::timestamp, %[2]s)
+FROM %[3]s
+WHERE asset_id = $1`, int(coarsest.Seconds()), candleOrigin, source), assetId, now.Add(-policy.Raw).Unix()).Scan(&first, &cutoff)
+	if err != nil || !first.Valid {
+		return 0, err
+	}
+
+	window := retentionWindow(j.BatchWindow, coarsest)
+	var rolled int64
+	for start := first.Time; start.Before(cutoff); start = start.Add(window) {
+		end := start.Add(window)
+		if end.After(cutoff) {
+			end = cutoff
+		}
+
+		err := WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+			for _, query := range queries {
+				if _, err := MakeQueryContext(ctx, tx, query, assetId, start, end); err != nil {
+					return err
+				}
+			}
+
+			res, err := MakeQueryContext(ctx, tx, "DELETE FROM "+source+"\nWHERE asset_id = $1 AND timestamp >= $2 AND timestamp < $3", assetId, start, end)
+			if err != nil {
+				return err
+			}
+
+			deleted, err := res.RowsAffected()
+			rolled += deleted
+			return err
+		})
+		if err != nil {
+			return rolled, err
+		}
+	}
+
+	return rolled, nil
+}
+
+// Deletes the expired candles of the asset, in batches of BatchSize
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database connection
+//   - assetId:	the asset id
+//   - policy:	the asset policy
+//   - now:		the run time
+//
+// Returns:
+//   - int64:	the deleted candles
+//   - error:	if an error occured
+func (j *RetentionJob) expireCandles(ctx context.Context, db Executor, assetId int, policy RetentionPolicy, now time.Time) (int64, error) {
+	batchSize := j.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultRetentionBatchSize
+	}
+
+	var deleted int64
+	for _, r := range policy.Rollups {
+		if r.Retention == 0 {
+			continue
+		}
+
+		for {
+			res, err := DeleteRowsByPrimaryKeyWithSelectionQueryContext(ctx, db, types.Candle{},
+				"SELECT id FROM Candle\nWHERE asset_id = $1 AND resolution = $2 AND bucket < TO_TIMESTAMP($3)::timestamp\nLIMIT $4",
+				assetId, r.Resolution, now.Add(-r.Retention).Unix(), batchSize)
+			if err != nil {
+				return deleted, err
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+
+			if n < int64(batchSize) {
+				break
+			}
+		}
+	}
+
+	return deleted, nil
+}
+
+// Builds the upsert of the candles of the resolution from the prices of
+// the asset bound as $1 within [$2, $3). Prices rolled up into an existing
+// candle extend its range and count, its open and close are replaced by
+// the rolled up ones opened before or closed after them
+//
+// Parameters:
+//   - resolution:	the candle resolution
+//   - source:		the prices table or partition
+//
+// Returns:
+//   - string:	the query
+//   - error:	if the resolution is not supported
+func buildRollupQuery(resolution types.Resolution, source string) (string, error) {
+	query, err := buildCandlesSelect(resolution, source, "timestamp >= $2 AND timestamp < $3")
+	if err != nil {
+		return "", err
+	}
+
+	return `INSERT INTO Candle (asset_id, resolution, bucket, open, high, low, close, count, opened_at, closed_at)
+` + query + `
+ON CONFLICT (asset_id, resolution, bucket) DO UPDATE SET
+	open = CASE WHEN EXCLUDED.opened_at < Candle.opened_at THEN EXCLUDED.open ELSE Candle.open END,
+	high = GREATEST(Candle.high, EXCLUDED.high),
+	low = LEAST(Candle.low, EXCLUDED.low),
+	close = CASE WHEN EXCLUDED.closed_at > Candle.closed_at THEN EXCLUDED.close ELSE Candle.close END,
+	count = Candle.count + EXCLUDED.count,
+	opened_at = LEAST(Candle.opened_at, EXCLUDED.opened_at),
+	closed_at = GREATEST(Candle.closed_at, EXCLUDED.closed_at)`, nil
+}
+
+// Returns the batch window rounded down to a multiple of the coarsest
+// resolution, at least one bucket
+func retentionWindow(window time.Duration, coarsest time.Duration) time.Duration {
+	if window < coarsest {
+		return coarsest
+	}
+
+	return window / coarsest * coarsest
+}
+
+// Selects the ids returned by the query
+func selectIds(ctx context.Context, db Executor, query string) ([]int, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,293 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Policy validation
+type TestRetentionPolicyInput struct {
+	Policy  RetentionPolicy
+	Correct bool
+}
+
+var RETENTION_POLICIES = []TestRetentionPolicyInput{
+	{Policy: DefaultRetentionPolicy, Correct: true},
+	{Policy: RetentionPolicy{}, Correct: true},
+	{Policy: RetentionPolicy{Raw: time.Hour}, Correct: true},
+	{Policy: RetentionPolicy{Raw: -time.Hour}, Correct: false},
+	{Policy: RetentionPolicy{Rollups: []Rollup{{Resolution: types.Resolution1m}}}, Correct: false},
+	{Policy: RetentionPolicy{Raw: time.Hour, Rollups: []Rollup{{Resolution: "2m"}}}, Correct: false},
+	{Policy: RetentionPolicy{Raw: time.Hour, Rollups: []Rollup{{Resolution: types.Resolution1m}, {Resolution: types.Resolution1m}}}, Correct: false},
+	{Policy: RetentionPolicy{Raw: time.Hour, Rollups: []Rollup{{Resolution: types.Resolution1d}}}, Correct: false},
+	{Policy: RetentionPolicy{Raw: time.Hour, Rollups: []Rollup{{Resolution: types.Resolution1m, Retention: time.Minute}}}, Correct: false},
+}
+
+func TestRetentionPolicyValidateFunc(t *testing.T) {
+	for i, input := range RETENTION_POLICIES {
+		err := input.Policy.Validate()
+		if input.Correct && err != nil {
+			t.Errorf("policy %d rejected: %v", i, err)
+		}
+		if !input.Correct && !errors.Is(err, ErrInvalidRetentionPolicy) {
+			t.Errorf("policy %d accepted: %v", i, err)
+		}
+	}
+}
+
+func TestRetentionWindowFunc(t *testing.T) {
+	if w := retentionWindow(24*time.Hour, time.Hour); w != 24*time.Hour {
+		t.Errorf("wrong window: %v", w)
+	}
+	if w := retentionWindow(90*time.Minute, time.Hour); w != time.Hour {
+		t.Errorf("window not rounded to the resolution: %v", w)
+	}
+	if w := retentionWindow(time.Minute, time.Hour); w != time.Hour {
+		t.Errorf("window shorter than the resolution: %v", w)
+	}
+}
+
+func TestBuildRollupQueryFunc(t *testing.T) {
+	query, err := buildRollupQuery(types.Resolution1h, "Price")
+	if err != nil {
+		t.Fatalf("error building the query: %v", err)
+	}
+
+	correct := `INSERT INTO Candle (asset_id, resolution, bucket, open, high, low, close, count, opened_at, closed_at)
+SELECT asset_id, '1h' AS resolution, date_bin('3600 seconds', timestamp, TIMESTAMP '2000-01-01 00:00:00') AS bucket,
+	(array_agg(price ORDER BY timestamp ASC))[1] AS open,
+	MAX(price) AS high,
+	MIN(price) AS low,
+	(array_agg(price ORDER BY timestamp DESC))[1] AS close,
+	COUNT(*) AS count,
+	MIN(timestamp) AS opened_at,
+	MAX(timestamp) AS closed_at
+FROM Price
+WHERE asset_id = $1 AND timestamp >= $2 AND timestamp < $3
+GROUP BY asset_id, bucket
+ON CONFLICT (asset_id, resolution, bucket) DO UPDATE SET
+	open = CASE WHEN EXCLUDED.opened_at < Candle.opened_at THEN EXCLUDED.open ELSE Candle.open END,
+	high = GREATEST(Candle.high, EXCLUDED.high),
+	low = LEAST(Candle.low, EXCLUDED.low),
+	close = CASE WHEN EXCLUDED.closed_at > Candle.closed_at THEN EXCLUDED.close ELSE Candle.close END,
+	count = Candle.count + EXCLUDED.count,
+	opened_at = LEAST(Candle.opened_at, EXCLUDED.opened_at),
+	closed_at = GREATEST(Candle.closed_at, EXCLUDED.closed_at)`
+	if query != correct {
+		t.Errorf("incorrect query:\n%v\n%v", query, correct)
+	}
+
+	if _, err := buildRollupQuery("2m", "Price"); err == nil {
+		t.Errorf("unsupported resolution accepted")
+	}
+}
+
+// Late prices rolled up into an existing candle
+func TestRollupLatePricesFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	models, err := NewRegistry(types.Asset{}, types.Price{}, types.Candle{})
+	if err != nil {
+		t.Fatalf("error creating the registry: %v", err)
+	}
+	if err := models.EnsureSchema(db); err != nil {
+		t.Fatalf("error creating the schema: %v", err)
+	}
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	query, err := buildRollupQuery(types.Resolution1m, "Price")
+	if err != nil {
+		t.Fatalf("error building the query: %v", err)
+	}
+
+	// Offsets from CANDLE_START to prices, rolled up one batch at a time
+	batches := []map[int]int{
+		{20: 100, 30: 110},
+		{10: 90, 50: 130},
+		{25: 50},
+	}
+	for _, batch := range batches {
+		if _, err := MakeQuery(db, "DELETE FROM Price WHERE asset_id = 1"); err != nil {
+			t.Fatalf("error deleting the prices: %v", err)
+		}
+		for offset, price := range batch {
+			p := types.Price{Id: types.Default[int64]{Default: true}, Asset_id: 1, Price: price, Timestamp: types.Timestamp{Unix: int(CANDLE_START.Unix()) + offset}}
+			if _, err := InsertEntry(db, p); err != nil {
+				t.Fatalf("error inserting the price: %v", err)
+			}
+		}
+
+		if _, err := MakeQuery(db, query, 1, CANDLE_START, CANDLE_START.Add(time.Minute)); err != nil {
+			t.Fatalf("error rolling up the prices: %v", err)
+		}
+	}
+
+	candle, err := SelectOne[types.Candle](db, "SELECT * FROM Candle")
+	if err != nil {
+		t.Fatalf("error selecting the candle: %v", err)
+	}
+	if candle.Open != 90 || candle.High != 130 || candle.Low != 50 || candle.Close != 130 || candle.Count != 5 {
+		t.Errorf("wrong candle: %v", candle)
+	}
+	if candle.Opened_at.Unix != int(CANDLE_START.Unix())+10 || candle.Closed_at.Unix != int(CANDLE_START.Unix())+50 {
+		t.Errorf("wrong candle range: %v %v", candle.Opened_at, candle.Closed_at)
+	}
+}
+
+// Retention run
+func TestRetentionJobRunFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	models, err := NewRegistry(types.Asset{}, types.Price{}, types.Candle{})
+	if err != nil {
+		t.Fatalf("error creating the registry: %v", err)
+	}
+	if err := models.EnsureSchema(db); err != nil {
+		t.Fatalf("error creating the schema: %v", err)
+	}
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	// Prices of CANDLE_PRICES 10 and 100 days ago, and a recent one
+	now := time.Now()
+	var prices []types.Price
+	for _, start := range []time.Time{now.Add(-100 * 24 * time.Hour), now.Add(-10 * 24 * time.Hour)} {
+		start = start.Truncate(time.Hour)
+		for i, p := range CANDLE_PRICES {
+			prices = append(prices, types.Price{
+				Id:        types.Default[int64]{Default: true},
+				Asset_id:  1,
+				Price:     p,
+				Timestamp: types.Timestamp{Unix: int(start.Unix()) + 30*i},
+			})
+		}
+	}
+	prices = append(prices, types.Price{
+		Id:        types.Default[int64]{Default: true},
+		Asset_id:  1,
+		Price:     1,
+		Timestamp: types.Timestamp{Unix: int(now.Add(-time.Hour).Unix())},
+	})
+	_, errs := InsertEntries(db, prices)
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("error inserting the prices: %v", err)
+	}
+
+	job, err := NewRetentionJob(db, DefaultRetentionPolicy)
+	if err != nil {
+		t.Fatalf("error creating the job: %v", err)
+	}
+
+	report, err := job.Run(context.Background())
+	if err != nil {
+		t.Fatalf("error running the job: %v", err)
+	}
+	if report.Skipped || report.RolledUp != int64(2*len(CANDLE_PRICES)) || report.CandlesDeleted != int64(len(CANDLES)) {
+		t.Errorf("wrong report: %+v", report)
+	}
+
+	remaining, err := SelectInto[types.Price](db, "SELECT * FROM Price")
+	if err != nil || len(remaining) != 1 || remaining[0].Price != 1 {
+		t.Errorf("wrong remaining prices: %v %v", remaining, err)
+	}
+
+	// 1 minute candles of 100 days ago are expired, hourly ones are kept
+	candles, err := SelectInto[types.Candle](db, "SELECT * FROM Candle ORDER BY resolution DESC, bucket")
+	if err != nil {
+		t.Fatalf("error selecting the candles: %v", err)
+	}
+	if len(candles) != len(CANDLES)+2 {
+		t.Fatalf("wrong number of candles: %v", candles)
+	}
+	for i, c := range candles[:len(CANDLES)] {
+		if c.Closed_at.Unix-c.Opened_at.Unix != 30 {
+			t.Errorf("wrong candle range: %v %v", c.Opened_at, c.Closed_at)
+		}
+		c.Id, c.Bucket, c.Opened_at, c.Closed_at = types.Default[int64]{}, types.Timestamp{}, types.Timestamp{}, types.Timestamp{}
+		if c != CANDLES[i] {
+			t.Errorf("wrong candle:\ngiven %v\nwanted %v", c, CANDLES[i])
+		}
+	}
+	for _, c := range candles[len(CANDLES):] {
+		if c.Resolution != types.Resolution1h || c.Count != len(CANDLE_PRICES) || c.High != 130 || c.Low != 80 {
+			t.Errorf("wrong hourly candle: %v", c)
+		}
+	}
+
+	// Runs are idempotent
+	report, err = job.Run(context.Background())
+	if err != nil || report != (RetentionReport{}) {
+		t.Errorf("wrong second run: %+v %v", report, err)
+	}
+}
+
+func TestRetentionJobPartitionsFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	models, err := NewRegistry(types.Asset{}, types.Price{}, types.Candle{})
+	if err != nil {
+		t.Fatalf("error creating the registry: %v", err)
+	}
+	if err := models.EnsureSchema(db); err != nil {
+		t.Fatalf("error creating the schema: %v", err)
+	}
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	start := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Hour)
+	prices := make([]types.Price, len(CANDLE_PRICES))
+	for i, p := range CANDLE_PRICES {
+		prices[i] = types.Price{
+			Id:        types.Default[int64]{Default: true},
+			Asset_id:  1,
+			Price:     p,
+			Timestamp: types.Timestamp{Unix: int(start.Unix()) + 30*i},
+		}
+	}
+	_, errs := InsertEntries(db, prices)
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("error inserting the prices: %v", err)
+	}
+
+	// The partition of 10 days ago takes the prices over
+	partitions, err := NewPartitionManager(types.Price{}, PartitionDaily)
+	if err != nil {
+		t.Fatalf("error creating the manager: %v", err)
+	}
+	if err := partitions.createPartition(context.Background(), db, partitions.PartitionOf(start)); err != nil {
+		t.Fatalf("error creating the partition: %v", err)
+	}
+
+	job, err := NewRetentionJob(db, DefaultRetentionPolicy)
+	if err != nil {
+		t.Fatalf("error creating the job: %v", err)
+	}
+	job.Partitions = partitions
+
+	report, err := job.Run(context.Background())
+	if err != nil {
+		t.Fatalf("error running the job: %v", err)
+	}
+	if report.PartitionsDropped != 1 || report.RolledUp != int64(len(CANDLE_PRICES)) {
+		t.Errorf("wrong report: %+v", report)
+	}
+
+	current, err := partitions.Partitions(db)
+	if err != nil || len(current) != partitions.Ahead+1 {
+		t.Errorf("wrong partitions: %v %v", current, err)
+	}
+
+	candles, err := SelectInto[types.Candle](db, "SELECT * FROM Candle")
+	if err != nil || len(candles) != len(CANDLES)+1 {
+		t.Errorf("wrong candles: %v %v", candles, err)
+	}
+}
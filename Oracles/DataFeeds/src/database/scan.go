@@ -1,19 +1,34 @@
 package database
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
 )
 
+var (
+	ErrUnknownColumn = errors.New("column has no matching field")
+)
+
 type ScannableRow interface {
 	Scan(dest ...any) error
 }
 
+// Rows exposing their column names, as *sql.Rows
+type ColumnsRow interface {
+	ScannableRow
+	Columns() ([]string, error)
+}
+
 // ScanRowToStruct scans all rows of the selected table rows
 //
+// Rows exposing their columns, as *sql.Rows, are mapped to the fields
+// sharing their db name, columns without a field are ignored. Other rows,
+// as *sql.Row, must select every field in the struct order
+//
 // Parameters:
 //
 //   - *sql.Row:	the row to scan
@@ -29,31 +44,42 @@ type ScannableRow interface {
 // Returns:
 //   - error: an error if occurs, nil otherwise
 func ScanRowToStruct(row ScannableRow, table reflect.Value) error {
+	return scanRowToStruct(row, table, false)
+}
+
+// ScanRowToStructStrict scans the row as ScanRowToStruct, but rejects
+// columns without a matching field with ErrUnknownColumn
+//
+// Parameters:
+//   - *sql.Row:	the row to scan
+//   - table:		the table to scan, passed as reflect.ValueOf(&yourStruct).Elem()
+//
+// Returns:
+//   - error: an error if occurs, nil otherwise
+func ScanRowToStructStrict(row ScannableRow, table reflect.Value) error {
+	return scanRowToStruct(row, table, true)
+}
+
+func scanRowToStruct(row ScannableRow, table reflect.Value, strict bool) error {
 	if !utils.ValidateStruct(table.Type()) {
 		return fmt.Errorf("cant scan a row without a struct table")
 	}
 
-	var addresses []any
-	for i := 0; i < table.NumField(); i++ {
-		tf := table.Field(i)
-
-		if utils.ValidateCustomStruct(tf.Type()) {
-			tf, err := handleCustomStructField(tf)
-			if err != nil {
-				return err
-			}
-
-			if !tf.CanAddr() {
-				return fmt.Errorf("field is not addressable %v", tf)
-			}
-			addresses = append(addresses, tf.Addr().Interface())
-			continue
+	if cr, ok := row.(ColumnsRow); ok {
+		columns, err := cr.Columns()
+		if err != nil {
+			return err
 		}
+		return ScanColumnsToStruct(row, columns, table, strict)
+	}
 
-		if !tf.CanAddr() {
-			return fmt.Errorf("field is not addressable %v", tf)
+	var addresses []any
+	for i := 0; i < table.NumField(); i++ {
+		address, err := fieldAddress(table.Field(i))
+		if err != nil {
+			return err
 		}
-		addresses = append(addresses, tf.Addr().Interface())
+		addresses = append(addresses, address)
 	}
 
 	return row.Scan(addresses...)
@@ -68,38 +94,103 @@ func ScanRowToStruct(row ScannableRow, table reflect.Value) error {
 //
 // Returns:
 //   - error: an error if occurs, nil otherwise
-func ScanSelectedRowsToParameters(row *sql.Row, table reflect.Value, tableRows ...int) error {
-	if table.NumField() > len(tableRows) {
+func ScanSelectedRowsToParameters(row ScannableRow, table reflect.Value, tableRows ...int) error {
+	if len(tableRows) > table.NumField() {
 		return fmt.Errorf("more rows to scan than actual rows")
 	}
 
 	var addresses []any
 	for _, indexRow := range tableRows {
-		if table.NumField() >= 1+indexRow {
-			return fmt.Errorf("more rows to scan than actual rows")
+		if indexRow < 0 || indexRow >= table.NumField() {
+			return ErrComlumnIndexOutOfBounds
+		}
+
+		address, err := fieldAddress(table.Field(indexRow))
+		if err != nil {
+			return err
 		}
+		addresses = append(addresses, address)
+	}
+
+	return row.Scan(addresses...)
+}
 
-		tf := table.Field(indexRow)
+// ScanColumnsToStruct scans the row columns into the table fields
+// sharing their db name, fields without a column are left untouched
+//
+// Parameters:
+//   - row:		the row to scan
+//   - columns:	the row column names, in order
+//   - table:	the table to scan, passed as reflect.ValueOf(&yourStruct).Elem()
+//   - strict:	whether columns without a field are rejected or ignored
+//
+// Returns:
+//   - error: an error if occurs, nil otherwise
+func ScanColumnsToStruct(row ScannableRow, columns []string, table reflect.Value, strict bool) error {
+	if !utils.ValidateStruct(table.Type()) {
+		return fmt.Errorf("cant scan a row without a struct table")
+	}
 
-		if utils.ValidateCustomStruct(tf.Type()) {
-			tf, err := handleCustomStructField(tf)
-			if err != nil {
-				return err
+	// Map db names to fields
+	fields := make(map[string]int, table.NumField())
+	for i := 0; i < table.NumField(); i++ {
+		name, err := utils.GetFieldNameDB(table.Type().Field(i))
+		if err != nil {
+			return err
+		}
+		fields[strings.ToLower(name)] = i
+	}
+
+	addresses := make([]any, len(columns))
+	for i, column := range columns {
+		index, ok := fields[strings.ToLower(column)]
+		if !ok {
+			if strict {
+				return fmt.Errorf("%w: %s", ErrUnknownColumn, column)
 			}
 
-			addresses = append(addresses, tf.Addr().Interface())
+			// Discard the column
+			addresses[i] = new(any)
 			continue
 		}
 
-		if !tf.CanAddr() {
-			return fmt.Errorf("field is not addressable %v", tf)
+		address, err := fieldAddress(table.Field(index))
+		if err != nil {
+			return err
 		}
-		addresses = append(addresses, tf.Addr().Interface())
+		addresses[i] = address
 	}
 
 	return row.Scan(addresses...)
 }
 
+// fieldAddress returns the address to scan the field into, fields of types
+// without native database/sql support are scanned through valueScanner
+//
+// Parameters:
+//   - tf:	the table field
+//
+// Returns:
+//   - any:		the address to scan
+//   - error:	an error if occurs, nil otherwise
+func fieldAddress(tf reflect.Value) (any, error) {
+	if utils.ValidateCustomStruct(tf.Type()) {
+		var err error
+		tf, err = handleCustomStructField(tf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !tf.CanAddr() {
+		return nil, fmt.Errorf("field is not addressable %v", tf)
+	}
+	if scanner := valueScanner(tf); scanner != nil {
+		return scanner, nil
+	}
+	return tf.Addr().Interface(), nil
+}
+
 // handleCustomStruct handles the correct pointer selection for custom structs types
 //
 // Parameters:
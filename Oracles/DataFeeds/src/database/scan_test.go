@@ -0,0 +1,123 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Row returning fixed values
+type TestRow struct {
+	Values []any
+}
+
+func (r TestRow) Scan(dest ...any) error {
+	if len(dest) != len(r.Values) {
+		return fmt.Errorf("expected %d destinations, given %d", len(r.Values), len(dest))
+	}
+
+	for i, v := range r.Values {
+		if v == nil {
+			continue
+		}
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+// Row returning fixed values and their columns
+type TestColumnsRow struct {
+	TestRow
+	Names []string
+}
+
+func (r TestColumnsRow) Columns() ([]string, error) {
+	return r.Names, nil
+}
+
+type ScanRowInput struct {
+	Row     ScannableRow
+	Strict  bool
+	Correct types.Asset
+	Err     error
+}
+
+var SCAN_ROWS = []ScanRowInput{
+	{
+		Row: TestRow{Values: []any{uint64(1), "BTC", "binance", int8(8)}},
+		Correct: types.Asset{
+			Id:       types.Default[uint64]{Value: 1},
+			Ticker:   "BTC",
+			Source:   "binance",
+			Decimals: 8,
+		},
+	},
+	{
+		Row: TestColumnsRow{
+			TestRow: TestRow{Values: []any{int8(8), "BTC", uint64(1)}},
+			Names:   []string{"decimals", "ticker", "id"},
+		},
+		Correct: types.Asset{
+			Id:       types.Default[uint64]{Value: 1},
+			Ticker:   "BTC",
+			Decimals: 8,
+		},
+	},
+	{
+		Row: TestColumnsRow{
+			TestRow: TestRow{Values: []any{"BTC", nil}},
+			Names:   []string{"ticker", "listed"},
+		},
+		Correct: types.Asset{Ticker: "BTC"},
+	},
+	{
+		Row: TestColumnsRow{
+			TestRow: TestRow{Values: []any{"BTC", nil}},
+			Names:   []string{"ticker", "listed"},
+		},
+		Strict: true,
+		Err:    ErrUnknownColumn,
+	},
+}
+
+func TestScanRowToStructFunc(t *testing.T) {
+	for _, sr := range SCAN_ROWS {
+		var asset types.Asset
+		var err error
+		if sr.Strict {
+			err = ScanRowToStructStrict(sr.Row, reflect.ValueOf(&asset).Elem())
+		} else {
+			err = ScanRowToStruct(sr.Row, reflect.ValueOf(&asset).Elem())
+		}
+
+		if !errors.Is(err, sr.Err) {
+			t.Errorf("wrong error: given %v, wanted %v", err, sr.Err)
+			continue
+		}
+
+		if asset != sr.Correct {
+			t.Errorf("incorrect scan:\ngiven %v\nwanted %v", asset, sr.Correct)
+		}
+	}
+}
+
+func TestScanSelectedRowsToParametersFunc(t *testing.T) {
+	var asset types.Asset
+	row := TestRow{Values: []any{int8(8), "BTC"}}
+
+	err := ScanSelectedRowsToParameters(row, reflect.ValueOf(&asset).Elem(), 3, 1)
+	if err != nil {
+		t.Fatalf("error when scanning selected rows: %v", err)
+	}
+	if asset.Decimals != 8 || asset.Ticker != "BTC" {
+		t.Errorf("incorrect scan: %v", asset)
+	}
+
+	err = ScanSelectedRowsToParameters(row, reflect.ValueOf(&asset).Elem(), 4, 1)
+	if !errors.Is(err, ErrComlumnIndexOutOfBounds) {
+		t.Errorf("wrong error: given %v, wanted %v", err, ErrComlumnIndexOutOfBounds)
+	}
+}
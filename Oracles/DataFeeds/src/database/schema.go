@@ -0,0 +1,513 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+var (
+	ErrIncompatibleSchema = errors.New("database schema is incompatible with the tables")
+)
+
+// Kind of difference between a table struct and the live table
+type SchemaDifferenceKind string
+
+const (
+	MissingTable        SchemaDifferenceKind = "missing table"
+	MissingColumn       SchemaDifferenceKind = "missing column"
+	ExtraColumn         SchemaDifferenceKind = "extra column"
+	TypeMismatch        SchemaDifferenceKind = "type mismatch"
+	NullabilityMismatch SchemaDifferenceKind = "nullability mismatch"
+	MissingIndex        SchemaDifferenceKind = "missing index"
+	MissingConstraint   SchemaDifferenceKind = "missing constraint"
+)
+
+// Difference between a table struct and the live table. Name is the
+// column, index or constraint concerned, Definition the tag it comes from
+// and Omittable whether an extra column can be left out on insertion
+type SchemaDifference struct {
+	Table      string
+	Name       string
+	Kind       SchemaDifferenceKind
+	Expected   string
+	Actual     string
+	Definition string
+	Omittable  bool
+}
+
+func (d SchemaDifference) String() string {
+	switch d.Kind {
+	case MissingTable:
+		return fmt.Sprintf("%s: %s", d.Table, d.Kind)
+	case TypeMismatch, NullabilityMismatch:
+		return fmt.Sprintf("%s.%s: %s, expected %s, found %s", d.Table, d.Name, d.Kind, d.Expected, d.Actual)
+	}
+	return fmt.Sprintf("%s.%s: %s", d.Table, d.Name, d.Kind)
+}
+
+// Whether the service can run despite the difference, extra columns
+// that can be omitted on insertion and missing indexes only are
+func (d SchemaDifference) Compatible() bool {
+	switch d.Kind {
+	case ExtraColumn:
+		return d.Omittable
+	case MissingIndex:
+		return true
+	}
+	return false
+}
+
+// Live column of information_schema.columns
+type liveColumn struct {
+	sqlType  string
+	nullable bool
+	omit     bool
+}
+
+// Keywords ending the type of a db tag
+var columnConstraintKeywords = []string{"NOT", "NULL", "DEFAULT", "PRIMARY", "UNIQUE", "CHECK", "REFERENCES", "CONSTRAINT", "GENERATED", "COLLATE"}
+
+// Index name of a CREATE INDEX statement
+var indexNameRegex = regexp.MustCompile(`(?i)^\s*CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+
+// Columns of a FOREIGN KEY reference
+var foreignKeyColumnsRegex = regexp.MustCompile(`(?i)FOREIGN\s+KEY\s*\(([^)]*)\)`)
+
+// Compares the table struct db, ref, idx and unique tags against the
+// live table
+//
+// Parameters:
+//   - db:		the database struct
+//   - table:	the struct table
+//
+// Returns:
+//   - []SchemaDifference:	the differences, empty if the schema matches
+//   - error:				if an error occured
+func DiffSchema(db Executor, table types.Table) ([]SchemaDifference, error) {
+	return DiffSchemaContext(context.Background(), db, table)
+}
+
+// Compares the table struct db, ref, idx and unique tags against the
+// live table. The queries are aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - table:	the struct table
+//
+// Returns:
+//   - []SchemaDifference:	the differences, empty if the schema matches
+//   - error:				if an error occured
+func DiffSchemaContext(ctx context.Context, db Executor, table types.Table) ([]SchemaDifference, error) {
+	tt := reflect.TypeOf(table)
+	if !utils.ValidateStruct(tt) {
+		return nil, ErrNotValidTable
+	}
+	name := utils.BaseTypeName(tt)
+	liveName := strings.ToLower(name)
+
+	columns, err := readLiveColumns(ctx, db, liveName)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return []SchemaDifference{{Table: name, Kind: MissingTable}}, nil
+	}
+
+	indexes, err := readLiveNames(ctx, db, "SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1", liveName)
+	if err != nil {
+		return nil, err
+	}
+
+	foreignKeys, err := readLiveNames(ctx, db, `SELECT kcu.column_name
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema() AND tc.table_name = $1`, liveName)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffTable(tt, columns, indexes, foreignKeys)
+}
+
+// Compares the table struct against its live columns, indexes and
+// foreign key columns
+//
+// Parameters:
+//   - tt:			the reflect table type
+//   - columns:		the live columns by name
+//   - indexes:		the live index names
+//   - foreignKeys:	the live columns referencing another table
+//
+// Returns:
+//   - []SchemaDifference:	the differences
+//   - error:				if a tag is malformed
+func diffTable(tt reflect.Type, columns map[string]liveColumn, indexes []string, foreignKeys []string) ([]SchemaDifference, error) {
+	name := utils.BaseTypeName(tt)
+	var diffs []SchemaDifference
+	expected := make(map[string]bool)
+
+	for i := 0; i < tt.NumField(); i++ {
+		f := tt.Field(i)
+
+		column, sqlType, notNull, err := parseColumnTag(f)
+		if err != nil {
+			return nil, err
+		}
+		definition, err := columnDefinition(f)
+		if err != nil {
+			return nil, err
+		}
+		expected[column] = true
+
+		live, ok := columns[column]
+		if !ok {
+			diffs = append(diffs, SchemaDifference{Table: name, Name: column, Kind: MissingColumn, Definition: definition})
+		} else {
+			if live.sqlType != sqlType {
+				diffs = append(diffs, SchemaDifference{Table: name, Name: column, Kind: TypeMismatch, Expected: sqlType, Actual: live.sqlType})
+			}
+			if live.nullable == notNull {
+				diffs = append(diffs, SchemaDifference{Table: name, Name: column, Kind: NullabilityMismatch, Expected: nullability(!notNull), Actual: nullability(live.nullable)})
+			}
+		}
+
+		if ref, ok := f.Tag.Lookup("ref"); ok {
+			for _, c := range parseForeignKeyColumns(ref) {
+				if !slices.Contains(foreignKeys, c) {
+					diffs = append(diffs, SchemaDifference{Table: name, Name: c, Kind: MissingConstraint, Definition: ref})
+				}
+			}
+		}
+
+		if idx, ok := f.Tag.Lookup("idx"); ok {
+			match := indexNameRegex.FindStringSubmatch(idx)
+			if match == nil {
+				return nil, fmt.Errorf("malformed idx tag: %s", idx)
+			}
+			if !slices.Contains(indexes, strings.ToLower(match[1])) {
+				diffs = append(diffs, SchemaDifference{Table: name, Name: match[1], Kind: MissingIndex, Definition: idx})
+			}
+		}
+	}
+
+	// Unique constraints are backed by an index of the same name
+	uniques, err := getUniqueConstraints(tt)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range uniques {
+		if !slices.Contains(indexes, strings.ToLower(u.Name)) {
+			diffs = append(diffs, SchemaDifference{Table: name, Name: u.Name, Kind: MissingConstraint, Definition: fmt.Sprintf("CONSTRAINT %s UNIQUE (%s)", u.Name, strings.Join(u.Columns, ", "))})
+		}
+	}
+
+	// Extra live columns, sorted for a stable output
+	var extra []string
+	for column := range columns {
+		if !expected[column] {
+			extra = append(extra, column)
+		}
+	}
+	slices.Sort(extra)
+	for _, column := range extra {
+		diffs = append(diffs, SchemaDifference{Table: name, Name: column, Kind: ExtraColumn, Actual: columns[column].sqlType, Omittable: columns[column].omit})
+	}
+
+	return diffs, nil
+}
+
+// Returns the statements reconciling the live table with its struct.
+// Extra columns are dropped only if dropColumns is set, since data is lost
+//
+// Parameters:
+//   - table:		the struct table
+//   - diffs:		the differences of DiffSchema
+//   - dropColumns:	whether extra columns are dropped
+//
+// Returns:
+//   - []string:	the statements
+//   - error:		if a missing table cannot be parsed
+func ReconcileStatements(table types.Table, diffs []SchemaDifference, dropColumns bool) ([]string, error) {
+	var statements []string
+	for _, d := range diffs {
+		alter := "ALTER TABLE " + d.Table + " "
+
+		switch d.Kind {
+		case MissingTable:
+			query, err := ParseStructToTable(reflect.TypeOf(table))
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, query)
+		case MissingColumn:
+			statements = append(statements, alter+"ADD COLUMN "+d.Definition)
+		case ExtraColumn:
+			if dropColumns {
+				statements = append(statements, alter+"DROP COLUMN "+d.Name)
+			}
+		case TypeMismatch:
+			statements = append(statements, fmt.Sprintf("%sALTER COLUMN %s TYPE %s USING %s::%s", alter, d.Name, d.Expected, d.Name, d.Expected))
+		case NullabilityMismatch:
+			if d.Expected == nullability(false) {
+				statements = append(statements, alter+"ALTER COLUMN "+d.Name+" SET NOT NULL")
+			} else {
+				statements = append(statements, alter+"ALTER COLUMN "+d.Name+" DROP NOT NULL")
+			}
+		case MissingIndex:
+			statements = append(statements, d.Definition)
+		case MissingConstraint:
+			statements = append(statements, alter+"ADD "+d.Definition)
+		}
+	}
+
+	return statements, nil
+}
+
+// Checks every table against the live schema, incompatible differences
+// are returned as an ErrIncompatibleSchema
+//
+// Parameters:
+//   - db:		the database struct
+//   - tables:	the struct tables
+//
+// Returns:
+//   - []SchemaDifference:	the compatible differences, to be reported
+//   - error:				ErrIncompatibleSchema or if an error occured
+func CheckSchema(db Executor, tables ...types.Table) ([]SchemaDifference, error) {
+	return CheckSchemaContext(context.Background(), db, tables...)
+}
+
+// Checks every table against the live schema, incompatible differences
+// are returned as an ErrIncompatibleSchema. The queries are aborted as
+// soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - tables:	the struct tables
+//
+// Returns:
+//   - []SchemaDifference:	the compatible differences, to be reported
+//   - error:				ErrIncompatibleSchema or if an error occured
+func CheckSchemaContext(ctx context.Context, db Executor, tables ...types.Table) ([]SchemaDifference, error) {
+	var compatible []SchemaDifference
+	var incompatible []string
+
+	for _, table := range tables {
+		diffs, err := DiffSchemaContext(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range diffs {
+			if d.Compatible() {
+				compatible = append(compatible, d)
+				continue
+			}
+			incompatible = append(incompatible, d.String())
+		}
+	}
+
+	if len(incompatible) > 0 {
+		return compatible, fmt.Errorf("%w:\n\t%s", ErrIncompatibleSchema, strings.Join(incompatible, "\n\t"))
+	}
+
+	return compatible, nil
+}
+
+// Reads the live columns of the table
+func readLiveColumns(ctx context.Context, db Executor, table string) (map[string]liveColumn, error) {
+	rows, err := db.QueryContext(ctx, `SELECT column_name, data_type, udt_name, character_maximum_length, numeric_precision, numeric_scale, is_nullable, column_default
+FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = $1`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]liveColumn)
+	for rows.Next() {
+		var name, dataType, udtName, isNullable string
+		var length, precision, scale sql.NullInt64
+		var columnDefault sql.NullString
+		if err := rows.Scan(&name, &dataType, &udtName, &length, &precision, &scale, &isNullable, &columnDefault); err != nil {
+			return nil, err
+		}
+
+		live := liveColumn{
+			sqlType:  liveSQLType(dataType, udtName, length, precision, scale),
+			nullable: isNullable == "YES",
+		}
+		live.omit = live.nullable || columnDefault.Valid
+		columns[name] = live
+	}
+
+	return columns, rows.Err()
+}
+
+// Reads the first column of every row
+func readLiveNames(ctx context.Context, db Executor, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// Parses the column name, normalized type and nullability of a db tag
+//
+// Parameters:
+//   - f:	the struct field
+//
+// Returns:
+//   - string:	the column name
+//   - string:	the normalized type
+//   - bool:	whether the column is NOT NULL
+//   - error:	if the tag is malformed
+func parseColumnTag(f reflect.StructField) (string, string, bool, error) {
+	tag, err := columnDefinition(f)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	tokens := strings.Fields(tag)
+	if len(tokens) < 2 {
+		return "", "", false, fmt.Errorf("db tag has no type: %s", tag)
+	}
+
+	end := len(tokens)
+	for i := 2; i < len(tokens); i++ {
+		if slices.Contains(columnConstraintKeywords, strings.ToUpper(tokens[i])) {
+			end = i
+			break
+		}
+	}
+
+	upper := strings.ToUpper(tag)
+	notNull := strings.Contains(upper, "NOT NULL") || strings.Contains(upper, "PRIMARY KEY")
+
+	return strings.ToLower(tokens[0]), normalizeSQLType(strings.Join(tokens[1:end], " ")), notNull, nil
+}
+
+// Normalizes a type to the information_schema.columns naming
+//
+// Parameters:
+//   - sqlType:	the type as written in a db tag
+//
+// Returns:
+//   - string:	the normalized type
+func normalizeSQLType(sqlType string) string {
+	t := strings.ToUpper(strings.Join(strings.Fields(sqlType), " "))
+
+	array := strings.HasSuffix(t, "[]")
+	t = strings.TrimSuffix(t, "[]")
+
+	args := ""
+	if i, j := strings.Index(t, "("), strings.Index(t, ")"); i >= 0 && j > i {
+		args = strings.ReplaceAll(t[i:j+1], " ", "")
+		t = strings.Join(strings.Fields(t[:i]+" "+t[j+1:]), " ")
+	}
+
+	var base string
+	switch t {
+	case "SERIAL", "INT", "INTEGER", "INT4":
+		base, args = "integer", ""
+	case "BIGSERIAL", "BIGINT", "INT8":
+		base, args = "bigint", ""
+	case "SMALLSERIAL", "SMALLINT", "INT2":
+		base, args = "smallint", ""
+	case "VARCHAR", "CHARACTER VARYING":
+		base = "character varying"
+	case "CHAR", "CHARACTER", "BPCHAR":
+		base = "character"
+	case "BOOL", "BOOLEAN":
+		base = "boolean"
+	case "TIMESTAMP", "TIMESTAMP WITHOUT TIME ZONE":
+		base = "timestamp without time zone"
+	case "TIMESTAMPTZ", "TIMESTAMP WITH TIME ZONE":
+		base = "timestamp with time zone"
+	case "DECIMAL", "NUMERIC":
+		base = "numeric"
+	case "REAL", "FLOAT4":
+		base = "real"
+	case "DOUBLE PRECISION", "FLOAT8":
+		base = "double precision"
+	default:
+		base = strings.ToLower(t)
+	}
+
+	if array {
+		// information_schema does not report element modifiers
+		return base + "[]"
+	}
+	if base == "timestamp without time zone" || base == "timestamp with time zone" {
+		// Precision is not part of the information_schema type
+		args = ""
+	}
+
+	return base + strings.ToLower(args)
+}
+
+// Returns the normalized type of an information_schema.columns row
+func liveSQLType(dataType string, udtName string, length sql.NullInt64, precision sql.NullInt64, scale sql.NullInt64) string {
+	switch dataType {
+	case "ARRAY":
+		return normalizeSQLType(strings.TrimPrefix(udtName, "_")) + "[]"
+	case "USER-DEFINED":
+		return udtName
+	case "character varying", "character":
+		if length.Valid {
+			return fmt.Sprintf("%s(%d)", dataType, length.Int64)
+		}
+	case "numeric":
+		if precision.Valid {
+			return fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
+		}
+	}
+
+	return dataType
+}
+
+// Parses the columns of a FOREIGN KEY reference
+func parseForeignKeyColumns(ref string) []string {
+	match := foreignKeyColumnsRegex.FindStringSubmatch(ref)
+	if match == nil {
+		return nil
+	}
+
+	var columns []string
+	for _, c := range strings.Split(match[1], ",") {
+		columns = append(columns, strings.ToLower(strings.TrimSpace(c)))
+	}
+
+	return columns
+}
+
+// Returns the nullability description
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Normalize types
+var NORMALIZED_SQL_TYPES = map[string]string{
+	"SERIAL":                      "integer",
+	"BIGSERIAL":                   "bigint",
+	"SMALLINT":                    "smallint",
+	"VARCHAR(16)":                 "character varying(16)",
+	"character varying (32)":      "character varying(32)",
+	"TIMESTAMP":                   "timestamp without time zone",
+	"TIMESTAMP(3) WITH TIME ZONE": "timestamp with time zone",
+	"NUMERIC(20, 8)":              "numeric(20,8)",
+	"DOUBLE   PRECISION":          "double precision",
+	"BIGINT[]":                    "bigint[]",
+	"VARCHAR(8)[]":                "character varying[]",
+	"JSONB":                       "jsonb",
+}
+
+func TestNormalizeSQLTypeFunc(t *testing.T) {
+	for input, correct := range NORMALIZED_SQL_TYPES {
+		if normalized := normalizeSQLType(input); normalized != correct {
+			t.Errorf("incorrect type of %s: given %s, wanted %s", input, normalized, correct)
+		}
+	}
+}
+
+// Diff tables
+var LIVE_PRICE_COLUMNS = map[string]liveColumn{
+	"id":        {sqlType: "integer", nullable: false, omit: true},
+	"asset_id":  {sqlType: "bigint", nullable: false},
+	"price":     {sqlType: "bigint", nullable: true},
+	"volume":    {sqlType: "bigint", nullable: true, omit: true},
+	"confirmed": {sqlType: "boolean", nullable: false},
+}
+
+var PRICE_DIFFERENCES = []SchemaDifference{
+	{Table: "Price", Name: "asset_id", Kind: TypeMismatch, Expected: "integer", Actual: "bigint"},
+	{Table: "Price", Name: "asset_id", Kind: MissingConstraint, Definition: "FOREIGN KEY (asset_id) REFERENCES asset(id)"},
+	{Table: "Price", Name: "idx_price_asset_id", Kind: MissingIndex, Definition: "CREATE INDEX idx_price_asset_id ON Price(asset_id)"},
+	{Table: "Price", Name: "price", Kind: NullabilityMismatch, Expected: "NOT NULL", Actual: "NULL"},
+	{Table: "Price", Name: "timestamp", Kind: MissingColumn, Definition: "timestamp TIMESTAMP DEFAULT NOW() NOT NULL"},
+	{Table: "Price", Name: "price_asset_id_timestamp_key", Kind: MissingConstraint, Definition: "CONSTRAINT price_asset_id_timestamp_key UNIQUE (asset_id, timestamp)"},
+	{Table: "Price", Name: "confirmed", Kind: ExtraColumn, Actual: "boolean"},
+	{Table: "Price", Name: "volume", Kind: ExtraColumn, Actual: "bigint", Omittable: true},
+}
+
+var PRICE_RECONCILE_STATEMENTS = []string{
+	"ALTER TABLE Price ALTER COLUMN asset_id TYPE integer USING asset_id::integer",
+	"ALTER TABLE Price ADD FOREIGN KEY (asset_id) REFERENCES asset(id)",
+	"CREATE INDEX idx_price_asset_id ON Price(asset_id)",
+	"ALTER TABLE Price ALTER COLUMN price SET NOT NULL",
+	"ALTER TABLE Price ADD COLUMN timestamp TIMESTAMP DEFAULT NOW() NOT NULL",
+	"ALTER TABLE Price ADD CONSTRAINT price_asset_id_timestamp_key UNIQUE (asset_id, timestamp)",
+	"ALTER TABLE Price DROP COLUMN confirmed",
+	"ALTER TABLE Price DROP COLUMN volume",
+}
+
+func TestDiffTableFunc(t *testing.T) {
+	diffs, err := diffTable(types.PRICE, LIVE_PRICE_COLUMNS, []string{"price_pkey"}, nil)
+	if err != nil {
+		t.Fatalf("error diffing the table: %v", err)
+	}
+
+	if !reflect.DeepEqual(diffs, PRICE_DIFFERENCES) {
+		t.Errorf("incorrect differences:\ngiven %v\nwanted %v", diffs, PRICE_DIFFERENCES)
+	}
+
+	for _, d := range diffs {
+		compatible := d.Kind == MissingIndex || d.Name == "volume"
+		if d.Compatible() != compatible {
+			t.Errorf("wrong compatibility of %v", d)
+		}
+	}
+
+	statements, err := ReconcileStatements(types.Price{}, diffs, true)
+	if err != nil {
+		t.Fatalf("error reconciling the table: %v", err)
+	}
+	if !reflect.DeepEqual(statements, PRICE_RECONCILE_STATEMENTS) {
+		t.Errorf("incorrect statements:\ngiven %v\nwanted %v", statements, PRICE_RECONCILE_STATEMENTS)
+	}
+
+	statements, _ = ReconcileStatements(types.Price{}, diffs, false)
+	if len(statements) != len(PRICE_RECONCILE_STATEMENTS)-2 {
+		t.Errorf("extra columns dropped: %v", statements)
+	}
+}
+
+// Diff live schema
+func TestDiffSchemaFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	// Missing tables
+	diffs, err := DiffSchema(db, types.Price{})
+	if err != nil || len(diffs) != 1 || diffs[0].Kind != MissingTable {
+		t.Fatalf("wrong missing table differences: %v %v", diffs, err)
+	}
+
+	for _, table := range []types.Table{types.Asset{}, types.Price{}} {
+		if _, err := CreateTable(db, table); err != nil {
+			t.Fatalf("error creating the table: %v", err)
+		}
+	}
+
+	if diffs, err := CheckSchema(db, types.Asset{}, types.Price{}); err != nil || len(diffs) != 0 {
+		t.Fatalf("created schema differs: %v %v", diffs, err)
+	}
+
+	// Drift
+	drift := []string{
+		"ALTER TABLE Price ALTER COLUMN price DROP NOT NULL",
+		"ALTER TABLE Price ADD COLUMN volume BIGINT",
+		"DROP INDEX idx_price_asset_id",
+	}
+	for _, d := range drift {
+		if _, err := db.Exec(d); err != nil {
+			t.Fatalf("error drifting the schema: %v", err)
+		}
+	}
+
+	diffs, err = CheckSchema(db, types.Asset{}, types.Price{})
+	if !errors.Is(err, ErrIncompatibleSchema) {
+		t.Errorf("wrong error: given %v, wanted %v", err, ErrIncompatibleSchema)
+	}
+	if len(diffs) != 2 {
+		t.Errorf("wrong compatible differences: %v", diffs)
+	}
+
+	// Reconcile
+	diffs, err = DiffSchema(db, types.Price{})
+	if err != nil {
+		t.Fatalf("error diffing the schema: %v", err)
+	}
+	statements, err := ReconcileStatements(types.Price{}, diffs, true)
+	if err != nil {
+		t.Fatalf("error reconciling the schema: %v", err)
+	}
+	for _, s := range statements {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("error reconciling the schema: %v", err)
+		}
+	}
+
+	if diffs, err := DiffSchema(db, types.Price{}); err != nil || len(diffs) != 0 {
+		t.Errorf("reconciled schema differs: %v %v", diffs, err)
+	}
+}
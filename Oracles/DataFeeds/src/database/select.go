@@ -1,12 +1,14 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
 )
 
@@ -23,7 +25,22 @@ var (
 // Returns:
 //   - *sql.Rows:	query rows result
 //   - error:		error if occured
-func SelectAll(db *sql.DB, table any) (*sql.Rows, error) {
+func SelectAll(db Executor, table any) (*sql.Rows, error) {
+	return SelectAllContext(context.Background(), db, table)
+}
+
+// Selects all rows from the table
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - table:	the table truct
+//
+// Returns:
+//   - *sql.Rows:	query rows result
+//   - error:		error if occured
+func SelectAllContext(ctx context.Context, db Executor, table any) (*sql.Rows, error) {
 	tt := reflect.TypeOf(table)
 
 	if !utils.ValidateStruct(tt) {
@@ -32,7 +49,7 @@ func SelectAll(db *sql.DB, table any) (*sql.Rows, error) {
 
 	query := "SELECT * FROM " + utils.BaseTypeName(tt)
 
-	return MakeQueryWithResult(db, query)
+	return MakeQueryWithResultContext(ctx, db, query)
 }
 
 // Makes a selection query of specific columns of the table
@@ -44,13 +61,28 @@ func SelectAll(db *sql.DB, table any) (*sql.Rows, error) {
 // Returns:
 //   - *sql.Rows:	query rows result
 //   - error:		error if occured
-func SelectColumns(db *sql.DB, table any, columns ...int) (*sql.Rows, error) {
+func SelectColumns(db Executor, table any, columns ...types.ColumnRef) (*sql.Rows, error) {
+	return SelectColumnsContext(context.Background(), db, table, columns...)
+}
+
+// Makes a selection query of specific columns of the table
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - table:	the table struct
+//
+// Returns:
+//   - *sql.Rows:	query rows result
+//   - error:		error if occured
+func SelectColumnsContext(ctx context.Context, db Executor, table any, columns ...types.ColumnRef) (*sql.Rows, error) {
 	query, err := parseStructToSelectColumns(reflect.TypeOf(table), columns...)
 	if err != nil {
 		return nil, err
 	}
 
-	return MakeQueryWithResult(db, query)
+	return MakeQueryWithResultContext(ctx, db, query)
 }
 
 // Parses the struct into a column selection query
@@ -62,7 +94,7 @@ func SelectColumns(db *sql.DB, table any, columns ...int) (*sql.Rows, error) {
 // Returns:
 //   - string:	the query
 //   - error:	error if occured
-func parseStructToSelectColumns(tt reflect.Type, columns ...int) (string, error) {
+func parseStructToSelectColumns(tt reflect.Type, columns ...types.ColumnRef) (string, error) {
 	if !utils.ValidateStruct(tt) {
 		return "", fmt.Errorf("table is not a struct")
 	}
@@ -74,13 +106,11 @@ func parseStructToSelectColumns(tt reflect.Type, columns ...int) (string, error)
 	var builder strings.Builder
 	builder.WriteString("SELECT ")
 	for i, c := range columns {
-		if c >= tt.NumField() {
-			return "", fmt.Errorf("field number out of range")
+		_, name_col, err := resolveColumn(tt, c)
+		if err != nil {
+			return "", err
 		}
 
-		str_db := tt.Field(c).Tag.Get("db")
-		name_col := strings.Split(str_db, " ")[0]
-
 		builder.WriteString(name_col)
 
 		if i+1 == len(columns) {
@@ -104,18 +134,51 @@ func parseStructToSelectColumns(tt reflect.Type, columns ...int) (string, error)
 // Returns:
 //   - *sql.Rows:	queried rows
 //   - error:		error if occured
-func SelectAllConditions(db *sql.DB, table any, conditions ...string) (*sql.Rows, error) {
-	query, err := buildSelectConditionsQuery(reflect.TypeOf(table), []int{}, conditions...)
+func SelectAllConditions(db Executor, table any, conditions ...string) (*sql.Rows, error) {
+	return SelectAllConditionsContext(context.Background(), db, table, conditions...)
+}
+
+// Makes a query selecting all columns with many custom conditions
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:			the context bounding the query
+//   - db:			database sql driver
+//   - table:		the table struct
+//   - conditions:	the conditions to include in the query
+//
+// Returns:
+//   - *sql.Rows:	queried rows
+//   - error:		error if occured
+func SelectAllConditionsContext(ctx context.Context, db Executor, table any, conditions ...string) (*sql.Rows, error) {
+	query, err := buildSelectConditionsQuery(reflect.TypeOf(table), nil, conditions...)
 	if err != nil {
 		return nil, err
 	}
 
-	return MakeQueryWithResult(db, query)
+	return MakeQueryWithResultContext(ctx, db, query)
+}
+
+// Makes a query selecting defined columns with many custom conditions
+//
+// Parameters:
+//   - db:				database sql driver
+//   - table:			the table struct
+//   - selectColumns:	the columns to be selected
+//   - conditions:		the conditions to include in the query
+//
+// Returns:
+//   - *sql.Rows:	queried rows
+//   - error:		error if occured
+func SelectColumnsConditions(db Executor, table any, selectColumns []types.ColumnRef, conditions ...string) (*sql.Rows, error) {
+	return SelectColumnsConditionsContext(context.Background(), db, table, selectColumns, conditions...)
 }
 
 // Makes a query selecting defined columns with many custom conditions
+// The query is aborted as soon as ctx is done
 //
 // Parameters:
+//   - ctx:				the context bounding the query
 //   - db:				database sql driver
 //   - table:			the table struct
 //   - selectColumns:	the columns to be selected
@@ -124,13 +187,13 @@ func SelectAllConditions(db *sql.DB, table any, conditions ...string) (*sql.Rows
 // Returns:
 //   - *sql.Rows:	queried rows
 //   - error:		error if occured
-func SelectColumnsConditions(db *sql.DB, table any, selectColumns []int, conditions ...string) (*sql.Rows, error) {
+func SelectColumnsConditionsContext(ctx context.Context, db Executor, table any, selectColumns []types.ColumnRef, conditions ...string) (*sql.Rows, error) {
 	query, err := buildSelectConditionsQuery(reflect.TypeOf(table), selectColumns, conditions...)
 	if err != nil {
 		return nil, err
 	}
 
-	return MakeQueryWithResult(db, query)
+	return MakeQueryWithResultContext(ctx, db, query)
 }
 
 // Builds a query with many conditions
@@ -143,7 +206,7 @@ func SelectColumnsConditions(db *sql.DB, table any, selectColumns []int, conditi
 // Returns:
 //   - string:	the query
 //   - error:	error if occured
-func buildSelectConditionsQuery(tt reflect.Type, selectColumns []int, conditions ...string) (string, error) {
+func buildSelectConditionsQuery(tt reflect.Type, selectColumns []types.ColumnRef, conditions ...string) (string, error) {
 	if !utils.ValidateStruct(tt) {
 		return "", fmt.Errorf("table is not a struct")
 	}
@@ -154,13 +217,7 @@ func buildSelectConditionsQuery(tt reflect.Type, selectColumns []int, conditions
 		builder.WriteString("*")
 	} else {
 		for i, sc := range selectColumns {
-			if sc >= tt.NumField() {
-				return "", ErrComlumnIndexOutOfBounds
-			}
-
-			f := tt.Field(sc)
-
-			fieldName, err := utils.GetFieldNameDB(f)
+			_, fieldName, err := resolveColumn(tt, sc)
 			if err != nil {
 				return "", err
 			}
@@ -198,25 +255,16 @@ func buildSelectConditionsQuery(tt reflect.Type, selectColumns []int, conditions
 // Returns:
 //   - *sql.Rows:	the rows result
 //   - error:		error if occured
-func SelectAllWhereAssetIdOrderedRow(db *sql.DB, table any, assetId int, orderByColumn int, limit int, desc bool) (*sql.Rows, error) {
-	// Build Order
-	tt := reflect.TypeOf(table)
-	if orderByColumn >= tt.NumField() {
-		return nil, ErrComlumnIndexOutOfBounds
-	}
-
-	conditions, err := buildSelectWhereAssetIdOrderedRowConditions(tt, assetId, orderByColumn, limit, desc)
-	if err != nil {
-		return nil, err
-	}
-
-	return SelectAllConditions(db, table, conditions...)
+func SelectAllWhereAssetIdOrderedRow(db Executor, table any, assetId int, orderByColumn types.ColumnRef, limit int, desc bool) (*sql.Rows, error) {
+	return SelectAllWhereAssetIdOrderedRowContext(context.Background(), db, table, assetId, orderByColumn, limit, desc)
 }
 
-// Builds conditions for a most recent query on the Price struct based on asset_id
+// Makes a ordered query on the Price struct based on asset_id filtering
+// The query is aborted as soon as ctx is done
 //
 // Parameters:
-//   - tt:				the reflect table type
+//   - ctx:			the context bounding the query
+//   - table:			the struct table
 //   - selectColumns:	the columns to be selected in the query (empty to select all), note that order in indexes will be followed in the query result
 //   - asset_id:		the asset_id to filter the query by
 //   - orderByColumn:	the column to order by
@@ -226,28 +274,14 @@ func SelectAllWhereAssetIdOrderedRow(db *sql.DB, table any, assetId int, orderBy
 // Returns:
 //   - *sql.Rows:	the rows result
 //   - error:		error if occured
-func buildSelectWhereAssetIdOrderedRowConditions(tt reflect.Type, asset_id int, orderByColumn int, limit int, desc bool) ([]string, error) {
-	var conditions []string
-
-	dbColumnName, err := utils.GetFieldNameDB(tt.Field(orderByColumn))
-	if err != nil {
-		return nil, err
-	}
-
-	conditions = append(conditions, fmt.Sprintf("WHERE asset_id = %d", asset_id))
-
-	if desc {
-		conditions = append(conditions, fmt.Sprintf("ORDER BY %s DESC", dbColumnName))
-	} else {
-		conditions = append(conditions, fmt.Sprintf("ORDER BY %s ASC", dbColumnName))
-	}
-
-	// Insert limit
-	if limit >= 0 {
-		conditions = append(conditions, fmt.Sprintf("LIMIT %d", limit))
-	}
-
-	return conditions, nil
+func SelectAllWhereAssetIdOrderedRowContext(ctx context.Context, db Executor, table any, assetId int, orderByColumn types.ColumnRef, limit int, desc bool) (*sql.Rows, error) {
+	assetIdColumn := fieldColumn{table: reflect.TypeOf(table), name: "asset_id"}
+
+	qb := NewQueryBuilder(table).
+		Where(types.Where(assetIdColumn, types.Equal, assetId)).
+		OrderBy(orderByColumn, desc).
+		Limit(limit)
+	return SelectWhereContext(ctx, db, qb)
 }
 
 // Makes a query on assets based on the source
@@ -263,19 +297,27 @@ func buildSelectWhereAssetIdOrderedRowConditions(tt reflect.Type, asset_id int,
 // Returns:
 //   - *sql.Rows:	the rows result
 //   - error:		error if occured
-func SelectTableByMatchRow(db *sql.DB, table any, matchColumns []int, matchValues []any, limit int) (*sql.Rows, error) {
-	// Build Order
-	tt := reflect.TypeOf(table)
-	if len(matchColumns) >= tt.NumField() || len(matchColumns) != len(matchValues) {
-		return nil, ErrComlumnIndexOutOfBounds
-	}
-
-	conditions, err := buildSelectTableByMatch(tt, matchColumns, matchValues, limit)
-	if err != nil {
-		return nil, err
-	}
+func SelectTableByMatchRow(db Executor, table any, matchColumns []types.ColumnRef, matchValues []any, limit int) (*sql.Rows, error) {
+	return SelectTableByMatchRowContext(context.Background(), db, table, matchColumns, matchValues, limit)
+}
 
-	return SelectColumnsConditions(db, table, []int{}, conditions...)
+// Makes a query on assets based on the source
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:			the context bounding the query
+//   - db:			the databse driver
+//   - table:	the
+//   - asset_id:		the asset_id to filter the query by
+//   - orderByColumn:	the column to order by
+//   - limit:			the maximum number of elements to retrive (>0)
+//   - desc:			if you wish to sort descending or ascending
+//
+// Returns:
+//   - *sql.Rows:	the rows result
+//   - error:		error if occured
+func SelectTableByMatchRowContext(ctx context.Context, db Executor, table any, matchColumns []types.ColumnRef, matchValues []any, limit int) (*sql.Rows, error) {
+	return SelectTableByMatchColumnsContext(ctx, db, table, nil, matchColumns, matchValues, limit)
 }
 
 // Makes the select query where you can select specific values for each column
@@ -291,25 +333,18 @@ func SelectTableByMatchRow(db *sql.DB, table any, matchColumns []int, matchValue
 // Returns:
 //   - *sql.Rows:	the rows result
 //   - error:		error if occured
-func SelectTableByMatchColumns(db *sql.DB, table any, selectColumns []int, matchColumns []int, matchValues []any, limit int) (*sql.Rows, error) {
-	// Build Order
-	tt := reflect.TypeOf(table)
-	if len(matchColumns) >= tt.NumField() || len(selectColumns) >= tt.NumField() || len(matchColumns) != len(matchValues) {
-		return nil, ErrComlumnIndexOutOfBounds
-	}
-
-	conditions, err := buildSelectTableByMatch(tt, matchColumns, matchValues, limit)
-	if err != nil {
-		return nil, err
-	}
-
-	return SelectColumnsConditions(db, table, selectColumns, conditions...)
+func SelectTableByMatchColumns(db Executor, table any, selectColumns []types.ColumnRef, matchColumns []types.ColumnRef, matchValues []any, limit int) (*sql.Rows, error) {
+	return SelectTableByMatchColumnsContext(context.Background(), db, table, selectColumns, matchColumns, matchValues, limit)
 }
 
-// Builds the select query where you can select specific values for each column
+// Makes the select query where you can select specific values for each column
+// The query is aborted as soon as ctx is done
 //
 // Parameters:
-//   - tt:				the struct table reflect type
+//   - ctx:				the context bounding the query
+//   - db:				the databse driver
+//   - table:			the struct table
+//   - selectColumns:	the columns to be selected
 //   - matchColumns:	the columns to be matched with a value
 //   - matchValues:		values that are going to be matched with
 //   - limit:			the limit of columns to return, negative to return them all
@@ -317,44 +352,71 @@ func SelectTableByMatchColumns(db *sql.DB, table any, selectColumns []int, match
 // Returns:
 //   - *sql.Rows:	the rows result
 //   - error:		error if occured
-func buildSelectTableByMatch(tt reflect.Type, matchColumns []int, matchValues []any, limit int) ([]string, error) {
-	var conditions []string
-	var builder strings.Builder
+func SelectTableByMatchColumnsContext(ctx context.Context, db Executor, table any, selectColumns []types.ColumnRef, matchColumns []types.ColumnRef, matchValues []any, limit int) (*sql.Rows, error) {
+	conditions, err := buildMatchConditions(matchColumns, matchValues)
+	if err != nil {
+		return nil, err
+	}
 
-	builder.WriteString("WHERE ")
-	mvv := reflect.ValueOf(matchValues)
-	for i := 0; i < len(matchColumns); i++ {
-		match_column, err := utils.GetFieldNameDB(tt.Field(matchColumns[i]))
-		if err != nil {
-			return []string{}, err
-		}
-		match_value := ParseValueToEntry(mvv.Index(i).Elem())
-
-		if i == 0 {
-			builder.WriteString(match_column)
-			builder.WriteString(" = ")
-			builder.WriteString(match_value)
-			conditions = append(conditions, builder.String())
-			builder.Reset()
-			continue
-		}
+	qb := NewQueryBuilder(table).Select(selectColumns...).Where(conditions...).Limit(limit)
+	return SelectWhereContext(ctx, db, qb)
+}
 
-		builder.WriteString("AND ")
-		builder.WriteString(match_column)
-		builder.WriteString(" = ")
-		builder.WriteString(match_value)
-		conditions = append(conditions, builder.String())
-		builder.Reset()
+// Builds the equality conditions of each column with its value
+//
+// Parameters:
+//   - matchColumns:	the columns to be matched with a value
+//   - matchValues:		values that are going to be matched with
+//
+// Returns:
+//   - []types.WhereCondition:	the conditions
+//   - error:					error if occured
+func buildMatchConditions(matchColumns []types.ColumnRef, matchValues []any) ([]types.WhereCondition, error) {
+	if len(matchColumns) != len(matchValues) {
+		return nil, fmt.Errorf("%d match columns for %d match values", len(matchColumns), len(matchValues))
 	}
 
-	// Insert limit
-	if limit >= 0 {
-		conditions = append(conditions, fmt.Sprintf("LIMIT %d", limit))
+	conditions := make([]types.WhereCondition, len(matchColumns))
+	for i, c := range matchColumns {
+		conditions[i] = types.Where(c, types.Equal, matchValues[i])
 	}
 
 	return conditions, nil
 }
 
+// Makes the selection query built by the query builder
+//
+// Parameters:
+//   - db:	the databse driver
+//   - qb:	the query builder
+//
+// Returns:
+//   - *sql.Rows:	the rows result
+//   - error:		error if occured
+func SelectWhere(db Executor, qb *QueryBuilder) (*sql.Rows, error) {
+	return SelectWhereContext(context.Background(), db, qb)
+}
+
+// Makes the selection query built by the query builder
+// The query is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:	the context bounding the query
+//   - db:	the databse driver
+//   - qb:	the query builder
+//
+// Returns:
+//   - *sql.Rows:	the rows result
+//   - error:		error if occured
+func SelectWhereContext(ctx context.Context, db Executor, qb *QueryBuilder) (*sql.Rows, error) {
+	query, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return MakeQueryWithResultContext(ctx, db, query, args...)
+}
+
 // func parseCustomStructValue(cv reflect.Value) {
 
 // }
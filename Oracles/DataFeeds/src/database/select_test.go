@@ -1,16 +1,18 @@
 package database
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 )
 
 // Types
 type SelectColumnsInput struct {
 	Struct  any
-	Columns []int
+	Columns []types.ColumnRef
 }
 type SelectColumnsCorrect struct {
 	Correct bool
@@ -36,7 +38,7 @@ var STRUCTS_TO_SELECT_COLUMNS = []TestSelectColumnsInput{
 				Source:   "Binance",
 				Decimals: 0,
 			},
-			Columns: []int{0, 2, 3},
+			Columns: []types.ColumnRef{types.AssetId, types.AssetSource, types.AssetDecimals},
 		},
 		Correct: SelectColumnsCorrect{
 			Correct: true,
@@ -55,7 +57,7 @@ FROM Asset`,
 				Source:   "Binance",
 				Decimals: 0,
 			},
-			Columns: []int{0, 2, 3, 1, 3, 2},
+			Columns: []types.ColumnRef{types.AssetId, types.AssetSource, types.AssetDecimals, types.AssetTicker, types.AssetDecimals, types.AssetSource},
 		},
 		Correct: SelectColumnsCorrect{
 			Correct: false,
@@ -65,7 +67,7 @@ FROM Asset`,
 	{
 		Input: SelectColumnsInput{
 			Struct:  123,
-			Columns: []int{},
+			Columns: []types.ColumnRef{},
 		},
 		Correct: SelectColumnsCorrect{
 			Correct: false,
@@ -83,7 +85,7 @@ FROM Asset`,
 				Source:   "Binance",
 				Decimals: 0,
 			},
-			Columns: []int{},
+			Columns: []types.ColumnRef{},
 		},
 		Correct: SelectColumnsCorrect{
 			Correct: false,
@@ -101,7 +103,17 @@ FROM Asset`,
 				Source:   "Binance",
 				Decimals: 0,
 			},
-			Columns: []int{6},
+			Columns: []types.ColumnRef{types.Col[types.Asset]("volume")},
+		},
+		Correct: SelectColumnsCorrect{
+			Correct: false,
+			Query:   ``,
+		},
+	},
+	{
+		Input: SelectColumnsInput{
+			Struct:  types.Asset{},
+			Columns: []types.ColumnRef{types.AssetId, types.PriceTimestamp},
 		},
 		Correct: SelectColumnsCorrect{
 			Correct: false,
@@ -129,7 +141,7 @@ func TestParseStructToSelectColumns(t *testing.T) {
 // Test Build Selection Query
 type BuildSelectionQueriesInput struct {
 	Conditions []string
-	Columns    []int
+	Columns    []types.ColumnRef
 	Table      any
 }
 type BuildSelectionQueries struct {
@@ -144,7 +156,7 @@ var BUILD_SELECTION_QUERIES = []BuildSelectionQueries{
 				"ORDER BY timestamp DESC",
 				"LIMIT 10",
 			},
-			Columns: []int{},
+			Columns: nil,
 			Table: types.Asset{
 				Id: types.Default[uint64]{
 					Default: true,
@@ -162,7 +174,7 @@ LIMIT 10`,
 				"ORDER BY timestamp DESC",
 				"LIMIT 10",
 			},
-			Columns: []int{2, 3},
+			Columns: []types.ColumnRef{types.AssetSource, types.AssetDecimals},
 			Table: types.Asset{
 				Id: types.Default[uint64]{
 					Default: true,
@@ -276,11 +288,8 @@ var MOST_RECENT_ROWS = MostRecentRows{
 }
 
 func TestSelectMostRecentRowsFunc(t *testing.T) {
-	_, db, cleanup, err := InitMockSqlDB()
-	if err != nil {
-		t.Fatalf("DB failed to start: %v", err)
-	}
-	defer cleanup()
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{}, types.Price{})
 
 	results, errors := InsertEntries(db, MOST_RECENT_ROWS.Input.EntryRows)
 	for _, err := range errors {
@@ -300,7 +309,7 @@ func TestSelectMostRecentRowsFunc(t *testing.T) {
 		t.Logf("Rows: %d", row)
 	}
 
-	rows, err := SelectAllWhereAssetIdOrderedRow(db, types.Price{}, 0, 3, 4, true)
+	rows, err := SelectAllWhereAssetIdOrderedRow(db, types.Price{}, 0, types.PriceTimestamp, 4, true)
 	if err != nil {
 		t.Errorf("error when selecting most recent rows: %v", err)
 		return
@@ -329,13 +338,12 @@ func TestSelectMostRecentRowsFunc(t *testing.T) {
 
 type BuildSelectTableMatch struct {
 	table    any
-	matchCol []int
+	matchCol []types.ColumnRef
 	matchVal []any
 	Limit    int
 }
 type BuildSelectTableMatchInput struct {
-	Input   BuildSelectTableMatch
-	Correct []string
+	Input BuildSelectTableMatch
 }
 
 var BUILD_SELECT_TABLE_MATCH = []BuildSelectTableMatchInput{
@@ -353,40 +361,65 @@ var BUILD_SELECT_TABLE_MATCH = []BuildSelectTableMatchInput{
 				},
 				Price: 1000,
 			},
-			matchCol: []int{1, 3},
+			matchCol: []types.ColumnRef{types.PriceAssetId, types.PriceTimestamp},
 			matchVal: []any{
 				124,
 				"4444",
 			},
 			Limit: 10,
 		},
-		Correct: []string{
-			`WHERE asset_id = 124`,
-			`AND timestamp = '4444'`,
-			`LIMIT 10`,
-		},
 	},
 }
 
-func TestBuildSelectTableByMatch(t *testing.T) {
+func TestBuildSelectTableByMatchQueryBuilderFunc(t *testing.T) {
+	correct := `SELECT * FROM Price
+WHERE asset_id = $1 AND timestamp = $2
+LIMIT 10`
+	correctDelete := `DELETE FROM Price
+WHERE asset_id = $1 AND timestamp = $2`
+
 	for _, bstm := range BUILD_SELECT_TABLE_MATCH {
-		strings, err := buildSelectTableByMatch(reflect.TypeOf(bstm.Input.table), bstm.Input.matchCol, bstm.Input.matchVal, bstm.Input.Limit)
+		conditions, err := buildMatchConditions(bstm.Input.matchCol, bstm.Input.matchVal)
+		if err != nil {
+			t.Errorf("error when building match conditions: %v", err)
+			continue
+		}
 
+		query, args, err := NewQueryBuilder(bstm.Input.table).Where(conditions...).Limit(bstm.Input.Limit).Build()
 		if err != nil {
 			t.Errorf("error when building select table by match query: %v", err)
 		}
 
-		for i := 0; i < len(strings); i++ {
-			if strings[i] != bstm.Correct[i] {
-				t.Errorf("error building the query: \ngiven %v\nwanted %v", strings[i], bstm.Correct[i])
-			}
+		if query != correct {
+			t.Errorf("error building the query: \ngiven %v\nwanted %v", query, correct)
+		}
+
+		if !reflect.DeepEqual(args, bstm.Input.matchVal) {
+			t.Errorf("error binding the query arguments: \ngiven %v\nwanted %v", args, bstm.Input.matchVal)
+		}
+
+		query, args, err = NewQueryBuilder(bstm.Input.table).Where(conditions...).BuildDelete()
+		if err != nil {
+			t.Errorf("error when building delete table by match query: %v", err)
+		}
+
+		if query != correctDelete {
+			t.Errorf("error building the query: \ngiven %v\nwanted %v", query, correctDelete)
+		}
+
+		if !reflect.DeepEqual(args, bstm.Input.matchVal) {
+			t.Errorf("error binding the query arguments: \ngiven %v\nwanted %v", args, bstm.Input.matchVal)
 		}
 	}
+
+	if _, err := DeleteRowsByMatch(nil, types.Price{}, nil, nil); !errors.Is(err, ErrNoMatchColumns) {
+		t.Errorf("wrong error: given %v, wanted %v", err, ErrNoMatchColumns)
+	}
 }
 
 type SelectTableMatch struct {
 	table    any
-	matchCol []int
+	matchCol []types.ColumnRef
 	matchVal []any
 	Limit    int
 }
@@ -503,8 +536,8 @@ var SELECT_TABLE_MATCH = SelectTableMatchInput{
 	},
 	Input: SelectTableMatch{
 		table: types.Price{},
-		matchCol: []int{
-			1, 2,
+		matchCol: []types.ColumnRef{
+			types.PriceAssetId, types.PricePrice,
 		},
 		matchVal: []any{
 			2, 99,
@@ -518,11 +551,8 @@ var SELECT_TABLE_MATCH = SelectTableMatchInput{
 }
 
 func TestSelectTableByMatchColumnsFunc(t *testing.T) {
-	_, db, cleanup, err := InitMockSqlDB()
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer cleanup()
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{}, types.Price{})
 
 	// Build Database
 	results, errors := InsertEntries(db, SELECT_TABLE_MATCH.Entries)
@@ -544,7 +574,7 @@ func TestSelectTableByMatchColumnsFunc(t *testing.T) {
 	}
 
 	// Make Query
-	rows, err := SelectTableByMatchColumns(db, SELECT_TABLE_MATCH.Input.table, []int{}, SELECT_TABLE_MATCH.Input.matchCol, SELECT_TABLE_MATCH.Input.matchVal, SELECT_TABLE_MATCH.Input.Limit)
+	rows, err := SelectTableByMatchColumns(db, SELECT_TABLE_MATCH.Input.table, nil, SELECT_TABLE_MATCH.Input.matchCol, SELECT_TABLE_MATCH.Input.matchVal, SELECT_TABLE_MATCH.Input.Limit)
 	if err != nil {
 		t.Errorf("error when selecting most recent rows: %v", err)
 		return
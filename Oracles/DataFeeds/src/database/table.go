@@ -1,10 +1,13 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
@@ -14,6 +17,12 @@ var (
 	ErrTableExists = errors.New("table already exists")
 )
 
+// Partition tag: <RANGE|LIST|HASH> (<columns>)
+var partitionTagRegex = regexp.MustCompile(`(?i)^\s*(RANGE|LIST|HASH)\s*\(([^)]*)\)\s*$`)
+
+// Inline primary key of a db tag
+var primaryKeyRegex = regexp.MustCompile(`(?i)\s+PRIMARY\s+KEY`)
+
 // Takes a db driver and a data struct, it then creates the struct table
 // in the db
 //
@@ -24,14 +33,29 @@ var (
 // Returns:
 //   - QueriesResult:	an array of results of the same length as entries
 //   - error:			if any error occured
-func CreateTable(db *sql.DB, data any) (sql.Result, error) {
+func CreateTable(db Executor, data any) (sql.Result, error) {
+	return CreateTableContext(context.Background(), db, data)
+}
+
+// Takes a db driver and a data struct, it then creates the struct table
+// in the db. The creation is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - data:	the data struct
+//
+// Returns:
+//   - QueriesResult:	an array of results of the same length as entries
+//   - error:			if any error occured
+func CreateTableContext(ctx context.Context, db Executor, data any) (sql.Result, error) {
 	// Check struct
 	if !utils.ValidateStruct(reflect.TypeOf(data)) {
 		return nil, fmt.Errorf("data is not a struct")
 	}
 
 	// Check existence
-	exists, err := CheckIfTableExists(db, data)
+	exists, err := CheckIfTableExistsContext(ctx, db, data)
 	if exists {
 		return nil, ErrTableExists
 	} else if err != nil {
@@ -44,8 +68,27 @@ func CreateTable(db *sql.DB, data any) (sql.Result, error) {
 		return nil, err
 	}
 
-	// Query
-	return MakeQuery(db, query)
+	// The table comes along its default partition and indexes, created
+	// together one statement at a time
+	statements, err := utils.SplitSQLStatements(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result sql.Result
+	err = WithTxContext(ctx, db, TxConfig{}, func(tx Executor) error {
+		for i, statement := range statements {
+			r, err := MakeQueryContext(ctx, tx, statement)
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				result = r
+			}
+		}
+		return nil
+	})
+	return result, err
 }
 
 // Takes a db driver and a data struct, it makes a query to
@@ -58,18 +101,33 @@ func CreateTable(db *sql.DB, data any) (sql.Result, error) {
 // Returns:
 //   - bool:	if the table exists or not
 //   - error:	if any error occured
-func CheckIfTableExists[T any](db *sql.DB, data T) (bool, error) {
+func CheckIfTableExists[T any](db Executor, data T) (bool, error) {
+	return CheckIfTableExistsContext(context.Background(), db, data)
+}
+
+// Takes a db driver and a data struct, it makes a query to
+// see if the struct table exists or not until ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - data:	the data struct
+//
+// Returns:
+//   - bool:	if the table exists or not
+//   - error:	if any error occured
+func CheckIfTableExistsContext[T any](ctx context.Context, db Executor, data T) (bool, error) {
 	d := reflect.TypeOf(data)
 	name := strings.ToLower(utils.BaseTypeName(d))
 
-	query := fmt.Sprintf(`SELECT EXISTS (
+	query := `SELECT EXISTS (
 		SELECT 1 
 		FROM information_schema.tables 
-		WHERE table_schema = 'public' 
-		AND table_name = '%s'
-	);`, name)
+		WHERE table_schema = current_schema() 
+		AND table_name = $1
+	);`
 
-	queryRows, err := MakeQueryWithResult(db, query)
+	queryRows, err := MakeQueryWithResultContext(ctx, db, query, name)
 
 	if err != nil {
 		return false, err
@@ -93,6 +151,10 @@ func CheckIfTableExists[T any](db *sql.DB, data T) (bool, error) {
 
 // Parses the struct into a table and returns its creation query
 //
+// A partitioned table, whose field has a `partition` tag, gets its primary
+// key widened with the partition columns as Postgres requires, and a
+// <table>_default partition for RANGE and LIST partitioning
+//
 // Parameters:
 //   - data:	the struct to be created as table
 //
@@ -100,19 +162,20 @@ func CheckIfTableExists[T any](db *sql.DB, data T) (bool, error) {
 //   - string:	the table creation query
 //   - error:	if any erro occured during parsing
 func ParseStructToTable(data reflect.Type) (string, error) {
-	var idx []string // idx
-	var ref []string // references
+	var defs []string // columns and table constraints
+	var idx []string  // idx
+	var ref []string  // references
 
 	// Check it is a struct
 	if !utils.ValidateStruct(data) {
 		return "", fmt.Errorf("cannot parse non-struct into table: %v", data)
 	}
 
-	// Build String
-	var builder strings.Builder
-	builder.WriteString("CREATE TABLE IF NOT EXISTS ")
-	builder.WriteString(data.Name())
-	builder.WriteString(" (\n")
+	partitioning, err := getPartitioning(data)
+	if err != nil {
+		return "", err
+	}
+	var primaryKey []string
 
 	// Parse each field into query
 	numField := data.NumField()
@@ -120,34 +183,29 @@ func ParseStructToTable(data reflect.Type) (string, error) {
 		f := data.Field(i)
 
 		// Check if there is a nested struct other than types.Default
-		if utils.ValidateStruct(f.Type) && !utils.ValidateCustomStruct(f.Type) {
+		if utils.ValidateStruct(f.Type) && !utils.ValidateCustomStruct(f.Type) && !utils.ValidateValueStruct(f.Type) {
 			return "", fmt.Errorf("cannot have nested struct as tables: %v", f)
 		}
 
-		// Add db type
-		str_db, ok_db := f.Tag.Lookup("db")
-		if ok_db {
-			if i == numField-1 && len(ref) == 0 {
-				// If it is the last skip the triling comma
-				builder.WriteString("\t")
-				builder.WriteString(str_db)
-			} else {
-				builder.WriteString("\t")
-				builder.WriteString(str_db)
-				builder.WriteString(",")
+		// Add db type, inferred from the field type if the tag only names the column
+		str_db, err := columnDefinition(f)
+		if err != nil {
+			return "", err
+		}
+		if partitioning != nil && isPrimaryKeyField(f) {
+			column, err := utils.GetFieldNameDB(f)
+			if err != nil {
+				return "", err
 			}
-		} else {
-			return "", fmt.Errorf("column is not defined")
+			primaryKey = append(primaryKey, column)
+			str_db = primaryKeyRegex.ReplaceAllString(str_db, "")
 		}
+		defs = append(defs, str_db)
 
 		// Add any references
 		str_ref, ok_ref := f.Tag.Lookup("ref")
 		if ok_ref {
 			ref = append(ref, str_ref)
-
-			if i == numField-1 {
-				builder.WriteString(",")
-			}
 		}
 
 		// Add any index
@@ -155,44 +213,154 @@ func ParseStructToTable(data reflect.Type) (string, error) {
 		if ok_idx {
 			idx = append(idx, str_idx)
 		}
+	}
 
-		// Close the line
-		builder.WriteString("\n")
+	// Add the widened primary key before the references
+	if len(primaryKey) > 0 {
+		for _, c := range partitioning.Columns {
+			if !slices.Contains(primaryKey, c) {
+				primaryKey = append(primaryKey, c)
+			}
+		}
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
 	}
 
 	// Add any reference at the end of the table
-	numRef := len(ref)
-	for i, r := range ref {
-		if i == numRef-1 {
-			builder.WriteString("\t")
-			builder.WriteString(r)
-			builder.WriteString("\n")
-			continue
-		}
+	defs = append(defs, ref...)
 
-		builder.WriteString("\t")
-		builder.WriteString(r)
-		builder.WriteString(",\n")
+	// Add any unique constraint after the references
+	uniques, err := getUniqueConstraints(data)
+	if err != nil {
+		return "", err
+	}
+	for _, u := range uniques {
+		defs = append(defs, fmt.Sprintf("CONSTRAINT %s UNIQUE (%s)", u.Name, strings.Join(u.Columns, ", ")))
+	}
+
+	// Build String
+	var builder strings.Builder
+	builder.WriteString("CREATE TABLE IF NOT EXISTS ")
+	builder.WriteString(data.Name())
+	builder.WriteString(" (\n\t")
+	builder.WriteString(strings.Join(defs, ",\n\t"))
+	builder.WriteString("\n)")
+	if partitioning != nil {
+		builder.WriteString(fmt.Sprintf(" PARTITION BY %s (%s);", partitioning.Method, strings.Join(partitioning.Columns, ", ")))
+		if partitioning.Method != "HASH" {
+			builder.WriteString(fmt.Sprintf("\nCREATE TABLE IF NOT EXISTS %s_default PARTITION OF %s DEFAULT;", data.Name(), data.Name()))
+		}
+	} else {
+		builder.WriteString(";")
 	}
-	builder.WriteString(");")
 
 	// Add any indexes at the end of the query
-	numIdx := len(idx)
-	for i, id := range idx {
-		if i == 0 {
-			builder.WriteString("\n")
+	for _, id := range idx {
+		builder.WriteString("\n")
+		builder.WriteString(id)
+		builder.WriteString(";")
+	}
+
+	// Return the query
+	return builder.String(), nil
+}
+
+// Partitioning of a table, defined by the `partition` tag of a field
+type partitioning struct {
+	Method  string
+	Columns []string
+}
+
+// Returns the table partitioning, nil if the table is not partitioned.
+// At most one field can define it
+//
+// Parameters:
+//   - data:	the table struct type
+//
+// Returns:
+//   - *partitioning:	the partitioning
+//   - error:			if the tag is malformed or repeated
+func getPartitioning(data reflect.Type) (*partitioning, error) {
+	var p *partitioning
+
+	for i := 0; i < data.NumField(); i++ {
+		tag, ok := data.Field(i).Tag.Lookup("partition")
+		if !ok {
+			continue
+		}
+		if p != nil {
+			return nil, fmt.Errorf("table %s is partitioned more than once", data.Name())
+		}
+
+		match := partitionTagRegex.FindStringSubmatch(tag)
+		if match == nil {
+			return nil, fmt.Errorf("malformed partition tag: %s", tag)
 		}
 
-		if i == numIdx-1 {
-			builder.WriteString(id)
-			builder.WriteString(";")
+		p = &partitioning{Method: strings.ToUpper(match[1])}
+		for _, c := range strings.Split(match[2], ",") {
+			p.Columns = append(p.Columns, strings.TrimSpace(c))
+		}
+	}
+
+	return p, nil
+}
+
+// Unique constraint of a table, defined by the fields sharing
+// the same `unique` tag name
+type uniqueConstraint struct {
+	Name    string
+	Columns []string
+}
+
+// Collects the unique constraints of the table, in the order their
+// first column appears. Fields with the same `unique` tag form a
+// composite constraint
+//
+// Parameters:
+//   - data:	the table struct type
+//
+// Returns:
+//   - []uniqueConstraint:	the unique constraints
+//   - error:				if any error occured
+func getUniqueConstraints(data reflect.Type) ([]uniqueConstraint, error) {
+	var uniques []uniqueConstraint
+	positions := map[string]int{}
+
+	for i := 0; i < data.NumField(); i++ {
+		f := data.Field(i)
+
+		name, ok := f.Tag.Lookup("unique")
+		if !ok {
 			continue
 		}
+		if name == "" {
+			return nil, fmt.Errorf("unique constraint of %s has no name", f.Name)
+		}
 
-		builder.WriteString(id)
-		builder.WriteString(";\n")
+		column, err := utils.GetFieldNameDB(f)
+		if err != nil {
+			return nil, err
+		}
+
+		position, ok := positions[name]
+		if !ok {
+			position = len(uniques)
+			positions[name] = position
+			uniques = append(uniques, uniqueConstraint{Name: name})
+		}
+		uniques[position].Columns = append(uniques[position].Columns, column)
 	}
 
-	// Return the query
-	return builder.String(), nil
+	return uniques, nil
+}
+
+// Checks whether the field is the table primary key
+//
+// Parameters:
+//   - f:	the struct field
+//
+// Returns:
+//   - bool:	if the field column is declared as PRIMARY KEY
+func isPrimaryKeyField(f reflect.StructField) bool {
+	return strings.Contains(strings.ToUpper(f.Tag.Get("db")), "PRIMARY KEY")
 }
@@ -4,8 +4,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
-	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
 )
 
 // Types
@@ -17,6 +17,18 @@ type TestParseStruct struct {
 	Unit_price      float32              `json:"unit_price" db:"unit_price DECIMAL(10, 2) NOT NULL CHECK (unit_price > 0)" idx:"CREATE INDEX idx_test_unit_price ON TestParseStruct(unit_price)"`
 }
 
+type TestUpsertStruct struct {
+	Id     types.Default[int64] `json:"id" db:"id SERIAL PRIMARY KEY"`
+	Code   string               `json:"code" db:"code VARCHAR(16) NOT NULL" unique:"testupsertstruct_code_day_key"`
+	Day    types.Timestamp      `json:"day" db:"day TIMESTAMP DEFAULT NOW() NOT NULL" unique:"testupsertstruct_code_day_key"`
+	Amount types.Default[int64] `json:"amount" db:"amount BIGINT DEFAULT 0 NOT NULL"`
+	Note   types.Null[string]   `json:"note" db:"note VARCHAR(64)" unique:"testupsertstruct_note_key"`
+}
+
+func (t TestUpsertStruct) GetPrimaryKeyNameDB() (string, error) {
+	return "id", nil
+}
+
 type TestCheckIfTableExistsInput struct {
 	Input   any
 	Create  bool
@@ -32,6 +44,12 @@ type TestInput struct {
 	Correct any
 }
 
+type TestInputWithArgs struct {
+	Input   any
+	Correct string
+	Args    []any
+}
+
 // Tests
 
 // Parse Struct
@@ -63,12 +81,15 @@ var PARSING_TO_TABLE_SAMPLES = []TestInput{
 			},
 		},
 		Correct: `CREATE TABLE IF NOT EXISTS Price (
-	id SERIAL PRIMARY KEY,
+	id SERIAL,
 	asset_id INTEGER NOT NULL,
 	price BIGINT NOT NULL,
 	timestamp TIMESTAMP DEFAULT NOW() NOT NULL,
-	FOREIGN KEY (asset_id) REFERENCES asset(id)
-);
+	PRIMARY KEY (id, timestamp),
+	FOREIGN KEY (asset_id) REFERENCES asset(id),
+	CONSTRAINT price_asset_id_timestamp_key UNIQUE (asset_id, timestamp)
+) PARTITION BY RANGE (timestamp);
+CREATE TABLE IF NOT EXISTS Price_default PARTITION OF Price DEFAULT;
 CREATE INDEX idx_price_asset_id ON Price(asset_id);`,
 	},
 	{
@@ -94,6 +115,18 @@ CREATE INDEX idx_price_asset_id ON Price(asset_id);`,
 CREATE INDEX idx_test_quantity ON TestParseStruct(quantity);
 CREATE INDEX idx_test_unit_price ON TestParseStruct(unit_price);`,
 	},
+	{
+		Input: TestUpsertStruct{},
+		Correct: `CREATE TABLE IF NOT EXISTS TestUpsertStruct (
+	id SERIAL PRIMARY KEY,
+	code VARCHAR(16) NOT NULL,
+	day TIMESTAMP DEFAULT NOW() NOT NULL,
+	amount BIGINT DEFAULT 0 NOT NULL,
+	note VARCHAR(64),
+	CONSTRAINT testupsertstruct_code_day_key UNIQUE (code, day),
+	CONSTRAINT testupsertstruct_note_key UNIQUE (note)
+);`,
+	},
 }
 
 func TestParseStructToTableFunc(t *testing.T) {
@@ -154,13 +187,9 @@ var CHECK_IF_TABLE_EXISTS = []TestCheckIfTableExistsInput{
 
 func TestCheckIfTableExistsFunc(t *testing.T) {
 	// Start Mock db
-	_, db, cleanup, err := InitMockSqlDB()
-	if err != nil {
-		utils.HandleFatalError(err)
-	}
-	defer cleanup()
+	db := dbtest.DB(t)
 
-	err = db.Ping()
+	err := db.Ping()
 	if err != nil {
 		t.Errorf("Failed to connect to the database: %v", err)
 		return
@@ -171,14 +200,8 @@ func TestCheckIfTableExistsFunc(t *testing.T) {
 	for _, input := range CHECK_IF_TABLE_EXISTS {
 		if input.Create {
 			// Create Table
-			query, err := ParseStructToTable(reflect.TypeOf(input.Input))
-			if err != nil {
-				t.Errorf("error on struct parsing: %v", err)
-			}
-
-			_, err = MakeQuery(db, query)
-			if err != nil {
-				t.Errorf("error when making the query: %v", err)
+			if _, err := CreateTable(db, input.Input); err != nil {
+				t.Errorf("error creating the table: %v", err)
 			}
 		}
 
@@ -197,14 +220,10 @@ func TestCheckIfTableExistsFunc(t *testing.T) {
 
 func TestCreateTableFunc(t *testing.T) {
 	// Start Mock db
-	_, db, cleanup, err := InitMockSqlDB()
-	if err != nil {
-		utils.HandleFatalError(err)
-	}
-	defer cleanup()
+	db := dbtest.DB(t)
 
 	// Check connection
-	err = db.Ping()
+	err := db.Ping()
 	if err != nil {
 		t.Errorf("Failed to connect to the database: %v", err)
 		return
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+// Selects the query rows into tables, columns are matched to fields by
+// their db name so partial selections are supported
+//
+// Parameters:
+//   - db:		the database struct
+//   - query:	the selection query
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - []T:		the selected tables
+//   - error:	error if occured
+func SelectInto[T types.Table](db Executor, query string, args ...any) ([]T, error) {
+	return SelectIntoContext[T](context.Background(), db, query, args...)
+}
+
+// Selects the query rows into tables, columns are matched to fields by
+// their db name so partial selections are supported.
+// The query runs inside a read only transaction, see WithReadOnlyTx, and
+// it is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - query:	the selection query
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - []T:		the selected tables
+//   - error:	error if occured
+func SelectIntoContext[T types.Table](ctx context.Context, db Executor, query string, args ...any) ([]T, error) {
+	var tables []T
+	err := MakeReadOnlyQueryWithResultContext(ctx, db, func(rows *sql.Rows) error {
+		var err error
+		tables, err = ScanRows[T](rows, nil)
+		return err
+	}, query, args...)
+	return tables, err
+}
+
+// Selects the first query row into a table
+//
+// Parameters:
+//   - db:		the database struct
+//   - query:	the selection query
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - T:		the selected table
+//   - error:	ErrNotFound, matching sql.ErrNoRows too, if no row has been selected, error if occured
+func SelectOne[T types.Table](db Executor, query string, args ...any) (T, error) {
+	return SelectOneContext[T](context.Background(), db, query, args...)
+}
+
+// Selects the first query row into a table
+// The query runs inside a read only transaction, see WithReadOnlyTx, and
+// it is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - query:	the selection query
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - T:		the selected table
+//   - error:	ErrNotFound, matching sql.ErrNoRows too, if no row has been selected, error if occured
+func SelectOneContext[T types.Table](ctx context.Context, db Executor, query string, args ...any) (T, error) {
+	var table T
+	err := WithReadOnlyTxContext(ctx, db, func(tx Executor) error {
+		var err error
+		table, err = selectOne[T](ctx, tx, query, args...)
+		return err
+	})
+	return table, err
+}
+
+// Selects the first query row into a table through db as is
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - query:	the selection query
+//   - args:	the values bound to the query placeholders
+//
+// Returns:
+//   - T:		the selected table
+//   - error:	ErrNotFound if no row has been selected, error if occured
+func selectOne[T types.Table](ctx context.Context, db Executor, query string, args ...any) (T, error) {
+	var table T
+
+	rows, err := MakeQueryWithResultContext(ctx, db, query, args...)
+	if err != nil {
+		return table, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return table, wrapError(err)
+		}
+		return table, wrapError(sql.ErrNoRows)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return table, err
+	}
+
+	if err := scanRowInto(rows, columns, &table); err != nil {
+		return table, err
+	}
+
+	return table, rows.Close()
+}
+
+// Scans and closes the rows, it accepts the results of the select
+// helpers directly:
+//
+//	prices, err := ScanRows[types.Price](SelectAll(db, types.Price{}))
+//
+// Parameters:
+//   - rows:	the rows to scan
+//   - err:		the selection error, returned as is
+//
+// Returns:
+//   - []T:		the scanned tables
+//   - error:	error if occured
+func ScanRows[T types.Table](rows *sql.Rows, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []T
+	for rows.Next() {
+		var table T
+		if err := scanRowInto(rows, columns, &table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return tables, rows.Close()
+}
+
+// Scans the row into the table and fills its unix timestamps
+//
+// Parameters:
+//   - row:		the row to scan
+//   - columns:	the row column names
+//   - table:	the table reference
+//
+// Returns:
+//   - error:	error if occured
+func scanRowInto[T types.Table](row ScannableRow, columns []string, table *T) error {
+	value := reflect.ValueOf(table).Elem()
+
+	if err := ScanColumnsToStruct(row, columns, value, false); err != nil {
+		return err
+	}
+
+	return utils.UpdateUnixTimestamps(value)
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+type ScanColumnsInput struct {
+	Columns []string
+	Row     TestRow
+	Correct types.Price
+	Err     bool
+}
+
+var SCAN_COLUMNS = []ScanColumnsInput{
+	{
+		Columns: []string{"id", "asset_id", "price", "timestamp"},
+		Row:     TestRow{Values: []any{int64(1), 2, 3, "2024-08-25T12:00:00Z"}},
+		Correct: types.Price{
+			Id:        types.Default[int64]{Value: 1},
+			Asset_id:  2,
+			Price:     3,
+			Timestamp: types.Timestamp{Datetime: "2024-08-25T12:00:00Z", Unix: 1724587200},
+		},
+	},
+	{
+		Columns: []string{"timestamp", "PRICE"},
+		Row:     TestRow{Values: []any{"2024-08-25 12:00:00", 3}},
+		Correct: types.Price{
+			Price:     3,
+			Timestamp: types.Timestamp{Datetime: "2024-08-25 12:00:00", Unix: 1724587200},
+		},
+	},
+	{
+		Columns: []string{"price", "volume"},
+		Row:     TestRow{Values: []any{3, 4}},
+		Correct: types.Price{Price: 3},
+	},
+}
+
+func TestScanRowIntoFunc(t *testing.T) {
+	for _, sc := range SCAN_COLUMNS {
+		var price types.Price
+		err := scanRowInto(sc.Row, sc.Columns, &price)
+		if (err != nil) != sc.Err {
+			t.Errorf("wrong error: %v", err)
+			continue
+		}
+		if sc.Err {
+			continue
+		}
+
+		if price != sc.Correct {
+			t.Errorf("incorrect scan:\ngiven %v\nwanted %v", price, sc.Correct)
+		}
+	}
+}
+
+// Typed selections
+func TestSelectIntoFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{}, types.Price{})
+
+	if _, err := InsertEntry(db, BULK_ASSET); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+	_, errs := InsertEntries(db, buildBulkPrices(1, 3))
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("error inserting the prices: %v", err)
+	}
+
+	// Full rows
+	prices, err := SelectInto[types.Price](db, "SELECT * FROM Price ORDER BY id")
+	if err != nil {
+		t.Fatalf("error selecting the prices: %v", err)
+	}
+	if len(prices) != 3 {
+		t.Fatalf("wrong number of prices: %d", len(prices))
+	}
+	for _, p := range prices {
+		if p.Id.Value == 0 || p.Timestamp.Unix == 0 {
+			t.Errorf("price not scanned: %v", p)
+		}
+	}
+
+	// Partial columns
+	partial, err := SelectInto[types.Price](db, "SELECT price, timestamp FROM Price WHERE price = $1", prices[1].Price)
+	if err != nil {
+		t.Fatalf("error selecting the prices: %v", err)
+	}
+	if len(partial) != 1 || partial[0].Id.Value != 0 || partial[0].Timestamp.Unix != prices[1].Timestamp.Unix {
+		t.Errorf("wrong partial selection: %v", partial)
+	}
+
+	// Select helpers results
+	all, err := ScanRows[types.Price](SelectAll(db, types.Price{}))
+	if err != nil || len(all) != 3 {
+		t.Errorf("wrong scanned selection: %v %v", all, err)
+	}
+
+	// Single row
+	one, err := SelectOne[types.Price](db, "SELECT * FROM Price WHERE id = $1", prices[0].Id.Value)
+	if err != nil || one != prices[0] {
+		t.Errorf("wrong single selection: %v %v", one, err)
+	}
+
+	if _, err := SelectOne[types.Price](db, "SELECT * FROM Price WHERE id = $1", -1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("wrong error: given %v, wanted %v", err, sql.ErrNoRows)
+	}
+}
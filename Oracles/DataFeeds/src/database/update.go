@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+var (
+	ErrNoColumnsToUpdate = errors.New("no columns to update")
+	ErrDefaultCondition  = errors.New("cannot match a column against DEFAULT")
+)
+
+// Updates the row matching the table primary key, every other column
+// is overwritten with the table values
+//
+// Parameters:
+//   - db:		the database struct
+//   - table:	the struct table holding the new values and the primary key
+//
+// Returns:
+//   - int64:	the number of updated rows
+//   - error:	if an error occured during the process
+func UpdateByPrimaryKey(db Executor, table types.Table) (int64, error) {
+	return UpdateByPrimaryKeyContext(context.Background(), db, table)
+}
+
+// Updates the row matching the table primary key, every other column
+// is overwritten with the table values. The update is aborted as soon
+// as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - table:	the struct table holding the new values and the primary key
+//
+// Returns:
+//   - int64:	the number of updated rows
+//   - error:	if an error occured during the process
+func UpdateByPrimaryKeyContext(ctx context.Context, db Executor, table types.Table) (int64, error) {
+	tt := reflect.TypeOf(table)
+	if !utils.ValidateStruct(tt) {
+		return 0, ErrNotValidTable
+	}
+
+	pk, err := table.GetPrimaryKeyNameDB()
+	if err != nil {
+		return 0, err
+	}
+
+	// Split primary key and columns
+	var columns []types.ColumnRef
+	var where []types.ColumnRef
+	for i := 0; i < tt.NumField(); i++ {
+		name, err := utils.GetFieldNameDB(tt.Field(i))
+		if err != nil {
+			return 0, err
+		}
+
+		if name == pk {
+			where = append(where, fieldColumn{table: tt, name: name})
+			continue
+		}
+		columns = append(columns, fieldColumn{table: tt, name: name})
+	}
+
+	if len(where) == 0 {
+		return 0, fmt.Errorf("primary key %s is not a column", pk)
+	}
+
+	return UpdateColumnsContext(ctx, db, table, columns, where)
+}
+
+// Updates the selected columns of the rows whose where columns are
+// equal to the table values, an empty where fails with ErrNoConditions
+//
+// Parameters:
+//   - db:		the database struct
+//   - table:	the struct table holding the new and the matched values
+//   - columns:	the columns to update
+//   - where:	the columns to match
+//
+// Returns:
+//   - int64:	the number of updated rows
+//   - error:	if an error occured during the process
+func UpdateColumns(db Executor, table any, columns []types.ColumnRef, where []types.ColumnRef) (int64, error) {
+	return UpdateColumnsContext(context.Background(), db, table, columns, where)
+}
+
+// Updates the selected columns of the rows whose where columns are
+// equal to the table values. The update is aborted as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the query
+//   - db:		the database struct
+//   - table:	the struct table holding the new and the matched values
+//   - columns:	the columns to update
+//   - where:	the columns to match
+//
+// Returns:
+//   - int64:	the number of updated rows
+//   - error:	if an error occured during the process
+func UpdateColumnsContext(ctx context.Context, db Executor, table any, columns []types.ColumnRef, where []types.ColumnRef) (int64, error) {
+	query, args, err := ParseStructToUpdateWithArgs(reflect.TypeOf(table), reflect.ValueOf(table), columns, where)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := MakeQueryContext(ctx, db, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Parses the struct into a parameterized update query, values are
+// referenced as $1..$n placeholders. Updated Default, Null and Timestamp
+// columns are set to DEFAULT, NULL and NOW() as on insertion, a matched
+// Null column is checked with IS NULL. Updating every row is refused with
+// ErrNoConditions
+//
+// Parameters:
+//   - data:	the struct type
+//   - value:	the struct value
+//   - columns:	the columns to update
+//   - where:	the columns to match, at least one
+//
+// Returns:
+//   - string:	the update query
+//   - []any:	the values bound to the query placeholders
+//   - error:	if any error occured during parsing
+func ParseStructToUpdateWithArgs(data reflect.Type, value reflect.Value, columns []types.ColumnRef, where []types.ColumnRef) (string, []any, error) {
+	if !utils.ValidateStruct(data) {
+		return "", nil, fmt.Errorf("cannot parse non-struct into update query: %v", data)
+	}
+	if len(columns) == 0 {
+		return "", nil, ErrNoColumnsToUpdate
+	}
+	if len(where) == 0 {
+		return "", nil, ErrNoConditions
+	}
+
+	var qa queryArgs
+	var builder strings.Builder
+	builder.WriteString("UPDATE ")
+	builder.WriteString(data.Name())
+	builder.WriteString("\nSET ")
+
+	// Add updated columns
+	for i, c := range columns {
+		index, name, err := resolveColumn(data, c)
+		if err != nil {
+			return "", nil, err
+		}
+		f := data.Field(index)
+		v := value.Field(index)
+
+		val := ""
+		if utils.ValidateStruct(f.Type) && !utils.ValidateValueStruct(f.Type) {
+			val, err = parseCustomStruct(f.Type, v, qa.parameter)
+			if err != nil {
+				return "", nil, err
+			}
+		} else {
+			val = qa.parameter(v)
+		}
+
+		builder.WriteString(name)
+		builder.WriteString(" = ")
+		builder.WriteString(val)
+
+		if i == len(columns)-1 {
+			continue
+		}
+		builder.WriteString(", ")
+	}
+
+	// Add matched columns
+	for i, c := range where {
+		index, _, err := resolveColumn(data, c)
+		if err != nil {
+			return "", nil, err
+		}
+
+		condition, err := parseFieldToCondition(data.Field(index), value.Field(index), qa.parameter)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if i == 0 {
+			builder.WriteString("\nWHERE ")
+		} else {
+			builder.WriteString(" AND ")
+		}
+		builder.WriteString(condition)
+	}
+
+	return builder.String(), qa.values, nil
+}
+
+// Parses the field into an equality condition on its current value
+//
+// Parameters:
+//   - f:		the struct field
+//   - v:		the field value
+//   - format:	the formatter of the value
+//
+// Returns:
+//   - string:	the condition
+//   - error:	if any error occured during parsing
+func parseFieldToCondition(f reflect.StructField, v reflect.Value, format valueFormatter) (string, error) {
+	name, err := utils.GetFieldNameDB(f)
+	if err != nil {
+		return "", err
+	}
+
+	if utils.ValidateDefaultStruct(f.Type) && v.FieldByName("Default").Bool() {
+		return "", ErrDefaultCondition
+	}
+	if utils.ValidateNullStruct(f.Type) && v.FieldByName("Null").Bool() {
+		return name + " IS NULL", nil
+	}
+
+	if utils.ValidateStruct(f.Type) && !utils.ValidateValueStruct(f.Type) {
+		val, err := parseCustomStruct(f.Type, v, format)
+		if err != nil {
+			return "", err
+		}
+		return name + " = " + val, nil
+	}
+
+	return name + " = " + format(v), nil
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Parse struct to update
+type ParseUpdateInput struct {
+	Input   any
+	Columns []types.ColumnRef
+	Where   []types.ColumnRef
+	Correct string
+	Args    []any
+	Err     error
+}
+
+var PARSING_TO_UPDATE = []ParseUpdateInput{
+	{
+		Input: types.Asset{
+			Id:       types.Default[uint64]{Default: false, Value: 1},
+			Ticker:   "BTC",
+			Source:   "binance",
+			Decimals: 8,
+		},
+		Columns: []types.ColumnRef{types.AssetTicker, types.AssetSource, types.AssetDecimals},
+		Where:   []types.ColumnRef{types.AssetId},
+		Correct: `UPDATE Asset
+SET ticker = $1, source = $2, decimals = $3
+WHERE id = $4`,
+		Args: []any{"BTC", "binance", int8(8), uint64(1)},
+	},
+	{
+		Input: TestUpsertStruct{
+			Id:     types.Default[int64]{Default: true},
+			Code:   "AAA",
+			Day:    types.Timestamp{Now: true},
+			Amount: types.Default[int64]{Default: true},
+			Note:   types.Null[string]{Null: true},
+		},
+		Columns: []types.ColumnRef{
+			types.Col[TestUpsertStruct]("day"),
+			types.Col[TestUpsertStruct]("amount"),
+			types.Col[TestUpsertStruct]("note"),
+		},
+		Where: []types.ColumnRef{
+			types.Col[TestUpsertStruct]("code"),
+			types.Col[TestUpsertStruct]("note"),
+		},
+		Correct: `UPDATE TestUpsertStruct
+SET day = NOW(), amount = DEFAULT, note = NULL
+WHERE code = $1 AND note IS NULL`,
+		Args: []any{"AAA"},
+	},
+	{
+		Input: types.Price{
+			Id:        types.Default[int64]{Default: true},
+			Asset_id:  1,
+			Price:     10,
+			Timestamp: types.Timestamp{Now: false, Unix: 100},
+		},
+		Columns: []types.ColumnRef{types.PricePrice},
+		Where:   []types.ColumnRef{types.PriceAssetId, types.PriceTimestamp},
+		Correct: `UPDATE Price
+SET price = $1
+WHERE asset_id = $2 AND timestamp = TO_TIMESTAMP($3)`,
+		Args: []any{10, 1, 100},
+	},
+	{
+		Input:   types.Asset{Id: types.Default[uint64]{Default: true}},
+		Columns: []types.ColumnRef{types.AssetTicker},
+		Where:   []types.ColumnRef{types.AssetId},
+		Err:     ErrDefaultCondition,
+	},
+	{
+		Input: types.Asset{},
+		Where: []types.ColumnRef{types.AssetId},
+		Err:   ErrNoColumnsToUpdate,
+	},
+	{
+		Input:   types.Asset{},
+		Columns: []types.ColumnRef{types.AssetTicker},
+		Err:     ErrNoConditions,
+	},
+	{
+		Input:   types.Asset{},
+		Columns: []types.ColumnRef{types.Col[types.Asset]("volume")},
+		Where:   []types.ColumnRef{types.AssetId},
+		Err:     ErrUnknownColumn,
+	},
+	{
+		Input:   types.Asset{},
+		Columns: []types.ColumnRef{types.PricePrice},
+		Where:   []types.ColumnRef{types.AssetId},
+		Err:     ErrColumnTable,
+	},
+}
+
+func TestParseStructToUpdateWithArgsFunc(t *testing.T) {
+	for _, pu := range PARSING_TO_UPDATE {
+		query, args, err := ParseStructToUpdateWithArgs(reflect.TypeOf(pu.Input), reflect.ValueOf(pu.Input), pu.Columns, pu.Where)
+		if !errors.Is(err, pu.Err) {
+			t.Errorf("wrong error: given %v, wanted %v", err, pu.Err)
+			continue
+		}
+
+		if query != pu.Correct {
+			t.Errorf("incorrect parsing:\n%v\n%v", query, pu.Correct)
+		}
+
+		if !reflect.DeepEqual(args, pu.Args) {
+			t.Errorf("incorrect arguments:\ngiven %v\nwanted %v", args, pu.Args)
+		}
+	}
+}
+
+// Update rows
+func TestUpdateFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, types.Asset{})
+
+	asset := types.Asset{
+		Id:       types.Default[uint64]{Default: true},
+		Ticker:   "BTC",
+		Source:   "binance",
+		Decimals: 8,
+	}
+	if _, err := InsertEntry(db, asset); err != nil {
+		t.Fatalf("error inserting the row: %v", err)
+	}
+
+	// Full update by primary key
+	asset.Id = types.Default[uint64]{Default: false, Value: 1}
+	asset.Source = "coinbase"
+	affected, err := UpdateByPrimaryKey(db, asset)
+	if err != nil {
+		t.Fatalf("error updating the row: %v", err)
+	}
+	if affected != 1 {
+		t.Errorf("update affected %d rows", affected)
+	}
+
+	var source string
+	var decimals int
+	if err := db.QueryRow("SELECT source, decimals FROM Asset WHERE id = 1").Scan(&source, &decimals); err != nil {
+		t.Fatalf("error selecting the row: %v", err)
+	}
+	if source != "coinbase" || decimals != 8 {
+		t.Errorf("wrong updated row: source %s, decimals %d", source, decimals)
+	}
+
+	// Partial update matched by ticker
+	partial := types.Asset{Ticker: "BTC", Decimals: 18}
+	affected, err = UpdateColumns(db, partial, []types.ColumnRef{types.AssetDecimals}, []types.ColumnRef{types.AssetTicker})
+	if err != nil {
+		t.Fatalf("error updating the row: %v", err)
+	}
+	if affected != 1 {
+		t.Errorf("update affected %d rows", affected)
+	}
+
+	if err := db.QueryRow("SELECT source, decimals FROM Asset WHERE id = 1").Scan(&source, &decimals); err != nil {
+		t.Fatalf("error selecting the row: %v", err)
+	}
+	if source != "coinbase" || decimals != 18 {
+		t.Errorf("wrong updated row: source %s, decimals %d", source, decimals)
+	}
+
+	// No matching row
+	asset.Id.Value = 2
+	if affected, err := UpdateByPrimaryKey(db, asset); err != nil || affected != 0 {
+		t.Errorf("missing row update: affected %d, error %v", affected, err)
+	}
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+var (
+	ErrNoUniqueConstraint = errors.New("table has no unique constraint")
+)
+
+// Policy applied when an upserted row conflicts with an existing one
+type ConflictPolicy int
+
+const (
+	// Keep the existing row
+	ConflictDoNothing ConflictPolicy = iota
+	// Overwrite every column of the existing row but the primary key
+	// and the conflicting columns
+	ConflictDoUpdate
+)
+
+// Takes multiple rows to upsert into the table, it upserts them
+// respecting the order
+//
+// Parameters:
+//   - db:		the database struct
+//   - data:	the structs rows
+//   - policy:	the policy applied on conflicting rows
+//
+// Returns:
+//   - []sql.Result:	an array of results of the same length as entries
+//   - []error:			an array of errors of the queries
+func UpsertEntries[T any](db Executor, data []T, policy ConflictPolicy) ([]sql.Result, []error) {
+	return UpsertEntriesContext(context.Background(), db, data, policy)
+}
+
+// Takes multiple rows to upsert into the table, it upserts them
+// respecting the order until ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - data:	the structs rows
+//   - policy:	the policy applied on conflicting rows
+//
+// Returns:
+//   - []sql.Result:	an array of results of the same length as entries
+//   - []error:			an array of errors of the queries
+func UpsertEntriesContext[T any](ctx context.Context, db Executor, data []T, policy ConflictPolicy) ([]sql.Result, []error) {
+	var results []sql.Result
+	var errors []error
+
+	for _, d := range data {
+		result, err := UpsertEntryContext(ctx, db, d, policy)
+
+		results = append(results, result)
+		errors = append(errors, err)
+	}
+
+	return results, errors
+}
+
+// Inserts the row unless it conflicts with the first unique constraint
+// of its table, in that case policy is applied
+//
+// Parameters:
+//   - db:		the database struct
+//   - data:	the struct row
+//   - policy:	the policy applied on a conflicting row
+//
+// Returns:
+//   - sql.Result:	the upsert result, no rows are affected by a skipped conflict
+//   - error:		if an error occured during the process
+func UpsertEntry(db Executor, data any, policy ConflictPolicy) (sql.Result, error) {
+	return UpsertEntryContext(context.Background(), db, data, policy)
+}
+
+// Inserts the row unless it conflicts with the first unique constraint
+// of its table, in that case policy is applied. The upsert is aborted
+// as soon as ctx is done
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - db:		the database struct
+//   - data:	the struct row
+//   - policy:	the policy applied on a conflicting row
+//
+// Returns:
+//   - sql.Result:	the upsert result, no rows are affected by a skipped conflict
+//   - error:		if an error occured during the process
+func UpsertEntryContext(ctx context.Context, db Executor, data any, policy ConflictPolicy) (sql.Result, error) {
+	// Check if it is a struct
+	ty := reflect.TypeOf(data)
+	if !utils.ValidateStruct(ty) {
+		return nil, invalidData(data)
+	}
+
+	// Build SQL query
+	query, args, err := ParseStructToUpsertWithArgs(ty, reflect.ValueOf(data), policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make Query
+	return MakeQueryContext(ctx, db, query, args...)
+}
+
+// Parses the struct into a parameterized upsert query with its current
+// parameters, values are referenced as $1..$n placeholders. Updated
+// columns take the inserted values, so Default, Null and Timestamp
+// columns are set to DEFAULT, NULL and NOW() as on insertion
+//
+// Parameters:
+//   - data:	the struct to be upserted in the database
+//   - value:	the struct value
+//   - policy:	the policy applied on a conflicting row
+//
+// Returns:
+//   - string:	the upsert query
+//   - []any:	the values bound to the query placeholders
+//   - error:	if any error occured during parsing
+func ParseStructToUpsertWithArgs(data reflect.Type, value reflect.Value, policy ConflictPolicy) (string, []any, error) {
+	query, args, err := ParseStructToEntryWithArgs(data, value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	conflict, err := parseStructToConflict(data, policy)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return query + "\n" + conflict, args, nil
+}
+
+// Parses the struct into the ON CONFLICT clause of the upsert query
+//
+// Parameters:
+//   - data:	the struct type
+//   - policy:	the policy applied on a conflicting row
+//
+// Returns:
+//   - string:	the conflict clause
+//   - error:	if any error occured during parsing
+func parseStructToConflict(data reflect.Type, policy ConflictPolicy) (string, error) {
+	uniques, err := getUniqueConstraints(data)
+	if err != nil {
+		return "", err
+	}
+	if len(uniques) == 0 {
+		return "", ErrNoUniqueConstraint
+	}
+	target := uniques[0].Columns
+
+	var builder strings.Builder
+	builder.WriteString("ON CONFLICT (")
+	builder.WriteString(strings.Join(target, ", "))
+	builder.WriteString(") ")
+
+	// Columns to overwrite
+	var updates []string
+	if policy == ConflictDoUpdate {
+		for i := 0; i < data.NumField(); i++ {
+			f := data.Field(i)
+
+			name, err := utils.GetFieldNameDB(f)
+			if err != nil {
+				return "", err
+			}
+
+			if isPrimaryKeyField(f) || slices.Contains(target, name) {
+				continue
+			}
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", name, name))
+		}
+	} else if policy != ConflictDoNothing {
+		return "", fmt.Errorf("unknown conflict policy: %d", policy)
+	}
+
+	if len(updates) == 0 {
+		builder.WriteString("DO NOTHING")
+		return builder.String(), nil
+	}
+
+	builder.WriteString("DO UPDATE SET ")
+	builder.WriteString(strings.Join(updates, ", "))
+	return builder.String(), nil
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Parse struct to upsert
+type ParseUpsertInput struct {
+	Input   any
+	Policy  ConflictPolicy
+	Correct string
+	Args    []any
+	Err     error
+}
+
+var PARSING_TO_UPSERT = []ParseUpsertInput{
+	{
+		Input: TestUpsertStruct{
+			Id:     types.Default[int64]{Default: true},
+			Code:   "AAA",
+			Day:    types.Timestamp{Now: false, Unix: 1724440501},
+			Amount: types.Default[int64]{Default: false, Value: 10},
+			Note:   types.Null[string]{Null: true},
+		},
+		Policy: ConflictDoUpdate,
+		Correct: `INSERT INTO TestUpsertStruct (id, code, day, amount, note)
+VALUES (DEFAULT, $1, TO_TIMESTAMP($2), $3, NULL)
+ON CONFLICT (code, day) DO UPDATE SET amount = EXCLUDED.amount, note = EXCLUDED.note`,
+		Args: []any{"AAA", 1724440501, int64(10)},
+	},
+	{
+		Input: TestUpsertStruct{
+			Id:     types.Default[int64]{Default: true},
+			Code:   "AAA",
+			Day:    types.Timestamp{Now: true},
+			Amount: types.Default[int64]{Default: true},
+			Note:   types.Null[string]{Null: false, Value: "note"},
+		},
+		Policy: ConflictDoNothing,
+		Correct: `INSERT INTO TestUpsertStruct (id, code, day, amount, note)
+VALUES (DEFAULT, $1, NOW(), DEFAULT, $2)
+ON CONFLICT (code, day) DO NOTHING`,
+		Args: []any{"AAA", "note"},
+	},
+	{
+		Input: types.Price{
+			Id:        types.Default[int64]{Default: true},
+			Asset_id:  1,
+			Price:     10,
+			Timestamp: types.Timestamp{Now: false, Unix: 100},
+		},
+		Policy: ConflictDoUpdate,
+		Correct: `INSERT INTO Price (id, asset_id, price, timestamp)
+VALUES (DEFAULT, $1, $2, TO_TIMESTAMP($3))
+ON CONFLICT (asset_id, timestamp) DO UPDATE SET price = EXCLUDED.price`,
+		Args: []any{1, 10, 100},
+	},
+	{
+		Input:  types.Asset{},
+		Policy: ConflictDoUpdate,
+		Err:    ErrNoUniqueConstraint,
+	},
+}
+
+func TestParseStructToUpsertWithArgsFunc(t *testing.T) {
+	for _, pu := range PARSING_TO_UPSERT {
+		query, args, err := ParseStructToUpsertWithArgs(reflect.TypeOf(pu.Input), reflect.ValueOf(pu.Input), pu.Policy)
+		if !errors.Is(err, pu.Err) {
+			t.Errorf("wrong error: given %v, wanted %v", err, pu.Err)
+			continue
+		}
+
+		if query != pu.Correct {
+			t.Errorf("incorrect parsing:\n%v\n%v", query, pu.Correct)
+		}
+
+		if !reflect.DeepEqual(args, pu.Args) {
+			t.Errorf("incorrect arguments:\ngiven %v\nwanted %v", args, pu.Args)
+		}
+	}
+}
+
+// Upsert rows
+func TestUpsertEntryFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	ensureSchema(t, db, TestUpsertStruct{})
+
+	row := TestUpsertStruct{
+		Id:     types.Default[int64]{Default: true},
+		Code:   "AAA",
+		Day:    types.Timestamp{Now: false, Unix: 1724440501},
+		Amount: types.Default[int64]{Default: false, Value: 10},
+		Note:   types.Null[string]{Null: false, Value: "first"},
+	}
+
+	// Insertion
+	if _, err := UpsertEntry(db, row, ConflictDoUpdate); err != nil {
+		t.Fatalf("error upserting the row: %v", err)
+	}
+
+	// Conflict skipped
+	skipped := row
+	skipped.Amount.Value = 20
+	result, err := UpsertEntry(db, skipped, ConflictDoNothing)
+	if err != nil {
+		t.Fatalf("error upserting the row: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 0 {
+		t.Errorf("skipped conflict affected %d rows", affected)
+	}
+
+	// Conflict updated with Default and Null values
+	updated := row
+	updated.Amount = types.Default[int64]{Default: true}
+	updated.Note = types.Null[string]{Null: true}
+	result, err = UpsertEntry(db, updated, ConflictDoUpdate)
+	if err != nil {
+		t.Fatalf("error upserting the row: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 1 {
+		t.Errorf("updated conflict affected %d rows", affected)
+	}
+
+	var count int
+	var amount int64
+	var note *string
+	err = db.QueryRow("SELECT COUNT(*) OVER (), amount, note FROM TestUpsertStruct").Scan(&count, &amount, &note)
+	if err != nil {
+		t.Fatalf("error selecting the row: %v", err)
+	}
+	if count != 1 || amount != 0 || note != nil {
+		t.Errorf("wrong upserted row: count %d, amount %d, note %v", count, amount, note)
+	}
+
+	// A different timestamp is a new row
+	other := row
+	other.Day = types.Timestamp{Now: true}
+	_, errs := UpsertEntries(db, []TestUpsertStruct{other}, ConflictDoUpdate)
+	if err := errors.Join(errs...); err != nil {
+		t.Errorf("error upserting the rows: %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM TestUpsertStruct").Scan(&count); err != nil || count != 2 {
+		t.Errorf("wrong number of rows: %d %v", count, err)
+	}
+}
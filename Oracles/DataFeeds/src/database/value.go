@@ -0,0 +1,387 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUnsupportedType = errors.New("go type has no column mapping")
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	bigIntType     = reflect.TypeOf((*big.Int)(nil))
+	decimalType    = reflect.TypeOf(types.Decimal{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	bytesType      = reflect.TypeOf([]byte(nil))
+	valuerType     = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType    = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// Returns the column type of a Go type, for db tags holding only the
+// column name:
+//
+//	string				TEXT
+//	bool				BOOLEAN
+//	int8, int16, uint8		SMALLINT
+//	int32, uint16			INTEGER
+//	int, int64, uint32, uint, uint64	BIGINT
+//	float32, float64		REAL, DOUBLE PRECISION
+//	time.Time			TIMESTAMPTZ
+//	[]byte				BYTEA
+//	*big.Int, types.Decimal		NUMERIC
+//	json.RawMessage, maps		JSONB
+//	slices				arrays of their element type
+//
+// Pointers map to their element type and are nullable as types.Null, e.g.
+// *int64 is a nullable BIGINT
+//
+// Parameters:
+//   - t:	the Go type
+//
+// Returns:
+//   - string:	the column type
+//   - bool:	whether the column is nullable
+//   - error:	ErrUnsupportedType if the type has no mapping
+func sqlTypeOf(t reflect.Type) (string, bool, error) {
+	switch {
+	case t == timeType:
+		return "TIMESTAMPTZ", false, nil
+	case t == bigIntType:
+		return "NUMERIC", true, nil
+	case t == decimalType:
+		return "NUMERIC", false, nil
+	case t == rawMessageType:
+		return "JSONB", true, nil
+	case isBytes(t):
+		return "BYTEA", true, nil
+	case utils.ValidateNullStruct(t):
+		value, _ := t.FieldByName("Value")
+		sqlType, _, err := sqlTypeOf(value.Type)
+		return sqlType, true, err
+	case utils.ValidateTimestampStruct(t):
+		return "TIMESTAMP", false, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if t.Elem().Kind() == reflect.Pointer {
+			break
+		}
+		sqlType, _, err := sqlTypeOf(t.Elem())
+		return sqlType, true, err
+	case reflect.Map:
+		return "JSONB", true, nil
+	case reflect.Slice:
+		sqlType, _, err := sqlTypeOf(t.Elem())
+		if err != nil || strings.HasSuffix(sqlType, "[]") {
+			break
+		}
+		return sqlType + "[]", true, nil
+	case reflect.String:
+		return "TEXT", false, nil
+	case reflect.Bool:
+		return "BOOLEAN", false, nil
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT", false, nil
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER", false, nil
+	case reflect.Int, reflect.Int64, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		return "BIGINT", false, nil
+	case reflect.Float32:
+		return "REAL", false, nil
+	case reflect.Float64:
+		return "DOUBLE PRECISION", false, nil
+	}
+
+	return "", false, fmt.Errorf("%w: %v", ErrUnsupportedType, t)
+}
+
+// Returns the column definition of a field, its db tag if it holds a type
+// or the name followed by the type of sqlTypeOf, NOT NULL unless nullable
+//
+// Parameters:
+//   - f:	the struct field
+//
+// Returns:
+//   - string:	the column definition
+//   - error:	if the tag is missing or the type has no mapping
+func columnDefinition(f reflect.StructField) (string, error) {
+	tag, ok := f.Tag.Lookup("db")
+	if !ok {
+		return "", fmt.Errorf("struct field doesn't have a db tag")
+	}
+	if len(strings.Fields(tag)) != 1 {
+		return tag, nil
+	}
+
+	// Defaults need their expression in the tag
+	if utils.ValidateDefaultStruct(f.Type) {
+		return "", fmt.Errorf("db tag has no type: %s", tag)
+	}
+
+	sqlType, nullable, err := sqlTypeOf(f.Type)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", tag, err)
+	}
+	if nullable {
+		return tag + " " + sqlType, nil
+	}
+	return tag + " " + sqlType + " NOT NULL", nil
+}
+
+// Returns the value bound to a query placeholder. Nil pointers, maps and
+// slices are NULL, pointers are dereferenced, *big.Int is sent as text,
+// maps and json.RawMessage as JSON and slices as postgres arrays
+//
+// Parameters:
+//   - value:	the field value
+//
+// Returns:
+//   - any:	the driver value
+func driverValue(value reflect.Value) any {
+	if !value.IsValid() {
+		return nil
+	}
+	if value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	t := value.Type()
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if value.IsNil() {
+			return nil
+		}
+	}
+
+	switch {
+	case t.Implements(valuerType) || t == timeType:
+		return value.Interface()
+	case t == bigIntType:
+		return value.Interface().(*big.Int).String()
+	case t == rawMessageType:
+		return string(value.Bytes())
+	case isBytes(t):
+		return value.Bytes()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return driverValue(value.Elem())
+	case reflect.Map:
+		return jsonValue{value.Interface()}
+	case reflect.Slice:
+		return pq.Array(value.Interface())
+	}
+
+	return value.Interface()
+}
+
+// Returns the literal of a value of a mapped type, see driverValue
+//
+// Parameters:
+//   - value:	the field value
+//
+// Returns:
+//   - string:	the SQL literal
+func literalValue(value reflect.Value) string {
+	v := driverValue(value)
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return "NULL"
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quoteLiteral(v)
+	case []byte:
+		return `'\x` + hex.EncodeToString(v) + `'`
+	case time.Time:
+		return quoteLiteral(v.Format(time.RFC3339Nano))
+	default:
+		return ParseValueToEntry(reflect.ValueOf(v))
+	}
+}
+
+// Reports whether the value needs driverValue to be sent, basic kinds and
+// the custom structs are sent as they are
+func isMappedValue(value reflect.Value) bool {
+	if !value.IsValid() {
+		return false
+	}
+
+	t := value.Type()
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		return true
+	case reflect.Struct:
+		return utils.ValidateValueStruct(t)
+	}
+	return false
+}
+
+// Quotes a string literal, escaping its quotes
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Reports whether the type is a byte slice, named or not
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// JSON value of a map, encoded when the query is sent
+type jsonValue struct {
+	value any
+}
+
+func (j jsonValue) Value() (driver.Value, error) {
+	encoded, err := json.Marshal(j.value)
+	return string(encoded), err
+}
+
+// Returns the scan destination of a field whose type has no native
+// database/sql support, nil if the field address can be scanned as is
+//
+// Parameters:
+//   - tf:	the addressable field
+//
+// Returns:
+//   - sql.Scanner:	the scanner writing into the field
+func valueScanner(tf reflect.Value) sql.Scanner {
+	t := tf.Type()
+	switch {
+	case reflect.PointerTo(t).Implements(scannerType), isBytes(t) && t != rawMessageType:
+		return nil
+	case t == bigIntType:
+		return bigIntScanner{tf}
+	case t == rawMessageType, t.Kind() == reflect.Map:
+		return jsonScanner{tf}
+	case t.Kind() == reflect.Slice:
+		return arrayScanner{tf}
+	}
+	return nil
+}
+
+// Scans a NUMERIC into a *big.Int field
+type bigIntScanner struct {
+	field reflect.Value
+}
+
+func (s bigIntScanner) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		s.field.SetZero()
+		return nil
+	case int64:
+		s.field.Set(reflect.ValueOf(big.NewInt(v)))
+		return nil
+	case []byte:
+		src = string(v)
+	}
+
+	text, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a big.Int", src)
+	}
+	n, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return fmt.Errorf("cannot scan %q into a big.Int", text)
+	}
+	s.field.Set(reflect.ValueOf(n))
+	return nil
+}
+
+// Scans a JSON or JSONB column into a json.RawMessage or map field
+type jsonScanner struct {
+	field reflect.Value
+}
+
+func (s jsonScanner) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		s.field.SetZero()
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into %v", src, s.field.Type())
+	}
+
+	if s.field.Type() == rawMessageType {
+		// The driver reuses its buffer
+		s.field.SetBytes(append([]byte(nil), data...))
+		return nil
+	}
+
+	s.field.SetZero()
+	return json.Unmarshal(data, s.field.Addr().Interface())
+}
+
+// Scans a postgres array into a slice field
+type arrayScanner struct {
+	field reflect.Value
+}
+
+func (s arrayScanner) Scan(src any) error {
+	if src == nil {
+		s.field.SetZero()
+		return nil
+	}
+
+	elem := s.field.Type().Elem()
+	var scanned sql.Scanner
+	switch {
+	case reflect.PointerTo(elem).Implements(scannerType):
+		return pq.GenericArray{A: s.field.Addr().Interface()}.Scan(src)
+	case isBytes(elem):
+		scanned = &pq.ByteaArray{}
+	case elem.Kind() == reflect.String:
+		scanned = &pq.StringArray{}
+	case elem.Kind() == reflect.Bool:
+		scanned = &pq.BoolArray{}
+	case elem.Kind() == reflect.Float32, elem.Kind() == reflect.Float64:
+		scanned = &pq.Float64Array{}
+	case elem.Kind() >= reflect.Int && elem.Kind() <= reflect.Uint64:
+		scanned = &pq.Int64Array{}
+	default:
+		return fmt.Errorf("%w: %v", ErrUnsupportedType, s.field.Type())
+	}
+
+	if err := scanned.Scan(src); err != nil {
+		return err
+	}
+
+	// Convert the elements to the field ones
+	values := reflect.ValueOf(scanned).Elem()
+	slice := reflect.MakeSlice(s.field.Type(), values.Len(), values.Len())
+	for i := 0; i < values.Len(); i++ {
+		slice.Index(i).Set(values.Index(i).Convert(elem))
+	}
+	s.field.Set(slice)
+	return nil
+}
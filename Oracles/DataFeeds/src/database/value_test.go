@@ -0,0 +1,278 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Types
+type TestValueStruct struct {
+	Id        types.Default[int64] `json:"id" db:"id SERIAL PRIMARY KEY"`
+	CreatedAt time.Time            `json:"created_at" db:"created_at"`
+	Quantity  *int64               `json:"quantity" db:"quantity"`
+	Payload   []byte               `json:"payload" db:"payload"`
+	Supply    *big.Int             `json:"supply" db:"supply"`
+	Amount    types.Decimal        `json:"amount" db:"amount"`
+	Meta      json.RawMessage      `json:"meta" db:"meta"`
+	Labels    map[string]any       `json:"labels" db:"labels"`
+	Ticks     []int64              `json:"ticks" db:"ticks"`
+	Tags      []string             `json:"tags" db:"tags"`
+}
+
+func (t TestValueStruct) GetPrimaryKeyNameDB() (string, error) {
+	return "id", nil
+}
+
+// Tests
+
+var (
+	TEST_VALUE_TIME     = time.Date(2024, 8, 23, 19, 15, 1, 500000000, time.UTC)
+	TEST_VALUE_QUANTITY = int64(42)
+)
+
+// Go types to column types
+var SQL_TYPE_SAMPLES = []TestInput{
+	{Input: reflect.TypeOf(""), Correct: "TEXT NOT NULL"},
+	{Input: reflect.TypeOf(true), Correct: "BOOLEAN NOT NULL"},
+	{Input: reflect.TypeOf(int16(0)), Correct: "SMALLINT NOT NULL"},
+	{Input: reflect.TypeOf(int32(0)), Correct: "INTEGER NOT NULL"},
+	{Input: reflect.TypeOf(int64(0)), Correct: "BIGINT NOT NULL"},
+	{Input: reflect.TypeOf(float64(0)), Correct: "DOUBLE PRECISION NOT NULL"},
+	{Input: reflect.TypeOf(time.Time{}), Correct: "TIMESTAMPTZ NOT NULL"},
+	{Input: reflect.TypeOf((*time.Time)(nil)), Correct: "TIMESTAMPTZ"},
+	{Input: reflect.TypeOf((*int64)(nil)), Correct: "BIGINT"},
+	{Input: reflect.TypeOf([]byte(nil)), Correct: "BYTEA"},
+	{Input: reflect.TypeOf((*big.Int)(nil)), Correct: "NUMERIC"},
+	{Input: reflect.TypeOf(types.Decimal{}), Correct: "NUMERIC NOT NULL"},
+	{Input: reflect.TypeOf((*types.Decimal)(nil)), Correct: "NUMERIC"},
+	{Input: reflect.TypeOf(json.RawMessage(nil)), Correct: "JSONB"},
+	{Input: reflect.TypeOf(map[string]any(nil)), Correct: "JSONB"},
+	{Input: reflect.TypeOf([]int64(nil)), Correct: "BIGINT[]"},
+	{Input: reflect.TypeOf([]string(nil)), Correct: "TEXT[]"},
+	{Input: reflect.TypeOf([][]byte(nil)), Correct: "BYTEA[]"},
+	{Input: reflect.TypeOf(types.Null[string]{}), Correct: "TEXT"},
+	{Input: reflect.TypeOf(types.Timestamp{}), Correct: "TIMESTAMP NOT NULL"},
+}
+
+func TestColumnDefinitionFunc(t *testing.T) {
+	for _, input := range SQL_TYPE_SAMPLES {
+		f := reflect.StructField{Name: "Column", Type: input.Input.(reflect.Type), Tag: `db:"column"`}
+
+		definition, err := columnDefinition(f)
+		if err != nil {
+			t.Errorf("error on %v: %v", f.Type, err)
+			continue
+		}
+
+		if definition != "column "+input.Correct.(string) {
+			t.Errorf("wrong definition of %v:\ngiven %v\nwanted column %v", f.Type, definition, input.Correct)
+		}
+	}
+
+	// Typed tags are kept as they are
+	f := reflect.StructField{Name: "Column", Type: reflect.TypeOf(""), Tag: `db:"column VARCHAR(16) NOT NULL"`}
+	if definition, err := columnDefinition(f); err != nil || definition != "column VARCHAR(16) NOT NULL" {
+		t.Errorf("typed tag not kept: %v %v", definition, err)
+	}
+
+	// Unmapped types and untyped defaults are rejected
+	for _, tt := range []reflect.Type{reflect.TypeOf(struct{}{}), reflect.TypeOf((**int64)(nil)), reflect.TypeOf([][]int64(nil)), reflect.TypeOf(types.Default[int64]{})} {
+		f := reflect.StructField{Name: "Column", Type: tt, Tag: `db:"column"`}
+		if _, err := columnDefinition(f); err == nil {
+			t.Errorf("no error on %v", tt)
+		}
+	}
+}
+
+func TestParseStructToTableInferredFunc(t *testing.T) {
+	correct := `CREATE TABLE IF NOT EXISTS TestValueStruct (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ NOT NULL,
+	quantity BIGINT,
+	payload BYTEA,
+	supply NUMERIC,
+	amount NUMERIC NOT NULL,
+	meta JSONB,
+	labels JSONB,
+	ticks BIGINT[],
+	tags TEXT[]
+);`
+
+	str, err := ParseStructToTable(reflect.TypeOf(TestValueStruct{}))
+	if err != nil {
+		t.Fatalf("error during parsing: %v", err)
+	}
+
+	if str != correct {
+		t.Errorf("incorrect parsing:\n%v\n%v", str, correct)
+	}
+}
+
+// Values to SQL literals
+var LITERAL_VALUE_SAMPLES = []TestInput{
+	{Input: TEST_VALUE_TIME, Correct: `'2024-08-23T19:15:01.5Z'`},
+	{Input: &TEST_VALUE_QUANTITY, Correct: `42`},
+	{Input: (*int64)(nil), Correct: `NULL`},
+	{Input: []byte{0xde, 0xad}, Correct: `'\xdead'`},
+	{Input: []byte(nil), Correct: `NULL`},
+	{Input: big.NewInt(-12), Correct: `'-12'`},
+	{Input: types.Decimal{Unscaled: big.NewInt(1250), Scale: 2}, Correct: `'12.50'`},
+	{Input: json.RawMessage(`{"it's":1}`), Correct: `'{"it''s":1}'`},
+	{Input: map[string]any{"a": 1}, Correct: `'{"a":1}'`},
+	{Input: []int64{1, 2}, Correct: `'{1,2}'`},
+	{Input: []string{"a", "b c"}, Correct: `'{"a","b c"}'`},
+}
+
+func TestLiteralValueFunc(t *testing.T) {
+	for _, input := range LITERAL_VALUE_SAMPLES {
+		literal := ParseValueToEntry(reflect.ValueOf(input.Input))
+		if literal != input.Correct {
+			t.Errorf("wrong literal of %T:\ngiven %v\nwanted %v", input.Input, literal, input.Correct)
+		}
+	}
+}
+
+// Values to query arguments
+var DRIVER_VALUE_SAMPLES = []TestInput{
+	{Input: TEST_VALUE_TIME, Correct: TEST_VALUE_TIME},
+	{Input: &TEST_VALUE_QUANTITY, Correct: int64(42)},
+	{Input: (*int64)(nil), Correct: nil},
+	{Input: []byte{0xde, 0xad}, Correct: []byte{0xde, 0xad}},
+	{Input: big.NewInt(-12), Correct: "-12"},
+	{Input: types.Decimal{Unscaled: big.NewInt(-5), Scale: 3}, Correct: "-0.005"},
+	{Input: json.RawMessage(`{"a":1}`), Correct: `{"a":1}`},
+	{Input: map[string]any{"a": 1}, Correct: `{"a":1}`},
+	{Input: map[string]any(nil), Correct: nil},
+	{Input: []int64{1, 2}, Correct: "{1,2}"},
+	{Input: []bool{true, false}, Correct: "{t,f}"},
+}
+
+func TestDriverValueFunc(t *testing.T) {
+	for _, input := range DRIVER_VALUE_SAMPLES {
+		var qa queryArgs
+		qa.parameter(reflect.ValueOf(input.Input))
+
+		value := qa.values[0]
+		if valuer, ok := value.(driver.Valuer); ok {
+			var err error
+			if value, err = valuer.Value(); err != nil {
+				t.Errorf("error on %T: %v", input.Input, err)
+				continue
+			}
+		}
+		if b, ok := value.([]byte); ok && reflect.TypeOf(input.Input) != bytesType {
+			value = string(b)
+		}
+
+		if !reflect.DeepEqual(value, input.Correct) {
+			t.Errorf("wrong argument of %T:\ngiven %#v\nwanted %#v", input.Input, value, input.Correct)
+		}
+	}
+}
+
+// Column values to fields
+var VALUE_SCANNER_SAMPLES = []struct {
+	Field   string
+	Src     any
+	Correct any
+}{
+	{Field: "Supply", Src: []byte("123456789012345678901234567890"), Correct: func() *big.Int { n, _ := new(big.Int).SetString("123456789012345678901234567890", 10); return n }()},
+	{Field: "Supply", Src: int64(7), Correct: big.NewInt(7)},
+	{Field: "Supply", Src: nil, Correct: (*big.Int)(nil)},
+	{Field: "Meta", Src: []byte(`{"a": 1}`), Correct: json.RawMessage(`{"a": 1}`)},
+	{Field: "Meta", Src: nil, Correct: json.RawMessage(nil)},
+	{Field: "Labels", Src: []byte(`{"a": "b"}`), Correct: map[string]any{"a": "b"}},
+	{Field: "Ticks", Src: []byte(`{1,-2,3}`), Correct: []int64{1, -2, 3}},
+	{Field: "Ticks", Src: nil, Correct: []int64(nil)},
+	{Field: "Tags", Src: []byte(`{a,"b c"}`), Correct: []string{"a", "b c"}},
+}
+
+func TestValueScannerFunc(t *testing.T) {
+	for _, input := range VALUE_SCANNER_SAMPLES {
+		var table TestValueStruct
+		field := reflect.ValueOf(&table).Elem().FieldByName(input.Field)
+
+		scanner := valueScanner(field)
+		if scanner == nil {
+			t.Errorf("no scanner for %v", input.Field)
+			continue
+		}
+
+		if err := scanner.Scan(input.Src); err != nil {
+			t.Errorf("error on %v: %v", input.Field, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(field.Interface(), input.Correct) {
+			t.Errorf("wrong %v:\ngiven %#v\nwanted %#v", input.Field, field.Interface(), input.Correct)
+		}
+	}
+
+	// Types with native support are scanned through their address
+	var table TestValueStruct
+	for _, name := range []string{"CreatedAt", "Quantity", "Payload", "Amount"} {
+		if scanner := valueScanner(reflect.ValueOf(&table).Elem().FieldByName(name)); scanner != nil {
+			t.Errorf("unexpected scanner for %v", name)
+		}
+	}
+}
+
+func TestValueRoundTripFunc(t *testing.T) {
+	db := dbtest.DB(t)
+
+	if _, err := CreateTable(db, TestValueStruct{}); err != nil {
+		t.Fatalf("failed to create the table: %v", err)
+	}
+
+	supply, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	amount, _ := types.ParseDecimal("12.50")
+	rows := []TestValueStruct{
+		{
+			Id:        types.Default[int64]{Default: true},
+			CreatedAt: TEST_VALUE_TIME,
+			Quantity:  &TEST_VALUE_QUANTITY,
+			Payload:   []byte{0x00, 0xff},
+			Supply:    supply,
+			Amount:    amount,
+			Meta:      json.RawMessage(`{"a": [1, 2]}`),
+			Labels:    map[string]any{"b": "c"},
+			Ticks:     []int64{1, 2, 3},
+			Tags:      []string{"x", "y z"},
+		},
+		{
+			Id:        types.Default[int64]{Default: true},
+			CreatedAt: TEST_VALUE_TIME,
+		},
+	}
+
+	for i, row := range rows {
+		if _, err := InsertEntry(db, row); err != nil {
+			t.Fatalf("error when adding entry %d: %v", i, err)
+		}
+
+		selected, err := SelectOne[TestValueStruct](db, "SELECT * FROM TestValueStruct WHERE id = $1", i+1)
+		if err != nil {
+			t.Fatalf("error when selecting entry %d: %v", i, err)
+		}
+
+		row.Id = types.Default[int64]{Value: int64(i + 1)}
+		if !selected.CreatedAt.Equal(row.CreatedAt) {
+			t.Errorf("wrong created_at: %v %v", selected.CreatedAt, row.CreatedAt)
+		}
+		if !selected.Amount.Equal(row.Amount) {
+			t.Errorf("wrong amount: %v %v", selected.Amount, row.Amount)
+		}
+		selected.CreatedAt, selected.Amount = row.CreatedAt, row.Amount
+
+		if !reflect.DeepEqual(selected, row) {
+			t.Errorf("wrong entry %d:\ngiven %+v\nwanted %+v", i, selected, row)
+		}
+	}
+}
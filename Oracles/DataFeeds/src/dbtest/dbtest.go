@@ -0,0 +1,265 @@
+// Package dbtest provides isolated Postgres databases to tests. An
+// embedded Postgres is started once per test package, on the first use,
+// and every test gets its own schema, dropped when the test ends:
+//
+//	func TestMain(m *testing.M) {
+//		dbtest.Main(m)
+//	}
+//
+//	func TestInsertFunc(t *testing.T) {
+//		db := dbtest.DB(t)
+//		dbtest.LoadFixtures(t, db, "testdata/assets.yaml")
+//		...
+//	}
+//
+// It doesn't depend on the database package, so that its tests can use it
+package dbtest
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/config"
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/joho/godotenv"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotStarted = errors.New("embedded postgres is not started")
+)
+
+// Longest schema name prefix taken from the test name, identifiers are
+// limited to 63 bytes
+const maxSchemaPrefix = 40
+
+// Characters not allowed in a schema name
+var schemaNameRegex = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// Embedded Postgres shared by the tests of the package
+var (
+	startOnce sync.Once
+	stopOnce  sync.Once
+	server    *embeddedpostgres.EmbeddedPostgres
+	runtime   string
+	baseDSN   string
+	admin     *sql.DB
+	startErr  error
+	schemas   atomic.Int64
+)
+
+// Isolated database of a test, its queries run in its own schema
+type Database struct {
+	// Database struct bound to the schema
+	DB *sql.DB
+	// Data source name bound to the schema, for the connections opened
+	// by the test itself
+	DSN string
+	// Schema name
+	Schema string
+}
+
+// Runs the tests of the package and stops the embedded Postgres, it is
+// meant to be the whole TestMain
+//
+// Parameters:
+//   - m:	the tests
+func Main(m *testing.M) {
+	code := m.Run()
+	if err := Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "error stopping the embedded postgres: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// Returns a new database isolated in its own schema, the embedded Postgres
+// is started on the first call. The schema is dropped when the test ends
+//
+// Parameters:
+//   - t:	the test
+//
+// Returns:
+//   - *Database:	the isolated database
+func New(t testing.TB) *Database {
+	t.Helper()
+
+	startOnce.Do(func() {
+		startErr = start()
+	})
+	if startErr != nil {
+		t.Fatalf("embedded postgres didnt start: %v", startErr)
+	}
+
+	schema := schemaName(t.Name(), schemas.Add(1))
+	if _, err := admin.Exec("CREATE SCHEMA " + pq.QuoteIdentifier(schema)); err != nil {
+		t.Fatalf("error creating the test schema: %v", err)
+	}
+
+	dsn := baseDSN + "&search_path=" + url.QueryEscape(schema)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("error opening the test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("error closing the test database: %v", err)
+		}
+		if _, err := admin.Exec("DROP SCHEMA " + pq.QuoteIdentifier(schema) + " CASCADE"); err != nil {
+			t.Errorf("error dropping the test schema: %v", err)
+		}
+	})
+
+	return &Database{DB: db, DSN: dsn, Schema: schema}
+}
+
+// Returns a new database struct isolated in its own schema, see New
+//
+// Parameters:
+//   - t:	the test
+//
+// Returns:
+//   - *sql.DB:	the database struct
+func DB(t testing.TB) *sql.DB {
+	t.Helper()
+	return New(t).DB
+}
+
+// Stops the embedded Postgres if it has been started, removing its data
+//
+// Returns:
+//   - error:	if it cannot be stopped
+func Stop() error {
+	var err error
+	stopOnce.Do(func() {
+		if server == nil {
+			return
+		}
+
+		err = errors.Join(admin.Close(), server.Stop(), os.RemoveAll(runtime))
+	})
+
+	return err
+}
+
+// Starts the embedded Postgres on a free port, configured by the
+// DB_EMBED_* keys of the nearest .env file
+//
+// Returns:
+//   - error:	if it cannot be started
+func start() error {
+	loadEnv()
+
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+
+	runtime, err = os.MkdirTemp("", "dbtest-")
+	if err != nil {
+		return err
+	}
+
+	username := envOr(config.DB_EMBED_USERNAME, "postgres")
+	password := envOr(config.DB_EMBED_PASSWORD, "postgres")
+	database := envOr(config.DB_EMBED_DATABASE, "postgres")
+
+	// Every package gets its own runtime, so that they run in parallel
+	server = embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Username(username).
+		Password(password).
+		Database(database).
+		Port(port).
+		RuntimePath(filepath.Join(runtime, "runtime")).
+		DataPath(filepath.Join(runtime, "data")).
+		Logger(io.Discard).
+		Version(embeddedpostgres.V16))
+
+	if err := server.Start(); err != nil {
+		server = nil
+		os.RemoveAll(runtime)
+		return err
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(username, password),
+		Host:     fmt.Sprintf("localhost:%d", port),
+		Path:     "/" + database,
+		RawQuery: "sslmode=disable",
+	}
+	baseDSN = dsn.String()
+
+	admin, err = sql.Open("postgres", baseDSN)
+	return err
+}
+
+// Loads the nearest .env file up from the working directory, the variables
+// already set are kept
+func loadEnv() {
+	dir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+
+	for {
+		path := filepath.Join(dir, ".env")
+		if _, err := os.Stat(path); err == nil {
+			godotenv.Load(path)
+			return
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+	}
+}
+
+// Returns the environment key or fallback if it is not set
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// Returns a free local port
+func freePort() (uint32, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	return uint32(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+// Returns the schema name of the test, unique within the package
+//
+// Parameters:
+//   - name:	the test name
+//   - n:		the schema number
+//
+// Returns:
+//   - string:	the schema name
+func schemaName(name string, n int64) string {
+	name = schemaNameRegex.ReplaceAllString(strings.ToLower(name), "_")
+	if len(name) > maxSchemaPrefix {
+		name = name[:maxSchemaPrefix]
+	}
+
+	return fmt.Sprintf("%s_%d", strings.Trim(name, "_"), n)
+}
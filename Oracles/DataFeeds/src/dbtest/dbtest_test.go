@@ -0,0 +1,66 @@
+package dbtest
+
+import (
+	"strings"
+	"testing"
+)
+
+type TestInput struct {
+	Input   any
+	Correct any
+}
+
+var SCHEMA_NAME_SAMPLES = []TestInput{
+	{Input: "TestInsertEntryFunc", Correct: "testinsertentryfunc_1"},
+	{Input: "TestSelectFunc/Latest price", Correct: "testselectfunc_latest_price_1"},
+	{Input: "TestAVeryLongTestNameThatGoesOnAndOnAndOnForever", Correct: "testaverylongtestnamethatgoesonandonando_1"},
+}
+
+func TestSchemaNameFunc(t *testing.T) {
+	for _, s := range SCHEMA_NAME_SAMPLES {
+		if name := schemaName(s.Input.(string), 1); name != s.Correct {
+			t.Errorf("wrong schema name, wanted %s, given %s", s.Correct, name)
+		}
+	}
+}
+
+func TestMain(m *testing.M) {
+	Main(m)
+}
+
+func TestNewFunc(t *testing.T) {
+	var first *Database
+	t.Run("first", func(t *testing.T) {
+		first = New(t)
+		if _, err := first.DB.Exec("CREATE TABLE asset (id SERIAL PRIMARY KEY, ticker TEXT)"); err != nil {
+			t.Fatalf("error creating the table: %v", err)
+		}
+
+		var schema string
+		if err := first.DB.QueryRow("SELECT current_schema()").Scan(&schema); err != nil || schema != first.Schema {
+			t.Errorf("wrong current schema %s, wanted %s: %v", schema, first.Schema, err)
+		}
+	})
+
+	// The schema of the first test has been dropped
+	second := New(t)
+	if second.Schema == first.Schema || !strings.Contains(second.DSN, second.Schema) {
+		t.Errorf("schemas are not isolated: %s and %s", first.Schema, second.Schema)
+	}
+
+	var exists bool
+	err := second.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1)", first.Schema).Scan(&exists)
+	if err != nil || exists {
+		t.Errorf("schema of the ended test has not been dropped: %v", err)
+	}
+
+	// The tables of the first test are not visible
+	err = second.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'asset')").Scan(&exists)
+	if err != nil || exists {
+		t.Errorf("table of another test is visible: %v", err)
+	}
+
+	if err := first.DB.Ping(); err == nil {
+		t.Errorf("database of the ended test is still open")
+	}
+}
@@ -0,0 +1,176 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+// Executor the fixtures are inserted through, satisfied by *sql.DB,
+// *sql.Conn, *sql.Tx and the database package executors
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Rows inserted into a table, in order. Columns are matched by name and
+// omitted columns take their default value
+type Fixture struct {
+	Table string           `yaml:"table" json:"table"`
+	Rows  []map[string]any `yaml:"rows"  json:"rows"`
+}
+
+// Inserts the fixtures of the YAML or JSON files, in order, failing the
+// test on any error. A file holds a list of fixtures:
+//
+//	# an asset and one of its prices
+//	- table: asset
+//	  rows:
+//	    - {id: 1, ticker: BTC, source: Binance, decimals: 18}
+//	- table: price
+//	  rows:
+//	    - {asset_id: 1, price: 4200, timestamp: "2024-01-01T00:00:00Z"}
+//
+// Parameters:
+//   - t:		the test
+//   - db:		the database struct
+//   - paths:	the fixture files
+func LoadFixtures(t testing.TB, db Executor, paths ...string) {
+	t.Helper()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("error reading the fixtures: %v", err)
+		}
+
+		fixtures, err := ParseFixtures(data)
+		if err != nil {
+			t.Fatalf("error parsing the fixtures of %s: %v", path, err)
+		}
+
+		if err := InsertFixtures(context.Background(), db, fixtures...); err != nil {
+			t.Fatalf("error inserting the fixtures of %s: %v", path, err)
+		}
+	}
+}
+
+// Parses YAML or JSON fixtures, as JSON is valid YAML
+//
+// Parameters:
+//   - data:	the fixtures file content
+//
+// Returns:
+//   - []Fixture:	the fixtures
+//   - error:		if the content is malformed
+func ParseFixtures(data []byte) ([]Fixture, error) {
+	var fixtures []Fixture
+	if err := yaml.Unmarshal(data, &fixtures); err != nil {
+		return nil, err
+	}
+
+	for _, f := range fixtures {
+		if f.Table == "" {
+			return nil, fmt.Errorf("fixture without table")
+		}
+	}
+
+	return fixtures, nil
+}
+
+// Inserts the fixtures, in order. The serial id sequences of the tables
+// whose ids are set are moved past them, so that later inserts don't
+// collide
+//
+// Parameters:
+//   - ctx:			the context bounding the queries
+//   - db:			the database struct
+//   - fixtures:	the fixtures
+//
+// Returns:
+//   - error:	if an insertion failed
+func InsertFixtures(ctx context.Context, db Executor, fixtures ...Fixture) error {
+	for _, f := range fixtures {
+		table := strings.ToLower(f.Table)
+		withIds := false
+
+		for _, row := range f.Rows {
+			query, args, err := buildFixtureInsert(table, row)
+			if err != nil {
+				return err
+			}
+			if _, err := db.ExecContext(ctx, query, args...); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+
+			_, ok := row["id"]
+			withIds = withIds || ok
+		}
+
+		if withIds {
+			_, err := db.ExecContext(ctx, fmt.Sprintf("SELECT setval(pg_get_serial_sequence($1, 'id'), (SELECT MAX(id) FROM %s))", pq.QuoteIdentifier(table)), table)
+			if err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Builds the insertion of a fixture row, columns are sorted by name
+//
+// Parameters:
+//   - table:	the table name
+//   - row:		the row values by column
+//
+// Returns:
+//   - string:	the query
+//   - []any:	the values bound to the query placeholders
+//   - error:	if a value cannot be encoded
+func buildFixtureInsert(table string, row map[string]any) (string, []any, error) {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	if len(columns) == 0 {
+		return fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", pq.QuoteIdentifier(table)), nil, nil
+	}
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(strings.ToLower(column))
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+
+		value, err := fixtureValue(row[column])
+		if err != nil {
+			return "", nil, fmt.Errorf("%s.%s: %w", table, column, err)
+		}
+		args[i] = value
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", pq.QuoteIdentifier(table), strings.Join(quoted, ", "), strings.Join(placeholders, ", ")), args, nil
+}
+
+// Returns the value bound for a fixture value, maps and lists are encoded
+// as JSON
+func fixtureValue(value any) (any, error) {
+	switch value.(type) {
+	case map[string]any, []any:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	default:
+		return value, nil
+	}
+}
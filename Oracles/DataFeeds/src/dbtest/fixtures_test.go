@@ -0,0 +1,102 @@
+package dbtest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFixturesFunc(t *testing.T) {
+	fixtures, err := ParseFixtures([]byte(`
+- table: Asset
+  rows:
+    - {ticker: BTC, decimals: 18}
+- table: price
+  rows: []
+`))
+	if err != nil {
+		t.Fatalf("error parsing the yaml fixtures: %v", err)
+	}
+	if len(fixtures) != 2 || fixtures[0].Table != "Asset" || len(fixtures[0].Rows) != 1 || fixtures[0].Rows[0]["ticker"] != "BTC" {
+		t.Errorf("wrong yaml fixtures: %+v", fixtures)
+	}
+
+	fixtures, err = ParseFixtures([]byte(`[{"table": "asset", "rows": [{"ticker": "ETH", "decimals": 18}]}]`))
+	if err != nil {
+		t.Fatalf("error parsing the json fixtures: %v", err)
+	}
+	if len(fixtures) != 1 || fixtures[0].Rows[0]["ticker"] != "ETH" || fixtures[0].Rows[0]["decimals"] != 18 {
+		t.Errorf("wrong json fixtures: %+v", fixtures)
+	}
+
+	if _, err := ParseFixtures([]byte(`[{"rows": []}]`)); err == nil {
+		t.Errorf("fixture without table has been parsed")
+	}
+	if _, err := ParseFixtures([]byte(`table: asset`)); err == nil {
+		t.Errorf("fixture not in a list has been parsed")
+	}
+}
+
+type FixtureInsertSample struct {
+	Row   map[string]any
+	Query string
+	Args  []any
+}
+
+var FIXTURE_INSERT_SAMPLES = []FixtureInsertSample{
+	{
+		Row:   map[string]any{"ticker": "BTC", "Decimals": 18, "id": 1},
+		Query: `INSERT INTO "asset" ("decimals", "id", "ticker") VALUES ($1, $2, $3)`,
+		Args:  []any{18, 1, "BTC"},
+	},
+	{
+		Row:   map[string]any{"meta": map[string]any{"venue": "spot"}},
+		Query: `INSERT INTO "asset" ("meta") VALUES ($1)`,
+		Args:  []any{`{"venue":"spot"}`},
+	},
+	{
+		Row:   map[string]any{},
+		Query: `INSERT INTO "asset" DEFAULT VALUES`,
+		Args:  nil,
+	},
+}
+
+func TestBuildFixtureInsertFunc(t *testing.T) {
+	for _, s := range FIXTURE_INSERT_SAMPLES {
+		query, args, err := buildFixtureInsert("asset", s.Row)
+		if err != nil {
+			t.Fatalf("error building the insert: %v", err)
+		}
+		if query != s.Query || !reflect.DeepEqual(args, s.Args) {
+			t.Errorf("wrong insert, wanted %s %v, given %s %v", s.Query, s.Args, query, args)
+		}
+	}
+}
+
+func TestLoadFixturesFunc(t *testing.T) {
+	db := DB(t)
+
+	_, err := db.Exec(`CREATE TABLE asset (id SERIAL PRIMARY KEY, ticker VARCHAR(16) NOT NULL, source VARCHAR(16) NOT NULL, decimals SMALLINT NOT NULL);
+CREATE TABLE price (id SERIAL PRIMARY KEY, asset_id INTEGER NOT NULL REFERENCES asset(id), price BIGINT NOT NULL, timestamp TIMESTAMP NOT NULL);`)
+	if err != nil {
+		t.Fatalf("error creating the tables: %v", err)
+	}
+
+	LoadFixtures(t, db, "testdata/assets.yaml", "testdata/prices.json")
+
+	var assets, prices, last int
+	if err := db.QueryRow("SELECT COUNT(*) FROM asset").Scan(&assets); err != nil || assets != 2 {
+		t.Errorf("wrong assets count %d: %v", assets, err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM price WHERE asset_id = 1").Scan(&prices); err != nil || prices != 3 {
+		t.Errorf("wrong prices count %d: %v", prices, err)
+	}
+	if err := db.QueryRow("SELECT price FROM price WHERE asset_id = 1 ORDER BY timestamp DESC LIMIT 1").Scan(&last); err != nil || last != 4190000 {
+		t.Errorf("wrong last price %d: %v", last, err)
+	}
+
+	// The id sequence has been moved past the fixtures
+	var id int
+	if err := db.QueryRow("INSERT INTO asset (ticker, source, decimals) VALUES ('SOL', 'Binance', 9) RETURNING id").Scan(&id); err != nil || id != 3 {
+		t.Errorf("wrong id after the fixtures %d: %v", id, err)
+	}
+}
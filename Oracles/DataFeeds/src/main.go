@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/config"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/database"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
+// Time given to the servers to finish the pending requests on shutdown
+const SHUTDOWN_TIMEOUT = 5 * time.Second
+
 type Price struct {
 	Price     int `json:"price"`
 	Timestamp int `json:"timestamp"`
@@ -23,16 +38,99 @@ func main() {
 	fmt.Println("Hello World")
 	utils.HandleFatalError(godotenv.Load(".env"))
 
-	// db.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		utils.HandleFatalError(runMigrate(os.Args[2:]))
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "retention" {
+		utils.HandleFatalError(runRetention())
+		return
+	}
+
+	db, err := openDatabase()
+	utils.HandleFatalError(err)
+	defer db.Close()
+
+	// The schema is owned by the migrations, refuse to run against a pending
+	// or incompatible schema
+	utils.HandleFatalError(checkMigrations(db))
+
+	diffs, err := database.CheckSchema(db, types.Asset{}, types.Price{}, types.Candle{})
+	for _, d := range diffs {
+		log.Printf("schema difference: %s\n", d)
+	}
+	utils.HandleFatalError(err)
+
+	// Every change of the assets must be recorded, they define how prices
+	// are read
+	utils.HandleFatalError(database.CheckAudit(db, types.Asset{}))
+
+	// The inserted prices must be notified to the listeners
+	utils.HandleFatalError(database.CheckPriceNotifications(db))
+
+	// Create the upcoming price partitions, retention runs keep them ahead
+	partitions, err := pricePartitions(db)
+	utils.HandleFatalError(err)
+	if partitions == nil {
+		log.Println("price table is not partitioned, run the migrations")
+	}
 
-	// URL := "postgresql://postgres:" + os.Getenv(DB_PASSWORD) + "@" + os.Getenv(DB_IP) + ":" + os.Getenv(DB_PORT) + "/postgres?sslmode=" + os.Getenv(DB_SSL_MODE)
-	// db, err := sql.Open("postgres", URL)
-	// utils.HandleFatalError(err)
-	// defer db.Close()
+	// Run until interrupted, the servers are shut down before the database
+	// is closed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// stats := db.Stats()
-	// fmt.Println(stats)
+	// Expose the pool statistics on /debug/vars
+	if err := database.PublishStats("database", db.DB); err != nil {
+		log.Println(err)
+	}
+	if addr := os.Getenv(config.METRICS_ADDR); addr != "" {
+		server := &http.Server{Addr: addr}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Println(err)
+				stop()
+			}
+		}()
+		defer shutdownServer(server)
+	}
+
+	// Serve the latest price of every asset from its materialized view,
+	// kept fresh until the shutdown
+	listener, err := openPriceListener()
+	utils.HandleFatalError(err)
+	defer listener.Close()
+
+	var routines sync.WaitGroup
+	defer routines.Wait()
+
+	routines.Add(1)
+	go func() {
+		defer routines.Done()
+		if err := refreshLatestPrices(ctx, db, listener); !errors.Is(err, context.Canceled) {
+			log.Println(err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("shutting down")
 
 	// insertEntry(db)
 	// addEntry(db)
 }
+
+// Shuts the server down, waiting for the pending requests up to the
+// shutdown timeout
+//
+// Parameters:
+//   - server:	the server
+func shutdownServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/database"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/migrations"
+)
+
+const migrateUsage = `usage: DataFeedExec migrate <command>
+
+commands:
+  up                       apply every pending migration
+  down [steps]             revert the last steps migrations, 1 by default
+  status                   print the state of every migration
+  force <version> [false]  mark a migration clean, or remove it with false`
+
+// Opens the database configured by the environment, waiting for it to be
+// reachable
+//
+// Returns:
+//   - *database.DB:	the database pool
+//   - error:			if an error occured
+func openDatabase() (*database.DB, error) {
+	c, err := database.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return database.Open(context.Background(), c)
+}
+
+// Returns the migrator of every schema migration
+//
+// Parameters:
+//   - db:	the database pool
+//
+// Returns:
+//   - *database.Migrator:	the migrator
+//   - error:				if a migration is malformed
+func newMigrator(db *database.DB) (*database.Migrator, error) {
+	all, err := migrations.All()
+	if err != nil {
+		return nil, err
+	}
+
+	return database.NewMigrator(db.DB, all...)
+}
+
+// Checks that every migration has been applied, the service never changes
+// the schema itself, see the migrate entry point
+//
+// Parameters:
+//   - db:	the database pool
+//
+// Returns:
+//   - error:	if a migration is pending or dirty
+func checkMigrations(db *database.DB) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		if s.Dirty {
+			return fmt.Errorf("migration %04d_%s is dirty, fix it then run migrate force", s.Version, s.Name)
+		}
+		if !s.Applied {
+			return fmt.Errorf("migration %04d_%s is pending, run migrate up", s.Version, s.Name)
+		}
+	}
+
+	return nil
+}
+
+// Runs the migrate entry point
+//
+// Parameters:
+//   - args:	the arguments following migrate
+//
+// Returns:
+//   - error:	if the command failed
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migrations\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				return err
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migrations\n", reverted)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			if s.Dirty {
+				state = "dirty"
+			} else if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		applied := true
+		if len(args) > 2 {
+			applied, err = strconv.ParseBool(args[2])
+			if err != nil {
+				return err
+			}
+		}
+
+		return migrator.Force(ctx, version, applied)
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
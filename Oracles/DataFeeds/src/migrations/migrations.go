@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"embed"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/database"
+)
+
+// Schema migrations, once released a migration file is frozen and any
+// schema change is a new numbered migration
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+// Returns every schema migration sorted by version
+//
+// Returns:
+//   - []database.Migration:	the migrations
+//   - error:					if any migration file is malformed
+func All() ([]database.Migration, error) {
+	return database.LoadSQLMigrations(files, "sql")
+}
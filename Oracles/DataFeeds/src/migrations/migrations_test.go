@@ -0,0 +1,144 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/database"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Schema created by CreateTable before the migrations, without the
+// unique constraint of the prices
+const BASELINE_SCHEMA = `CREATE TABLE Asset (
+	id SERIAL PRIMARY KEY,
+	ticker VARCHAR(16) NOT NULL,
+	source VARCHAR(16) NOT NULL,
+	decimals SMALLINT NOT NULL CHECK (decimals >= 0)
+);
+CREATE TABLE Price (
+	id SERIAL PRIMARY KEY,
+	asset_id INTEGER NOT NULL,
+	price BIGINT NOT NULL,
+	timestamp TIMESTAMP DEFAULT NOW() NOT NULL,
+	FOREIGN KEY (asset_id) REFERENCES asset(id)
+);
+CREATE INDEX idx_price_asset_id ON Price(asset_id);
+INSERT INTO Asset (ticker, source, decimals) VALUES ('BTC', 'binance', 8);
+INSERT INTO Price (asset_id, price, timestamp) VALUES (1, 10, '2024-01-01'), (1, 12, '2024-01-02');`
+
+// Price sharing its asset and timestamp with one of the baseline prices
+const BASELINE_DUPLICATED_PRICE = `INSERT INTO Price (asset_id, price, timestamp) VALUES (1, 11, '2024-01-01');`
+
+// TestMain runs before any test in this package, the embedded postgres
+// started by the tests is stopped once they are done
+func TestMain(m *testing.M) {
+	dbtest.Main(m)
+}
+
+func TestAllFunc(t *testing.T) {
+	migrations, err := All()
+	if err != nil {
+		t.Fatalf("error loading the migrations: %v", err)
+	}
+
+	if len(migrations) == 0 || migrations[0].Version != 1 || migrations[0].Name != "initial_schema" {
+		t.Fatalf("wrong initial migration: %v", migrations)
+	}
+
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration versions are not contiguous: %d at position %d", m.Version, i)
+		}
+		if m.Up == nil || m.Down == nil {
+			t.Errorf("migration %d_%s is missing a step", m.Version, m.Name)
+		}
+	}
+}
+
+func TestInitialSchemaAdoptionFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	if _, err := db.Exec(BASELINE_SCHEMA); err != nil {
+		t.Fatalf("error creating the baseline schema: %v", err)
+	}
+
+	migrations, err := All()
+	if err != nil {
+		t.Fatalf("error loading the migrations: %v", err)
+	}
+	migrator, err := database.NewMigrator(db, migrations[0])
+	if err != nil {
+		t.Fatalf("error creating the migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("error migrating up: %v", err)
+	}
+
+	// The prices are kept and the constraint added
+	var prices int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Price").Scan(&prices); err != nil || prices != 2 {
+		t.Errorf("wrong prices: %d, %v", prices, err)
+	}
+	if _, err := db.Exec("INSERT INTO Price (asset_id, price, timestamp) VALUES (1, 13, '2024-01-02')"); err == nil {
+		t.Errorf("duplicated price inserted")
+	}
+}
+
+func TestInitialSchemaDuplicatedPricesFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	if _, err := db.Exec(BASELINE_SCHEMA + BASELINE_DUPLICATED_PRICE); err != nil {
+		t.Fatalf("error creating the baseline schema: %v", err)
+	}
+
+	migrations, err := All()
+	if err != nil {
+		t.Fatalf("error loading the migrations: %v", err)
+	}
+	migrator, err := database.NewMigrator(db, migrations[0])
+	if err != nil {
+		t.Fatalf("error creating the migrator: %v", err)
+	}
+
+	// The migration fails, leaving the duplicated prices to be removed by hand
+	if _, err := migrator.Up(context.Background()); err == nil {
+		t.Fatalf("migrated up with duplicated prices")
+	}
+	var prices int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Price").Scan(&prices); err != nil || prices != 3 {
+		t.Errorf("wrong prices: %d, %v", prices, err)
+	}
+}
+
+func TestUpgradeFromBaselineFunc(t *testing.T) {
+	db := dbtest.DB(t)
+	if _, err := db.Exec(BASELINE_SCHEMA); err != nil {
+		t.Fatalf("error creating the baseline schema: %v", err)
+	}
+
+	migrations, err := All()
+	if err != nil {
+		t.Fatalf("error loading the migrations: %v", err)
+	}
+	migrator, err := database.NewMigrator(db, migrations...)
+	if err != nil {
+		t.Fatalf("error creating the migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("error migrating up: %v", err)
+	}
+
+	// The prices are moved into the partitioned table
+	var partitioned bool
+	if err := db.QueryRow("SELECT relkind = 'p' FROM pg_class WHERE oid = 'price'::regclass").Scan(&partitioned); err != nil || !partitioned {
+		t.Errorf("price is not partitioned: %v", err)
+	}
+	var prices int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Price").Scan(&prices); err != nil || prices != 2 {
+		t.Errorf("wrong prices: %d, %v", prices, err)
+	}
+
+	if diffs, err := database.CheckSchema(db, types.Asset{}, types.Price{}, types.Candle{}); err != nil {
+		t.Errorf("migrated schema is incompatible: %v %v", diffs, err)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS Price;
+DROP TABLE IF EXISTS Asset;
@@ -0,0 +1,37 @@
+-- Baseline schema, tables already created by CreateTable are adopted and
+-- reconciled with it
+CREATE TABLE IF NOT EXISTS Asset (
+	id SERIAL PRIMARY KEY,
+	ticker VARCHAR(16) NOT NULL,
+	source VARCHAR(16) NOT NULL,
+	decimals SMALLINT NOT NULL CHECK (decimals >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS Price (
+	id SERIAL PRIMARY KEY,
+	asset_id INTEGER NOT NULL,
+	price BIGINT NOT NULL,
+	timestamp TIMESTAMP DEFAULT NOW() NOT NULL,
+	FOREIGN KEY (asset_id) REFERENCES asset(id),
+	CONSTRAINT price_asset_id_timestamp_key UNIQUE (asset_id, timestamp)
+);
+CREATE INDEX IF NOT EXISTS idx_price_asset_id ON Price(asset_id);
+
+-- Price tables created before the unique tag miss its constraint, which is
+-- added once their duplicated prices have been removed by hand
+DO $$
+DECLARE
+	duplicates BIGINT;
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'price'::regclass AND conname = 'price_asset_id_timestamp_key') THEN
+		SELECT COUNT(*) INTO duplicates FROM Price p
+		WHERE EXISTS (SELECT 1 FROM Price d WHERE p.asset_id = d.asset_id AND p.timestamp = d.timestamp AND p.id > d.id);
+		IF duplicates > 0 THEN
+			RAISE EXCEPTION 'price has % rows sharing their asset_id and timestamp with another row', duplicates
+				USING HINT = 'remove the duplicated prices, then force the migration back and run it again';
+		END IF;
+
+		ALTER TABLE Price ADD CONSTRAINT price_asset_id_timestamp_key UNIQUE (asset_id, timestamp);
+	END IF;
+END
+$$;
@@ -0,0 +1,16 @@
+-- Candles the retention job rolls the expired prices up into
+CREATE TABLE IF NOT EXISTS Candle (
+	id SERIAL PRIMARY KEY,
+	asset_id INTEGER NOT NULL,
+	resolution VARCHAR(8) NOT NULL,
+	bucket TIMESTAMP NOT NULL,
+	open BIGINT NOT NULL,
+	high BIGINT NOT NULL,
+	low BIGINT NOT NULL,
+	close BIGINT NOT NULL,
+	count INTEGER NOT NULL,
+	opened_at TIMESTAMP NOT NULL,
+	closed_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (asset_id) REFERENCES asset(id),
+	CONSTRAINT candle_asset_id_resolution_bucket_key UNIQUE (asset_id, resolution, bucket)
+);
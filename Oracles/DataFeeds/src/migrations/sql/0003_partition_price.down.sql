@@ -0,0 +1,28 @@
+-- Converts Price back into a plain table, dropping its partitions
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_class WHERE relname = 'price' AND relkind = 'p' AND relnamespace = current_schema()::regnamespace) THEN
+		ALTER TABLE Price RENAME TO price_partitioned;
+		ALTER TABLE price_partitioned RENAME CONSTRAINT price_pkey TO price_partitioned_pkey;
+		ALTER TABLE price_partitioned RENAME CONSTRAINT price_asset_id_timestamp_key TO price_partitioned_asset_id_timestamp_key;
+		ALTER INDEX idx_price_asset_id RENAME TO idx_price_partitioned_asset_id;
+		ALTER SEQUENCE price_id_seq RENAME TO price_partitioned_id_seq;
+
+		CREATE TABLE Price (
+			id SERIAL PRIMARY KEY,
+			asset_id INTEGER NOT NULL,
+			price BIGINT NOT NULL,
+			timestamp TIMESTAMP DEFAULT NOW() NOT NULL,
+			FOREIGN KEY (asset_id) REFERENCES asset(id),
+			CONSTRAINT price_asset_id_timestamp_key UNIQUE (asset_id, timestamp)
+		);
+		CREATE INDEX idx_price_asset_id ON Price(asset_id);
+
+		INSERT INTO Price (id, asset_id, price, timestamp)
+		SELECT id, asset_id, price, timestamp FROM price_partitioned;
+		PERFORM setval('price_id_seq', COALESCE((SELECT MAX(id) FROM Price), 0) + 1, false);
+
+		DROP TABLE price_partitioned;
+	END IF;
+END
+$$;
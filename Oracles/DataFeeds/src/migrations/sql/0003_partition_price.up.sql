@@ -0,0 +1,32 @@
+-- Converts Price into a table partitioned by timestamp ranges, the rows
+-- are moved into the default partition until their partition is created.
+-- Tables already created partitioned are left as they are
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_class WHERE relname = 'price' AND relkind = 'r' AND relnamespace = current_schema()::regnamespace) THEN
+		ALTER TABLE Price RENAME TO price_unpartitioned;
+		ALTER TABLE price_unpartitioned RENAME CONSTRAINT price_pkey TO price_unpartitioned_pkey;
+		ALTER TABLE price_unpartitioned RENAME CONSTRAINT price_asset_id_timestamp_key TO price_unpartitioned_asset_id_timestamp_key;
+		ALTER INDEX idx_price_asset_id RENAME TO idx_price_unpartitioned_asset_id;
+		ALTER SEQUENCE price_id_seq RENAME TO price_unpartitioned_id_seq;
+
+		CREATE TABLE Price (
+			id SERIAL,
+			asset_id INTEGER NOT NULL,
+			price BIGINT NOT NULL,
+			timestamp TIMESTAMP DEFAULT NOW() NOT NULL,
+			PRIMARY KEY (id, timestamp),
+			FOREIGN KEY (asset_id) REFERENCES asset(id),
+			CONSTRAINT price_asset_id_timestamp_key UNIQUE (asset_id, timestamp)
+		) PARTITION BY RANGE (timestamp);
+		CREATE TABLE Price_default PARTITION OF Price DEFAULT;
+		CREATE INDEX idx_price_asset_id ON Price(asset_id);
+
+		INSERT INTO Price (id, asset_id, price, timestamp)
+		SELECT id, asset_id, price, timestamp FROM price_unpartitioned;
+		PERFORM setval('price_id_seq', COALESCE((SELECT MAX(id) FROM Price), 0) + 1, false);
+
+		DROP TABLE price_unpartitioned;
+	END IF;
+END
+$$;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS asset_audit ON asset;
+DROP FUNCTION IF EXISTS audit_row() CASCADE;
+DROP TABLE IF EXISTS audit_history;
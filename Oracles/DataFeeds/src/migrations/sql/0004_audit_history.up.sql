@@ -0,0 +1,40 @@
+-- Records every change of the Asset table into the audit history, the
+-- existing assets are recorded as snapshots
+CREATE TABLE IF NOT EXISTS audit_history (
+	id BIGSERIAL PRIMARY KEY,
+	table_name TEXT NOT NULL,
+	row_id BIGINT,
+	operation VARCHAR(8) NOT NULL CHECK (operation IN ('SNAPSHOT', 'INSERT', 'UPDATE', 'DELETE')),
+	old_values JSONB,
+	new_values JSONB,
+	actor TEXT NOT NULL,
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+CREATE INDEX IF NOT EXISTS idx_audit_history_row ON audit_history (table_name, row_id, changed_at);
+CREATE OR REPLACE FUNCTION audit_row() RETURNS trigger AS $$
+DECLARE
+	old_row JSONB;
+	new_row JSONB;
+BEGIN
+	IF TG_OP <> 'INSERT' THEN
+		old_row := to_jsonb(OLD);
+	END IF;
+	IF TG_OP <> 'DELETE' THEN
+		new_row := to_jsonb(NEW);
+	END IF;
+	IF old_row = new_row THEN
+		RETURN NULL;
+	END IF;
+
+	INSERT INTO audit_history (table_name, row_id, operation, old_values, new_values, actor)
+	VALUES (TG_TABLE_NAME, (COALESCE(new_row, old_row)->>'id')::BIGINT, TG_OP, old_row, new_row,
+		COALESCE(NULLIF(current_setting('app.actor', true), ''), session_user));
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS asset_audit ON asset;
+CREATE TRIGGER asset_audit AFTER INSERT OR UPDATE OR DELETE ON asset
+FOR EACH ROW EXECUTE FUNCTION audit_row();
+INSERT INTO audit_history (table_name, row_id, operation, new_values, actor)
+SELECT 'asset', t.id, 'SNAPSHOT', to_jsonb(t), session_user FROM asset t
+WHERE NOT EXISTS (SELECT 1 FROM audit_history h WHERE h.table_name = 'asset' AND h.row_id = t.id);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS price_notify ON price;
+DROP FUNCTION IF EXISTS notify_price();
@@ -0,0 +1,17 @@
+-- Notifies every inserted price on the price channel, with the timestamp
+-- as unix seconds. Timestamps are stored in the session time zone by
+-- TO_TIMESTAMP, so they are read back in it
+CREATE OR REPLACE FUNCTION notify_price() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('price', json_build_object(
+		'id', NEW.id,
+		'asset_id', NEW.asset_id,
+		'price', NEW.price,
+		'timestamp', EXTRACT(EPOCH FROM NEW.timestamp::timestamptz)::BIGINT
+	)::TEXT);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS price_notify ON price;
+CREATE TRIGGER price_notify AFTER INSERT ON price
+FOR EACH ROW EXECUTE FUNCTION notify_price();
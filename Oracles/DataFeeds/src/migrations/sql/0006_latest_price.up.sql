@@ -0,0 +1,5 @@
+-- Latest price of every asset, refreshed concurrently by the service
+CREATE MATERIALIZED VIEW IF NOT EXISTS latest_price AS
+SELECT DISTINCT ON (asset_id) id, asset_id, price, timestamp FROM price
+ORDER BY asset_id, timestamp DESC;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_latest_price_asset_id ON latest_price (asset_id);
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/database"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Runs the retention entry point, enforcing the default retention policy
+// once. It is meant to be scheduled, overlapping runs are skipped
+//
+// Returns:
+//   - error:	if the run failed
+func runRetention() error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	job, err := database.NewRetentionJob(db.DB, database.DefaultRetentionPolicy)
+	if err != nil {
+		return err
+	}
+
+	job.Partitions, err = pricePartitions(db)
+	if err != nil {
+		return err
+	}
+
+	report, err := job.Run(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if report.Skipped {
+		fmt.Println("retention already running, skipped")
+		return nil
+	}
+	fmt.Printf("rolled up %d prices, deleted %d candles and %d partitions\n", report.RolledUp, report.CandlesDeleted, report.PartitionsDropped)
+
+	return nil
+}
+
+// Returns the daily partition manager of the Price table after creating
+// its upcoming partitions, nil if the table is not partitioned yet
+//
+// Parameters:
+//   - db:	the database executor
+//
+// Returns:
+//   - *database.PartitionManager:	the partition manager
+//   - error:						if an error occured
+func pricePartitions(db database.Executor) (*database.PartitionManager, error) {
+	partitions, err := database.NewPartitionManager(types.Price{}, database.PartitionDaily)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = partitions.EnsurePartitions(db)
+	if errors.Is(err, database.ErrNotPartitioned) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return partitions, nil
+}
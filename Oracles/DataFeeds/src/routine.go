@@ -1 +1,70 @@
 package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/database"
+)
+
+// Refreshes of the latest_price view, the interval catches up the inserts
+// missed while the listener reconnects
+const (
+	LATEST_PRICES_REFRESH_INTERVAL = time.Minute
+	LATEST_PRICES_DEBOUNCE         = time.Second
+)
+
+// Opens the listener of the inserted prices on the database configured by
+// the environment, its errors are logged
+//
+// Returns:
+//   - *database.PriceListener:	the listener
+//   - error:					if an error occured
+func openPriceListener() (*database.PriceListener, error) {
+	c, err := database.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return database.NewPriceListener(c.DSN(), database.ListenerConfig{
+		OnError: func(err error) {
+			log.Printf("price listener: %v\n", err)
+		},
+	})
+}
+
+// Keeps the latest_price view fresh until ctx is done, refreshing it on the
+// inserted prices and on an interval
+//
+// Parameters:
+//   - ctx:			the context stopping the refreshes
+//   - db:			the database struct
+//   - listener:	the price listener
+//
+// Returns:
+//   - error:	the ctx error, ErrListenerClosed if the listener is closed
+func refreshLatestPrices(ctx context.Context, db database.Executor, listener *database.PriceListener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logRefresh := func(err error) {
+		if err != nil && ctx.Err() == nil {
+			log.Printf("error refreshing the latest prices: %v\n", err)
+		}
+	}
+
+	var scheduled sync.WaitGroup
+	scheduled.Add(1)
+	go func() {
+		defer scheduled.Done()
+		database.ScheduleLatestPricesRefresh(ctx, db, LATEST_PRICES_REFRESH_INTERVAL, logRefresh)
+	}()
+
+	err := database.RefreshLatestPricesOnInsert(ctx, db, listener, LATEST_PRICES_DEBOUNCE, logRefresh)
+	cancel()
+	scheduled.Wait()
+
+	return err
+}
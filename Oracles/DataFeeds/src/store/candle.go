@@ -0,0 +1,150 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/utils"
+)
+
+// Origin of the candle buckets, the same as the database package
+var candleOrigin = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+// Aggregates prices ordered by timestamp into the OHLC candles of the
+// resolution, ordered by bucket
+//
+// Parameters:
+//   - prices:		the prices ordered by timestamp
+//   - resolution:	the candle resolution
+//
+// Returns:
+//   - []types.Candle:	the candles
+//   - error:			if the resolution is not supported
+func BuildCandles(prices []types.Price, resolution types.Resolution) ([]types.Candle, error) {
+	duration, err := resolution.Duration()
+	if err != nil {
+		return nil, err
+	}
+	seconds := int64(duration.Seconds())
+
+	var candles []types.Candle
+	for _, p := range prices {
+		bucket := int(bucketStart(int64(p.Timestamp.Unix), seconds))
+		at := unixTimestamp(int64(p.Timestamp.Unix))
+
+		last := len(candles) - 1
+		if last < 0 || candles[last].Bucket.Unix != bucket {
+			candles = append(candles, types.Candle{
+				Asset_id:   p.Asset_id,
+				Resolution: resolution,
+				Bucket:     unixTimestamp(int64(bucket)),
+				Open:       p.Price,
+				High:       p.Price,
+				Low:        p.Price,
+				Close:      p.Price,
+				Count:      1,
+				Opened_at:  at,
+				Closed_at:  at,
+			})
+			continue
+		}
+
+		c := &candles[last]
+		c.High = max(c.High, p.Price)
+		c.Low = min(c.Low, p.Price)
+		c.Close = p.Price
+		c.Count++
+		c.Closed_at = at
+	}
+
+	return candles, nil
+}
+
+// Merges the stored candles with the ones aggregated from the raw prices,
+// candles of the same bucket are merged in the time order of their prices
+//
+// Parameters:
+//   - stored:		the stored candles
+//   - aggregated:	the candles aggregated from the raw prices
+//
+// Returns:
+//   - []types.Candle:	the candles ordered by bucket
+func mergeCandles(stored []types.Candle, aggregated []types.Candle) []types.Candle {
+	candles := append([]types.Candle(nil), stored...)
+	buckets := make(map[int]int, len(candles))
+	for i, c := range candles {
+		buckets[c.Bucket.Unix] = i
+	}
+
+	for _, c := range aggregated {
+		i, ok := buckets[c.Bucket.Unix]
+		if !ok {
+			buckets[c.Bucket.Unix] = len(candles)
+			candles = append(candles, c)
+			continue
+		}
+
+		candles[i] = mergeCandle(candles[i], c)
+	}
+
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Bucket.Unix < candles[j].Bucket.Unix
+	})
+	return candles
+}
+
+// Merges two candles of the same bucket, the open and close are the ones
+// of the earliest opened and latest closed candle
+//
+// Parameters:
+//   - c:		the candle
+//   - other:	the candle merged into c
+//
+// Returns:
+//   - types.Candle:	the merged candle
+func mergeCandle(c types.Candle, other types.Candle) types.Candle {
+	if other.Opened_at.Unix < c.Opened_at.Unix {
+		c.Open, c.Opened_at = other.Open, other.Opened_at
+	}
+	if other.Closed_at.Unix > c.Closed_at.Unix {
+		c.Close, c.Closed_at = other.Close, other.Closed_at
+	}
+	c.High = max(c.High, other.High)
+	c.Low = min(c.Low, other.Low)
+	c.Count += other.Count
+
+	return c
+}
+
+// Returns the start of the bucket holding the unix timestamp
+func bucketStart(unix int64, seconds int64) int64 {
+	offset := (unix - candleOrigin) % seconds
+	if offset < 0 {
+		offset += seconds
+	}
+
+	return unix - offset
+}
+
+// Returns the timestamp of the unix time, with the datetime database/sql
+// scans from the database
+func unixTimestamp(unix int64) types.Timestamp {
+	return types.Timestamp{
+		Datetime: time.Unix(unix, 0).UTC().Format(time.RFC3339Nano),
+		Unix:     int(unix),
+	}
+}
+
+// Returns the unix time of a timestamp to be stored, now for Now timestamps
+func storedUnix(ts types.Timestamp, now time.Time) (int64, error) {
+	switch {
+	case ts.Now:
+		return now.Unix(), nil
+	case ts.Unix != 0 || ts.Datetime == "":
+		return int64(ts.Unix), nil
+	}
+
+	unix, err := utils.DatetimeToUnix(ts.Datetime)
+	return int64(unix), err
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+func TestBucketStartFunc(t *testing.T) {
+	hour := int64(time.Hour.Seconds())
+	start := STORE_START.Unix()
+
+	if b := bucketStart(start+59*60, hour); b != start {
+		t.Errorf("wrong bucket: %d", b)
+	}
+	if b := bucketStart(candleOrigin-1, hour); b != candleOrigin-hour {
+		t.Errorf("wrong bucket before the origin: %d", b)
+	}
+}
+
+func TestBuildCandlesFunc(t *testing.T) {
+	prices := append([]types.Price(nil), STORE_PRICES[:6]...)
+	prices[5].Timestamp = unixTimestamp(STORE_START.Unix() + 150)
+
+	candles, err := BuildCandles(prices, types.Resolution1m)
+	if err != nil || len(candles) != len(STORE_CANDLES) {
+		t.Fatalf("wrong candles: %v %v", candles, err)
+	}
+	for i, c := range candles {
+		if c != STORE_CANDLES[i] {
+			t.Errorf("wrong candle:\ngiven %v\nwanted %v", c, STORE_CANDLES[i])
+		}
+	}
+
+	if _, err := BuildCandles(STORE_PRICES, "2m"); err == nil {
+		t.Errorf("unsupported resolution accepted")
+	}
+}
+
+func TestMergeCandlesFunc(t *testing.T) {
+	stored := []types.Candle{
+		{Bucket: unixTimestamp(0), Open: 1, High: 5, Low: 1, Close: 5, Count: 10, Opened_at: unixTimestamp(0), Closed_at: unixTimestamp(50)},
+		{Bucket: unixTimestamp(60), Open: 2, High: 6, Low: 2, Close: 6, Count: 10, Opened_at: unixTimestamp(70), Closed_at: unixTimestamp(80)},
+	}
+	aggregated := []types.Candle{
+		{Bucket: unixTimestamp(60), Open: 3, High: 9, Low: 1, Close: 4, Count: 2, Opened_at: unixTimestamp(65), Closed_at: unixTimestamp(75)},
+		{Bucket: unixTimestamp(120), Open: 7, High: 7, Low: 7, Close: 7, Count: 1, Opened_at: unixTimestamp(120), Closed_at: unixTimestamp(120)},
+	}
+
+	merged := mergeCandles(stored, aggregated)
+	if len(merged) != 3 || merged[0] != stored[0] || merged[2] != aggregated[1] {
+		t.Fatalf("wrong merged candles: %v", merged)
+	}
+
+	// The late prices opened the bucket before the stored candle
+	correct := types.Candle{Bucket: unixTimestamp(60), Open: 3, High: 9, Low: 1, Close: 6, Count: 12, Opened_at: unixTimestamp(65), Closed_at: unixTimestamp(80)}
+	if merged[1] != correct {
+		t.Errorf("wrong merged candle:\ngiven %v\nwanted %v", merged[1], correct)
+	}
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// In-memory store, meant for tests and local runs
+type Memory struct {
+	mu     sync.RWMutex
+	assets []types.Asset
+	// Prices of each asset ordered by timestamp
+	prices map[int][]types.Price
+	nextId int64
+}
+
+var _ Store = (*Memory)(nil)
+
+// Returns an empty in-memory store
+//
+// Returns:
+//   - *Memory:	the store
+func NewMemory() *Memory {
+	return &Memory{prices: make(map[int][]types.Price)}
+}
+
+func (m *Memory) InsertAsset(ctx context.Context, asset types.Asset) (types.Asset, error) {
+	if err := ctx.Err(); err != nil {
+		return types.Asset{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	asset.Id = types.Default[uint64]{Value: uint64(len(m.assets) + 1)}
+	m.assets = append(m.assets, asset)
+
+	return asset, nil
+}
+
+func (m *Memory) Asset(ctx context.Context, id int) (types.Asset, error) {
+	if err := ctx.Err(); err != nil {
+		return types.Asset{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if id < 1 || id > len(m.assets) {
+		return types.Asset{}, ErrNotFound
+	}
+
+	return m.assets[id-1], nil
+}
+
+func (m *Memory) Assets(ctx context.Context) ([]types.Asset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]types.Asset(nil), m.assets...), nil
+}
+
+func (m *Memory) InsertPrices(ctx context.Context, prices ...types.Price) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Validate every price before inserting any
+	now := time.Now()
+	stored := make([]types.Price, len(prices))
+	seen := make(map[[2]int]bool, len(prices))
+	for i, p := range prices {
+		if p.Asset_id < 1 || p.Asset_id > len(m.assets) {
+			return ErrUnknownAsset
+		}
+
+		unix, err := storedUnix(p.Timestamp, now)
+		if err != nil {
+			return err
+		}
+
+		key := [2]int{p.Asset_id, int(unix)}
+		if _, ok := m.find(p.Asset_id, unix); ok || seen[key] {
+			return ErrDuplicatePrice
+		}
+		seen[key] = true
+
+		p.Timestamp = unixTimestamp(unix)
+		stored[i] = p
+	}
+
+	for _, p := range stored {
+		m.nextId++
+		p.Id = types.Default[int64]{Value: m.nextId}
+
+		series := m.prices[p.Asset_id]
+		i, _ := m.find(p.Asset_id, int64(p.Timestamp.Unix))
+		series = append(series, types.Price{})
+		copy(series[i+1:], series[i:])
+		series[i] = p
+		m.prices[p.Asset_id] = series
+	}
+
+	return nil
+}
+
+func (m *Memory) PricesInRange(ctx context.Context, assetId int, from time.Time, to time.Time) ([]types.Price, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.inRange(assetId, from, to), nil
+}
+
+func (m *Memory) LatestPrice(ctx context.Context, assetId int) (types.Price, error) {
+	if err := ctx.Err(); err != nil {
+		return types.Price{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	series := m.prices[assetId]
+	if len(series) == 0 {
+		return types.Price{}, ErrNotFound
+	}
+
+	return series[len(series)-1], nil
+}
+
+func (m *Memory) LatestPrices(ctx context.Context) ([]types.Price, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var latest []types.Price
+	for id := 1; id <= len(m.assets); id++ {
+		if series := m.prices[id]; len(series) > 0 {
+			latest = append(latest, series[len(series)-1])
+		}
+	}
+
+	return latest, nil
+}
+
+func (m *Memory) Candles(ctx context.Context, assetId int, resolution types.Resolution, from time.Time, to time.Time) ([]types.Candle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return BuildCandles(m.inRange(assetId, from, to), resolution)
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Returns the position of the first price of the asset at or after unix,
+// and whether it is exactly at unix
+func (m *Memory) find(assetId int, unix int64) (int, bool) {
+	series := m.prices[assetId]
+	i := sort.Search(len(series), func(i int) bool {
+		return int64(series[i].Timestamp.Unix) >= unix
+	})
+
+	return i, i < len(series) && int64(series[i].Timestamp.Unix) == unix
+}
+
+// Returns a copy of the prices of the asset within [from, to)
+func (m *Memory) inRange(assetId int, from time.Time, to time.Time) []types.Price {
+	start, _ := m.find(assetId, from.Unix())
+	end, _ := m.find(assetId, to.Unix())
+	if start >= end {
+		return nil
+	}
+
+	return append([]types.Price(nil), m.prices[assetId][start:end]...)
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/database"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Postgres store, built on the database package. The tables are expected
+// to exist, see database.Registry and the migrations
+type Postgres struct {
+	db database.Executor
+}
+
+var _ Store = (*Postgres)(nil)
+
+// Returns the store of the database, db is a *sql.DB, a *database.DB
+// retrying its reads or a transaction
+//
+// Parameters:
+//   - db:	the database executor
+//
+// Returns:
+//   - *Postgres:	the store
+func NewPostgres(db database.Executor) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) InsertAsset(ctx context.Context, asset types.Asset) (types.Asset, error) {
+	asset.Id = types.Default[uint64]{Default: true}
+
+	id, err := database.InsertEntryReturningIdContext(ctx, p.db, asset)
+	if err != nil {
+		return types.Asset{}, postgresError(err)
+	}
+
+	asset.Id = types.Default[uint64]{Value: uint64(id)}
+	return asset, nil
+}
+
+func (p *Postgres) Asset(ctx context.Context, id int) (types.Asset, error) {
+	asset, err := database.SelectOneContext[types.Asset](ctx, p.db, "SELECT * FROM Asset WHERE id = $1", id)
+	return asset, postgresError(err)
+}
+
+func (p *Postgres) Assets(ctx context.Context) ([]types.Asset, error) {
+	assets, err := database.SelectIntoContext[types.Asset](ctx, p.db, "SELECT * FROM Asset ORDER BY id")
+	return assets, postgresError(err)
+}
+
+func (p *Postgres) InsertPrices(ctx context.Context, prices ...types.Price) error {
+	rows := make([]types.Price, len(prices))
+	for i, price := range prices {
+		price.Id = types.Default[int64]{Default: true}
+
+		// Timestamps are inserted from their unix time
+		if !price.Timestamp.Now {
+			unix, err := storedUnix(price.Timestamp, time.Time{})
+			if err != nil {
+				return err
+			}
+			price.Timestamp = types.Timestamp{Unix: int(unix)}
+		}
+		rows[i] = price
+	}
+
+	err := database.WithTxContext(ctx, p.db, database.TxConfig{}, func(tx database.Executor) error {
+		_, errs := database.BulkInsertEntriesContext(ctx, tx, rows)
+		return errors.Join(errs...)
+	})
+	return postgresError(err)
+}
+
+func (p *Postgres) PricesInRange(ctx context.Context, assetId int, from time.Time, to time.Time) ([]types.Price, error) {
+	prices, err := database.SelectPricesInRangeContext(ctx, p.db, assetId, from, to)
+	return prices, postgresError(err)
+}
+
+func (p *Postgres) LatestPrice(ctx context.Context, assetId int) (types.Price, error) {
+	price, err := database.SelectOneContext[types.Price](ctx, p.db, "SELECT * FROM Price WHERE asset_id = $1 ORDER BY timestamp DESC LIMIT 1", assetId)
+	return price, postgresError(err)
+}
+
+func (p *Postgres) LatestPrices(ctx context.Context) ([]types.Price, error) {
+	prices, err := database.SelectIntoContext[types.Price](ctx, p.db, "SELECT DISTINCT ON (asset_id) * FROM Price ORDER BY asset_id, timestamp DESC")
+	return prices, postgresError(err)
+}
+
+// Returns the candles aggregated from the raw prices, completed by the
+// candles the retention job rolled the expired prices up into
+func (p *Postgres) Candles(ctx context.Context, assetId int, resolution types.Resolution, from time.Time, to time.Time) ([]types.Candle, error) {
+	aggregated, err := database.SelectCandlesContext(ctx, p.db, assetId, resolution, from, to)
+	if err != nil {
+		return nil, postgresError(err)
+	}
+
+	stored, err := database.SelectIntoContext[types.Candle](ctx, p.db, `SELECT * FROM Candle
+WHERE asset_id = $1 AND resolution = $2 AND bucket >= TO_TIMESTAMP($3) AND bucket < TO_TIMESTAMP($4)`, assetId, resolution, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, postgresError(err)
+	}
+
+	return mergeCandles(stored, aggregated), nil
+}
+
+// Closes the database, executors which cannot be closed, as transactions,
+// are left to their owner
+func (p *Postgres) Close() error {
+	if closer, ok := p.db.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Translates the database errors to the store ones
+func postgresError(err error) error {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, database.ErrUniqueViolation):
+		return errors.Join(ErrDuplicatePrice, err)
+	case errors.Is(err, database.ErrForeignKeyViolation):
+		return errors.Join(ErrUnknownAsset, err)
+	}
+
+	return err
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Schema of the SQLite store, timestamps are stored as unix seconds
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS asset (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	ticker TEXT NOT NULL,
+	source TEXT NOT NULL,
+	decimals INTEGER NOT NULL CHECK (decimals >= 0)
+);
+CREATE TABLE IF NOT EXISTS price (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	asset_id INTEGER NOT NULL REFERENCES asset(id),
+	price INTEGER NOT NULL,
+	timestamp INTEGER NOT NULL,
+	UNIQUE (asset_id, timestamp)
+);`
+
+// SQLite store, built on the pure Go modernc.org/sqlite driver
+type SQLite struct {
+	db *sql.DB
+}
+
+var _ Store = (*SQLite)(nil)
+
+// Opens the SQLite database of the data source name and creates its
+// tables, ":memory:" opens a private in-memory database
+//
+// Parameters:
+//   - dsn:	the data source name, usually a file path
+//
+// Returns:
+//   - *SQLite:	the store
+//   - error:	if an error occured
+func NewSQLite(dsn string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single connection serializes the writes, and keeps an in-memory
+	// database alive along with its foreign keys setting
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON;\n" + sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) InsertAsset(ctx context.Context, asset types.Asset) (types.Asset, error) {
+	res, err := s.db.ExecContext(ctx, "INSERT INTO asset (ticker, source, decimals) VALUES (?, ?, ?)", asset.Ticker, asset.Source, asset.Decimals)
+	if err != nil {
+		return types.Asset{}, sqliteError(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return types.Asset{}, err
+	}
+
+	asset.Id = types.Default[uint64]{Value: uint64(id)}
+	return asset, nil
+}
+
+func (s *SQLite) Asset(ctx context.Context, id int) (types.Asset, error) {
+	assets, err := s.assets(ctx, "SELECT id, ticker, source, decimals FROM asset WHERE id = ?", id)
+	if err != nil {
+		return types.Asset{}, err
+	}
+	if len(assets) == 0 {
+		return types.Asset{}, ErrNotFound
+	}
+
+	return assets[0], nil
+}
+
+func (s *SQLite) Assets(ctx context.Context) ([]types.Asset, error) {
+	return s.assets(ctx, "SELECT id, ticker, source, decimals FROM asset ORDER BY id")
+}
+
+func (s *SQLite) InsertPrices(ctx context.Context, prices ...types.Price) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, p := range prices {
+		unix, err := storedUnix(p.Timestamp, now)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO price (asset_id, price, timestamp) VALUES (?, ?, ?)", p.Asset_id, p.Price, unix)
+		if err != nil {
+			return sqliteError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLite) PricesInRange(ctx context.Context, assetId int, from time.Time, to time.Time) ([]types.Price, error) {
+	return s.prices(ctx, `SELECT id, asset_id, price, timestamp FROM price
+WHERE asset_id = ? AND timestamp >= ? AND timestamp < ?
+ORDER BY timestamp`, assetId, from.Unix(), to.Unix())
+}
+
+func (s *SQLite) LatestPrice(ctx context.Context, assetId int) (types.Price, error) {
+	prices, err := s.prices(ctx, "SELECT id, asset_id, price, timestamp FROM price WHERE asset_id = ? ORDER BY timestamp DESC LIMIT 1", assetId)
+	if err != nil {
+		return types.Price{}, err
+	}
+	if len(prices) == 0 {
+		return types.Price{}, ErrNotFound
+	}
+
+	return prices[0], nil
+}
+
+func (s *SQLite) LatestPrices(ctx context.Context) ([]types.Price, error) {
+	return s.prices(ctx, `SELECT p.id, p.asset_id, p.price, p.timestamp FROM price p
+JOIN (SELECT asset_id, MAX(timestamp) AS timestamp FROM price GROUP BY asset_id) l
+ON p.asset_id = l.asset_id AND p.timestamp = l.timestamp
+ORDER BY p.asset_id`)
+}
+
+func (s *SQLite) Candles(ctx context.Context, assetId int, resolution types.Resolution, from time.Time, to time.Time) ([]types.Candle, error) {
+	prices, err := s.PricesInRange(ctx, assetId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildCandles(prices, resolution)
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// Selects the assets of the query
+func (s *SQLite) assets(ctx context.Context, query string, args ...any) ([]types.Asset, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []types.Asset
+	for rows.Next() {
+		var a types.Asset
+		if err := rows.Scan(&a.Id.Value, &a.Ticker, &a.Source, &a.Decimals); err != nil {
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+
+	return assets, rows.Err()
+}
+
+// Selects the prices of the query
+func (s *SQLite) prices(ctx context.Context, query string, args ...any) ([]types.Price, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []types.Price
+	for rows.Next() {
+		var p types.Price
+		var unix int64
+		if err := rows.Scan(&p.Id.Value, &p.Asset_id, &p.Price, &unix); err != nil {
+			return nil, err
+		}
+		p.Timestamp = unixTimestamp(unix)
+		prices = append(prices, p)
+	}
+
+	return prices, rows.Err()
+}
+
+// Translates the SQLite errors to the store ones
+func sqliteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+			return errors.Join(ErrDuplicatePrice, err)
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return errors.Join(ErrUnknownAsset, err)
+		}
+	}
+
+	return err
+}
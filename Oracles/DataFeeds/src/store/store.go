@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrUnknownAsset   = errors.New("price of an unknown asset")
+	ErrDuplicatePrice = errors.New("asset already has a price at the timestamp")
+)
+
+// Store persists the assets and their prices, feeds and strategies depend
+// on it only so that they run on any backend
+//
+// Prices are unique per asset and timestamp and must reference an
+// existing asset. Ranges include from and exclude to
+type Store interface {
+	// Inserts the asset and returns it with its id
+	InsertAsset(ctx context.Context, asset types.Asset) (types.Asset, error)
+	// Returns the asset, ErrNotFound if it doesn't exist
+	Asset(ctx context.Context, id int) (types.Asset, error)
+	// Returns every asset ordered by id
+	Assets(ctx context.Context) ([]types.Asset, error)
+
+	// Inserts the prices, none is inserted if any fails
+	InsertPrices(ctx context.Context, prices ...types.Price) error
+	// Returns the prices of the asset within [from, to) ordered by timestamp
+	PricesInRange(ctx context.Context, assetId int, from time.Time, to time.Time) ([]types.Price, error)
+	// Returns the latest price of the asset, ErrNotFound if it has none
+	LatestPrice(ctx context.Context, assetId int) (types.Price, error)
+	// Returns the latest price of every asset having one, ordered by asset id
+	LatestPrices(ctx context.Context) ([]types.Price, error)
+
+	// Returns the candles of the asset within [from, to) ordered by bucket
+	Candles(ctx context.Context, assetId int, resolution types.Resolution, from time.Time, to time.Time) ([]types.Candle, error)
+
+	// Releases the store resources
+	Close() error
+}
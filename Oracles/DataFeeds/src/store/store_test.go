@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/database"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/dbtest"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Stores checked against the contract, the postgres one runs in its own
+// dbtest schema
+var STORES = map[string]func(t *testing.T) (Store, error){
+	"memory": func(t *testing.T) (Store, error) {
+		return NewMemory(), nil
+	},
+	"sqlite": func(t *testing.T) (Store, error) {
+		return NewSQLite(":memory:")
+	},
+	"postgres": func(t *testing.T) (Store, error) {
+		db := dbtest.DB(t)
+
+		models, err := database.NewRegistry(types.Asset{}, types.Price{}, types.Candle{})
+		if err != nil {
+			return nil, err
+		}
+		if err := models.EnsureSchema(db); err != nil {
+			return nil, err
+		}
+
+		return NewPostgres(&database.DB{DB: db, ReadRetries: 1}), nil
+	},
+}
+
+var STORE_ASSETS = []types.Asset{
+	{Ticker: "BTC", Source: "Binance", Decimals: 8},
+	{Ticker: "ETH", Source: "Binance", Decimals: 18},
+}
+
+var STORE_START = time.Date(2024, 8, 25, 12, 0, 0, 0, time.UTC)
+
+// Prices every 30 seconds, the first asset over 3 minutes
+var STORE_PRICES = []types.Price{
+	{Asset_id: 1, Price: 100, Timestamp: types.Timestamp{Unix: int(STORE_START.Unix())}},
+	{Asset_id: 1, Price: 120, Timestamp: types.Timestamp{Unix: int(STORE_START.Unix()) + 30}},
+	{Asset_id: 1, Price: 90, Timestamp: types.Timestamp{Unix: int(STORE_START.Unix()) + 60}},
+	{Asset_id: 1, Price: 110, Timestamp: types.Timestamp{Unix: int(STORE_START.Unix()) + 90}},
+	{Asset_id: 1, Price: 130, Timestamp: types.Timestamp{Unix: int(STORE_START.Unix()) + 120}},
+	{Asset_id: 1, Price: 80, Timestamp: types.Timestamp{Datetime: "2024-08-25 12:02:30"}},
+	{Asset_id: 2, Price: 7, Timestamp: types.Timestamp{Unix: int(STORE_START.Unix())}},
+}
+
+var STORE_CANDLES = []types.Candle{
+	{Asset_id: 1, Resolution: types.Resolution1m, Bucket: unixTimestamp(STORE_START.Unix()), Open: 100, High: 120, Low: 100, Close: 120, Count: 2, Opened_at: unixTimestamp(STORE_START.Unix()), Closed_at: unixTimestamp(STORE_START.Unix() + 30)},
+	{Asset_id: 1, Resolution: types.Resolution1m, Bucket: unixTimestamp(STORE_START.Unix() + 60), Open: 90, High: 110, Low: 90, Close: 110, Count: 2, Opened_at: unixTimestamp(STORE_START.Unix() + 60), Closed_at: unixTimestamp(STORE_START.Unix() + 90)},
+	{Asset_id: 1, Resolution: types.Resolution1m, Bucket: unixTimestamp(STORE_START.Unix() + 120), Open: 130, High: 130, Low: 80, Close: 80, Count: 2, Opened_at: unixTimestamp(STORE_START.Unix() + 120), Closed_at: unixTimestamp(STORE_START.Unix() + 150)},
+}
+
+// TestMain runs before any test in this package, the embedded postgres
+// started by the tests is stopped once they are done
+func TestMain(m *testing.M) {
+	dbtest.Main(m)
+}
+
+func TestStoreFunc(t *testing.T) {
+	for name, open := range STORES {
+		t.Run(name, func(t *testing.T) {
+			s, err := open(t)
+			if err != nil {
+				t.Fatalf("error opening the store: %v", err)
+			}
+			defer s.Close()
+
+			testStore(t, s)
+		})
+	}
+}
+
+// Checks the Store contract
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	// Assets
+	for i, a := range STORE_ASSETS {
+		inserted, err := s.InsertAsset(ctx, a)
+		if err != nil {
+			t.Fatalf("error inserting the asset: %v", err)
+		}
+		if inserted.Id.Value != uint64(i+1) || inserted.Ticker != a.Ticker {
+			t.Errorf("wrong inserted asset: %v", inserted)
+		}
+	}
+
+	asset, err := s.Asset(ctx, 2)
+	if err != nil || asset.Ticker != "ETH" || asset.Decimals != 18 {
+		t.Errorf("wrong asset: %v %v", asset, err)
+	}
+	if _, err := s.Asset(ctx, 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing asset found: %v", err)
+	}
+
+	assets, err := s.Assets(ctx)
+	if err != nil || len(assets) != len(STORE_ASSETS) || assets[0].Ticker != "BTC" {
+		t.Errorf("wrong assets: %v %v", assets, err)
+	}
+
+	// Prices
+	if _, err := s.LatestPrice(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("latest price of an asset without prices: %v", err)
+	}
+	if err := s.InsertPrices(ctx, STORE_PRICES...); err != nil {
+		t.Fatalf("error inserting the prices: %v", err)
+	}
+
+	// Nothing is inserted on failures
+	duplicate := []types.Price{{Asset_id: 2, Price: 1, Timestamp: types.Timestamp{Unix: int(STORE_START.Unix()) + 30}}, STORE_PRICES[0]}
+	if err := s.InsertPrices(ctx, duplicate...); !errors.Is(err, ErrDuplicatePrice) {
+		t.Errorf("duplicate price inserted: %v", err)
+	}
+	if err := s.InsertPrices(ctx, types.Price{Asset_id: 3, Price: 1, Timestamp: types.Timestamp{Unix: 1}}); !errors.Is(err, ErrUnknownAsset) {
+		t.Errorf("price of an unknown asset inserted: %v", err)
+	}
+
+	ranged, err := s.PricesInRange(ctx, 1, STORE_START.Add(30*time.Second), STORE_START.Add(2*time.Minute))
+	if err != nil || len(ranged) != 3 || ranged[0].Price != 120 || ranged[2].Price != 110 {
+		t.Errorf("wrong range: %v %v", ranged, err)
+	}
+	for i := 1; i < len(ranged); i++ {
+		if ranged[i].Id.Value == ranged[i-1].Id.Value || ranged[i].Timestamp.Unix-ranged[i-1].Timestamp.Unix != 30 {
+			t.Errorf("wrong prices: %v", ranged)
+		}
+	}
+
+	latest, err := s.LatestPrice(ctx, 1)
+	if err != nil || latest.Price != 80 || latest.Timestamp.Unix != int(STORE_START.Unix())+150 {
+		t.Errorf("wrong latest price: %v %v", latest, err)
+	}
+
+	latests, err := s.LatestPrices(ctx)
+	if err != nil || len(latests) != 2 || latests[0].Price != 80 || latests[1].Price != 7 {
+		t.Errorf("wrong latest prices: %v %v", latests, err)
+	}
+
+	// Candles
+	candles, err := s.Candles(ctx, 1, types.Resolution1m, STORE_START, STORE_START.Add(time.Hour))
+	if err != nil || len(candles) != len(STORE_CANDLES) {
+		t.Fatalf("wrong candles: %v %v", candles, err)
+	}
+	for i, c := range candles {
+		// Backends format the datetimes differently, only the unix times are compared
+		c.Id = types.Default[int64]{}
+		c.Bucket = unixTimestamp(int64(c.Bucket.Unix))
+		c.Opened_at = unixTimestamp(int64(c.Opened_at.Unix))
+		c.Closed_at = unixTimestamp(int64(c.Closed_at.Unix))
+		if c != STORE_CANDLES[i] {
+			t.Errorf("wrong candle:\ngiven %v\nwanted %v", c, STORE_CANDLES[i])
+		}
+	}
+
+	// Cancelled contexts are honoured
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := s.Assets(cancelled); err == nil {
+		t.Errorf("cancelled context ignored")
+	}
+}
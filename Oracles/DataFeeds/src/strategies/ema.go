@@ -1 +1,40 @@
 package strategies
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/store"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+// Returns the exponential moving average of the candle closes of the
+// asset over the periods preceding to, smoothed by 2 / (periods + 1) and
+// seeded with the first close
+//
+// Parameters:
+//   - ctx:			the context bounding the queries
+//   - s:			the store
+//   - assetId:		the asset id
+//   - resolution:	the period resolution
+//   - periods:		the number of periods
+//   - to:			the first excluded instant
+//
+// Returns:
+//   - int:		the average price
+//   - error:	ErrInvalidPeriods, ErrNoPrices or the store error
+func ExponentialMovingAverage(ctx context.Context, s store.Store, assetId int, resolution types.Resolution, periods int, to time.Time) (int, error) {
+	candles, err := periodCandles(ctx, s, assetId, resolution, periods, to)
+	if err != nil {
+		return 0, err
+	}
+
+	alpha := 2 / float64(periods+1)
+	ema := float64(candles[0].Close)
+	for _, c := range candles[1:] {
+		ema = alpha*float64(c.Close) + (1-alpha)*ema
+	}
+
+	return int(math.Round(ema)), nil
+}
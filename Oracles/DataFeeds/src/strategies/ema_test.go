@@ -0,0 +1,23 @@
+package strategies
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+func TestExponentialMovingAverageFunc(t *testing.T) {
+	s := newStrategyStore(t)
+	ctx := context.Background()
+	to := STRATEGY_START.Add(3 * time.Minute)
+
+	// Closes 120, 110, 80 smoothed by 0.5: 115, 97.5
+	if price, err := ExponentialMovingAverage(ctx, s, 1, types.Resolution1m, 3, to); err != nil || price != 98 {
+		t.Errorf("wrong exponential moving average: %d %v", price, err)
+	}
+	if price, err := ExponentialMovingAverage(ctx, s, 1, types.Resolution1m, 1, to); err != nil || price != 80 {
+		t.Errorf("wrong exponential moving average: %d %v", price, err)
+	}
+}
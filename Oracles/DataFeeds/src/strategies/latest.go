@@ -1 +1,41 @@
 package strategies
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/store"
+)
+
+var (
+	ErrNoPrices   = errors.New("no prices to compute the strategy")
+	ErrStalePrice = errors.New("latest price is stale")
+)
+
+// Returns the latest price of the asset, if it is not older than maxAge
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - s:		the store
+//   - assetId:	the asset id
+//   - maxAge:	the maximum price age, 0 for any age
+//
+// Returns:
+//   - int:		the price
+//   - error:	ErrNoPrices, ErrStalePrice or the store error
+func Latest(ctx context.Context, s store.Store, assetId int, maxAge time.Duration) (int, error) {
+	price, err := s.LatestPrice(ctx, assetId)
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, ErrNoPrices
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if maxAge > 0 && time.Since(time.Unix(int64(price.Timestamp.Unix), 0)) > maxAge {
+		return price.Price, ErrStalePrice
+	}
+
+	return price.Price, nil
+}
@@ -0,0 +1,23 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLatestFunc(t *testing.T) {
+	s := newStrategyStore(t)
+	ctx := context.Background()
+
+	if price, err := Latest(ctx, s, 1, 0); err != nil || price != 80 {
+		t.Errorf("wrong latest price: %d %v", price, err)
+	}
+	if price, err := Latest(ctx, s, 1, time.Hour); !errors.Is(err, ErrStalePrice) || price != 80 {
+		t.Errorf("stale price accepted: %d %v", price, err)
+	}
+	if _, err := Latest(ctx, s, 2, 0); !errors.Is(err, ErrNoPrices) {
+		t.Errorf("price of an asset without prices: %v", err)
+	}
+}
@@ -1 +1,64 @@
 package strategies
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/store"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+var (
+	ErrInvalidPeriods = errors.New("periods must be positive")
+)
+
+// Returns the simple moving average of the candle closes of the asset
+// over the periods preceding to, periods without prices are skipped
+//
+// Parameters:
+//   - ctx:			the context bounding the queries
+//   - s:			the store
+//   - assetId:		the asset id
+//   - resolution:	the period resolution
+//   - periods:		the number of periods
+//   - to:			the first excluded instant
+//
+// Returns:
+//   - int:		the average price
+//   - error:	ErrInvalidPeriods, ErrNoPrices or the store error
+func MovingAverage(ctx context.Context, s store.Store, assetId int, resolution types.Resolution, periods int, to time.Time) (int, error) {
+	candles, err := periodCandles(ctx, s, assetId, resolution, periods, to)
+	if err != nil {
+		return 0, err
+	}
+
+	sum := 0
+	for _, c := range candles {
+		sum += c.Close
+	}
+
+	return sum / len(candles), nil
+}
+
+// Returns the candles of the periods preceding to
+func periodCandles(ctx context.Context, s store.Store, assetId int, resolution types.Resolution, periods int, to time.Time) ([]types.Candle, error) {
+	if periods <= 0 {
+		return nil, ErrInvalidPeriods
+	}
+
+	duration, err := resolution.Duration()
+	if err != nil {
+		return nil, err
+	}
+
+	candles, err := s.Candles(ctx, assetId, resolution, to.Add(-time.Duration(periods)*duration), to)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) == 0 {
+		return nil, ErrNoPrices
+	}
+
+	return candles, nil
+}
@@ -0,0 +1,31 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+func TestMovingAverageFunc(t *testing.T) {
+	s := newStrategyStore(t)
+	ctx := context.Background()
+	to := STRATEGY_START.Add(3 * time.Minute)
+
+	// Closes 120, 110, 80
+	if price, err := MovingAverage(ctx, s, 1, types.Resolution1m, 3, to); err != nil || price != 103 {
+		t.Errorf("wrong moving average: %d %v", price, err)
+	}
+	if price, err := MovingAverage(ctx, s, 1, types.Resolution1m, 1, to); err != nil || price != 80 {
+		t.Errorf("wrong moving average: %d %v", price, err)
+	}
+
+	if _, err := MovingAverage(ctx, s, 1, types.Resolution1m, 0, to); !errors.Is(err, ErrInvalidPeriods) {
+		t.Errorf("invalid periods accepted: %v", err)
+	}
+	if _, err := MovingAverage(ctx, s, 2, types.Resolution1m, 3, to); !errors.Is(err, ErrNoPrices) {
+		t.Errorf("moving average without prices: %v", err)
+	}
+}
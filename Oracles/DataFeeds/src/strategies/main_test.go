@@ -0,0 +1,35 @@
+package strategies
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/store"
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
+)
+
+var STRATEGY_START = time.Date(2024, 8, 25, 12, 0, 0, 0, time.UTC)
+
+// Closes of consecutive minutes, a price every 30 seconds
+var STRATEGY_PRICES = []int{100, 120, 90, 110, 130, 80}
+
+// Returns a memory store holding an asset and STRATEGY_PRICES
+func newStrategyStore(t *testing.T) store.Store {
+	s := store.NewMemory()
+	ctx := context.Background()
+
+	if _, err := s.InsertAsset(ctx, types.Asset{Ticker: "BTC", Source: "Binance", Decimals: 8}); err != nil {
+		t.Fatalf("error inserting the asset: %v", err)
+	}
+
+	prices := make([]types.Price, len(STRATEGY_PRICES))
+	for i, p := range STRATEGY_PRICES {
+		prices[i] = types.Price{Asset_id: 1, Price: p, Timestamp: types.Timestamp{Unix: int(STRATEGY_START.Unix()) + 30*i}}
+	}
+	if err := s.InsertPrices(ctx, prices...); err != nil {
+		t.Fatalf("error inserting the prices: %v", err)
+	}
+
+	return s
+}
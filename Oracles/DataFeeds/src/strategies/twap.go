@@ -1 +1,46 @@
 package strategies
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/store"
+)
+
+// Returns the time weighted average price of the asset within [from, to),
+// each price is weighted by the time until the next one or to
+//
+// Parameters:
+//   - ctx:		the context bounding the queries
+//   - s:		the store
+//   - assetId:	the asset id
+//   - from:	the first included instant
+//   - to:		the first excluded instant
+//
+// Returns:
+//   - int:		the average price
+//   - error:	ErrNoPrices or the store error
+func TWAP(ctx context.Context, s store.Store, assetId int, from time.Time, to time.Time) (int, error) {
+	prices, err := s.PricesInRange(ctx, assetId, from, to)
+	if err != nil {
+		return 0, err
+	}
+	if len(prices) == 0 {
+		return 0, ErrNoPrices
+	}
+
+	var weighted, total float64
+	for i, p := range prices {
+		end := to.Unix()
+		if i+1 < len(prices) {
+			end = int64(prices[i+1].Timestamp.Unix)
+		}
+
+		weight := float64(end - int64(p.Timestamp.Unix))
+		weighted += float64(p.Price) * weight
+		total += weight
+	}
+
+	return int(math.Round(weighted / total)), nil
+}
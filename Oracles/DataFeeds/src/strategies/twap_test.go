@@ -0,0 +1,27 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTWAPFunc(t *testing.T) {
+	s := newStrategyStore(t)
+	ctx := context.Background()
+
+	// Every price lasts 30 seconds
+	if price, err := TWAP(ctx, s, 1, STRATEGY_START, STRATEGY_START.Add(3*time.Minute)); err != nil || price != 105 {
+		t.Errorf("wrong twap: %d %v", price, err)
+	}
+
+	// The last price lasts until to
+	if price, err := TWAP(ctx, s, 1, STRATEGY_START.Add(2*time.Minute), STRATEGY_START.Add(5*time.Minute)); err != nil || price != 88 {
+		t.Errorf("wrong twap: %d %v", price, err)
+	}
+
+	if _, err := TWAP(ctx, s, 1, STRATEGY_START.Add(time.Hour), STRATEGY_START.Add(2*time.Hour)); !errors.Is(err, ErrNoPrices) {
+		t.Errorf("twap without prices: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	CANDLE = reflect.TypeOf(Candle{})
+)
+
+// Candle resolution
+type Resolution string
+
+const (
+	Resolution1m Resolution = "1m"
+	Resolution5m Resolution = "5m"
+	Resolution1h Resolution = "1h"
+	Resolution1d Resolution = "1d"
+)
+
+// Supported resolutions, from the finest
+var RESOLUTIONS = []Resolution{Resolution1m, Resolution5m, Resolution1h, Resolution1d}
+
+// Returns the bucket duration of the resolution
+//
+// Returns:
+//   - time.Duration:	the bucket duration
+//   - error:			if the resolution is not supported
+func (r Resolution) Duration() (time.Duration, error) {
+	switch r {
+	case Resolution1m:
+		return time.Minute, nil
+	case Resolution5m:
+		return 5 * time.Minute, nil
+	case Resolution1h:
+		return time.Hour, nil
+	case Resolution1d:
+		return 24 * time.Hour, nil
+	}
+
+	return 0, fmt.Errorf("unsupported resolution: %s", r)
+}
+
+// Candle struct
+//
+// OHLC aggregation of the prices of an asset over a bucket of the
+// resolution, Bucket is the start of the bucket. Opened_at and Closed_at
+// are the timestamps of the open and close prices, so that prices rolled
+// up later into the bucket are merged in time order
+type Candle struct {
+	Id         Default[int64] `json:"id"         db:"id SERIAL PRIMARY KEY"`
+	Asset_id   int            `json:"asset_id"   db:"asset_id INTEGER NOT NULL" ref:"FOREIGN KEY (asset_id) REFERENCES asset(id)" unique:"candle_asset_id_resolution_bucket_key"`
+	Resolution Resolution     `json:"resolution" db:"resolution VARCHAR(8) NOT NULL" unique:"candle_asset_id_resolution_bucket_key"`
+	Bucket     Timestamp      `json:"bucket"     db:"bucket TIMESTAMP NOT NULL" unique:"candle_asset_id_resolution_bucket_key"`
+	Open       int            `json:"open"       db:"open BIGINT NOT NULL"`
+	High       int            `json:"high"       db:"high BIGINT NOT NULL"`
+	Low        int            `json:"low"        db:"low BIGINT NOT NULL"`
+	Close      int            `json:"close"      db:"close BIGINT NOT NULL"`
+	Count      int            `json:"count"      db:"count INTEGER NOT NULL"`
+	Opened_at  Timestamp      `json:"opened_at"  db:"opened_at TIMESTAMP NOT NULL"`
+	Closed_at  Timestamp      `json:"closed_at"  db:"closed_at TIMESTAMP NOT NULL"`
+}
+
+func (c Candle) GetPrimaryKeyNameDB() (string, error) {
+	str, err := getFieldNameDB(reflect.TypeOf(c).Field(0))
+	if err != nil {
+		return "", err
+	}
+
+	return str, nil
+}
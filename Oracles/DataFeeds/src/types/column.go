@@ -0,0 +1,60 @@
+package types
+
+import (
+	"reflect"
+)
+
+// Column reference interface, a column is resolved against the db
+// tags of its table when the query is built
+type ColumnRef interface {
+	ColumnName() string
+	TableType() reflect.Type
+}
+
+// Column of the table T, referenced by its db name
+type Column[T Table] struct {
+	Name string
+}
+
+// Returns the column handle of the table T
+//
+// Parameters:
+//   - name:	the column db name
+//
+// Returns:
+//   - Column[T]:	the column handle
+func Col[T Table](name string) Column[T] {
+	return Column[T]{Name: name}
+}
+
+func (c Column[T]) ColumnName() string {
+	return c.Name
+}
+
+func (c Column[T]) TableType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// Price columns
+var (
+	PriceId        = Col[Price]("id")
+	PriceAssetId   = Col[Price]("asset_id")
+	PricePrice     = Col[Price]("price")
+	PriceTimestamp = Col[Price]("timestamp")
+)
+
+// Asset columns
+var (
+	AssetId       = Col[Asset]("id")
+	AssetTicker   = Col[Asset]("ticker")
+	AssetSource   = Col[Asset]("source")
+	AssetDecimals = Col[Asset]("decimals")
+)
+
+// Candle columns
+var (
+	CandleId         = Col[Candle]("id")
+	CandleAssetId    = Col[Candle]("asset_id")
+	CandleResolution = Col[Candle]("resolution")
+	CandleBucket     = Col[Candle]("bucket")
+)
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Exact decimal number, stored as a NUMERIC column. Its value is
+// Unscaled * 10^-Scale, e.g. 12.50 is {Unscaled: 1250, Scale: 2}
+//
+// Note that the zero value is 0
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int32
+}
+
+// Parses a decimal from its textual representation, e.g. -12.50
+//
+// Parameters:
+//   - s:	the decimal string
+//
+// Returns:
+//   - Decimal:	the decimal
+//   - error:	if the string is not a decimal
+func ParseDecimal(s string) (Decimal, error) {
+	digits := strings.TrimSpace(s)
+
+	var scale int32
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		scale = int32(len(digits) - i - 1)
+		digits = digits[:i] + digits[i+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("not a decimal: %q", s)
+	}
+
+	return Decimal{Unscaled: unscaled, Scale: scale}, nil
+}
+
+// Returns the decimal textual representation, with Scale fractional digits
+func (d Decimal) String() string {
+	if d.Unscaled == nil {
+		d.Unscaled = new(big.Int)
+	}
+
+	digits := new(big.Int).Abs(d.Unscaled).String()
+	sign := ""
+	if d.Unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if d.Scale <= 0 {
+		return sign + digits + strings.Repeat("0", int(-d.Scale))
+	}
+
+	if len(digits) <= int(d.Scale) {
+		digits = strings.Repeat("0", int(d.Scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(d.Scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Returns whether the decimals are the same number, regardless of their scale
+//
+// Parameters:
+//   - other:	the other decimal
+//
+// Returns:
+//   - bool:	if the numbers are equal
+func (d Decimal) Equal(other Decimal) bool {
+	return d.rat().Cmp(other.rat()) == 0
+}
+
+func (d Decimal) rat() *big.Rat {
+	unscaled := d.Unscaled
+	if unscaled == nil {
+		unscaled = new(big.Int)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(d.Scale, -d.Scale))), nil)
+	if d.Scale < 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(unscaled, scale))
+	}
+	return new(big.Rat).SetFrac(unscaled, scale)
+}
+
+// Value implements driver.Valuer, the decimal is sent as text
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner, from the NUMERIC text or an integer
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		parsed, err := ParseDecimal(string(v))
+		*d = parsed
+		return err
+	case string:
+		parsed, err := ParseDecimal(v)
+		*d = parsed
+		return err
+	case int64:
+		*d = Decimal{Unscaled: big.NewInt(v)}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into a decimal", src)
+	}
+}
@@ -39,12 +39,6 @@ type Timestamp struct {
 	Unix     int
 }
 
-// Where condition type
-type WhereCondition struct {
-	Column    int
-	Condition string
-}
-
 // Table interface
 type Table interface {
 	GetPrimaryKeyNameDB() (string, error)
@@ -52,12 +46,13 @@ type Table interface {
 
 // Price struct
 //
-// Many to One relation with Assset
+// Many to One relation with Assset, an asset has at most one price per timestamp.
+// The table is partitioned by timestamp ranges
 type Price struct {
 	Id        Default[int64] `json:"id"  db:"id SERIAL PRIMARY KEY"`
-	Asset_id  int            `json:"asset_id"  db:"asset_id INTEGER NOT NULL" ref:"FOREIGN KEY (asset_id) REFERENCES asset(id)" idx:"CREATE INDEX idx_price_asset_id ON Price(asset_id)"`
+	Asset_id  int            `json:"asset_id"  db:"asset_id INTEGER NOT NULL" ref:"FOREIGN KEY (asset_id) REFERENCES asset(id)" idx:"CREATE INDEX idx_price_asset_id ON Price(asset_id)" unique:"price_asset_id_timestamp_key"`
 	Price     int            `json:"price"     db:"price BIGINT NOT NULL"`
-	Timestamp Timestamp      `json:"timestamp" db:"timestamp TIMESTAMP DEFAULT NOW() NOT NULL"`
+	Timestamp Timestamp      `json:"timestamp" db:"timestamp TIMESTAMP DEFAULT NOW() NOT NULL" unique:"price_asset_id_timestamp_key" partition:"RANGE (timestamp)"`
 }
 
 func (p Price) GetPrimaryKeyNameDB() (string, error) {
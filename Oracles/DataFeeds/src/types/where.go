@@ -0,0 +1,74 @@
+package types
+
+// Comparison operator of a where condition
+type Operator string
+
+const (
+	Equal        Operator = "="
+	NotEqual     Operator = "<>"
+	Less         Operator = "<"
+	LessEqual    Operator = "<="
+	Greater      Operator = ">"
+	GreaterEqual Operator = ">="
+	Between      Operator = "BETWEEN"
+	In           Operator = "IN"
+	IsNull       Operator = "IS NULL"
+	IsNotNull    Operator = "IS NOT NULL"
+	Like         Operator = "LIKE"
+)
+
+// Conjunction joining the conditions of a group
+type Conjunction string
+
+const (
+	And Conjunction = "AND"
+	Or  Conjunction = "OR"
+)
+
+// Where condition type
+//
+// A condition either compares Column to Values with Operator, or groups
+// nested conditions joined by Conjunction when Group is not empty
+type WhereCondition struct {
+	Column   ColumnRef
+	Operator Operator
+	Values   []any
+
+	Group       []WhereCondition
+	Conjunction Conjunction
+}
+
+// Returns a condition comparing the column to the values
+//
+// Parameters:
+//   - column:		the compared column
+//   - operator:	the comparison operator
+//   - values:		the compared values, two for Between, none for IsNull and IsNotNull
+//
+// Returns:
+//   - WhereCondition:	the condition
+func Where(column ColumnRef, operator Operator, values ...any) WhereCondition {
+	return WhereCondition{Column: column, Operator: operator, Values: values}
+}
+
+// Returns a group of conditions that must all hold
+//
+// Parameters:
+//   - conditions:	the grouped conditions
+//
+// Returns:
+//   - WhereCondition:	the group
+func AllOf(conditions ...WhereCondition) WhereCondition {
+	return WhereCondition{Group: conditions, Conjunction: And}
+}
+
+// Returns a group of conditions of which at least one must hold
+//
+// Parameters:
+//   - conditions:	the grouped conditions
+//
+// Returns:
+//   - WhereCondition:	the group
+func AnyOf(conditions ...WhereCondition) WhereCondition {
+	return WhereCondition{Group: conditions, Conjunction: Or}
+}
@@ -1,11 +1,20 @@
 package utils
 
 import (
+	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 )
 
+// Layouts accepted as datetimes, postgres text output and the RFC 3339
+// format database/sql uses when scanning a time.Time into a string
+var datetimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339Nano,
+}
+
 // DatetimeToUnix translates a postgres timestamp to a unix timestamp
 //
 // Parameters:
@@ -15,17 +24,20 @@ import (
 //   - int64:	the unix timestamp
 //   - error: 	if any error occured
 func DatetimeToUnix(datetime string) (int, error) {
-	// Define the layout for the datetime format
-	layout := "2006-01-02 15:04:05"
+	var err error
+	for _, layout := range datetimeLayouts {
+		// Parse the datetime string into a time.Time object
+		var t time.Time
+		t, err = time.Parse(layout, datetime)
+		if err != nil {
+			continue
+		}
 
-	// Parse the datetime string into a time.Time object
-	t, err := time.Parse(layout, datetime)
-	if err != nil {
-		return 0, err
+		// Convert the time.Time object to Unix timestamp
+		return int(t.Unix()), nil
 	}
 
-	// Convert the time.Time object to Unix timestamp
-	return int(t.Unix()), nil
+	return 0, err
 }
 
 // UpdateUnixTimestampFromDatetime takes the current Datetime
@@ -45,3 +57,42 @@ func UpdateUnixTimestampFromDatetime(p *types.Price) error {
 	(*p).Timestamp.Unix = unix
 	return nil
 }
+
+// UpdateUnixTimestamps updates the Unix timestamp of every
+// types.Timestamp field of the table from its Datetime, fields
+// without a Datetime are left untouched
+//
+// Parameters:
+//   - table:	the addressable table value
+//
+// Returns:
+//   - error: 	if any error occured
+func UpdateUnixTimestamps(table reflect.Value) error {
+	if !ValidateStruct(table.Type()) {
+		return fmt.Errorf("cannot update timestamps of a non-struct: %v", table.Type())
+	}
+
+	for i := 0; i < table.NumField(); i++ {
+		tf := table.Field(i)
+		if !ValidateTimestampStruct(tf.Type()) {
+			continue
+		}
+
+		datetime := tf.FieldByName("Datetime").String()
+		if datetime == "" {
+			continue
+		}
+
+		unix, err := DatetimeToUnix(datetime)
+		if err != nil {
+			return err
+		}
+
+		if !tf.CanSet() {
+			return fmt.Errorf("field is not settable %v", tf)
+		}
+		tf.FieldByName("Unix").SetInt(int64(unix))
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
@@ -40,6 +41,14 @@ var DATE_TIMES_TO_UNIX = []DateToUnix{
 		date: "2015-11-11 11:11:11",
 		unix: 1447240271,
 	},
+	{
+		date: "2024-08-25T12:00:00Z",
+		unix: 1724587200,
+	},
+	{
+		date: "2024-08-25T14:00:00.5+02:00",
+		unix: 1724587200,
+	},
 }
 
 func TestDatetimeToUnixFunc(t *testing.T) {
@@ -76,3 +85,34 @@ func TestUpdateUnixTimestampFromDatetimeFunc(t *testing.T) {
 		}
 	}
 }
+
+type TimestampsTable struct {
+	Created types.Timestamp
+	Name    string
+	Updated types.Timestamp
+}
+
+func TestUpdateUnixTimestampsFunc(t *testing.T) {
+	table := TimestampsTable{
+		Created: types.Timestamp{Datetime: "2024-08-25 12:00:00"},
+		Name:    "name",
+		Updated: types.Timestamp{Unix: 10},
+	}
+
+	err := UpdateUnixTimestamps(reflect.ValueOf(&table).Elem())
+	if err != nil {
+		t.Fatalf("error when updating timestamps: %v", err)
+	}
+
+	if table.Created.Unix != 1724587200 {
+		t.Errorf("worng unix time: wanted %d, given %d\n", 1724587200, table.Created.Unix)
+	}
+	if table.Updated.Unix != 10 {
+		t.Errorf("timestamp without datetime updated: %d\n", table.Updated.Unix)
+	}
+
+	table.Created.Datetime = "not a datetime"
+	if err := UpdateUnixTimestamps(reflect.ValueOf(&table).Elem()); err == nil {
+		t.Errorf("wrong datetime did not error")
+	}
+}
@@ -0,0 +1,432 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrEmptyStatement      = errors.New("empty sql statement")
+	ErrMultipleStatements  = errors.New("multiple sql statements")
+	ErrUnterminatedSQL     = errors.New("unterminated sql string, identifier or comment")
+	ErrUnbalancedSQLParens = errors.New("unbalanced sql parentheses")
+)
+
+// Kind of a SQL token
+type SQLTokenKind int
+
+const (
+	// Keyword or bare identifier
+	SQLWord SQLTokenKind = iota
+	// Double quoted identifier
+	SQLQuotedIdentifier
+	// Single quoted, escape or dollar quoted string
+	SQLString
+	SQLNumber
+	// Positional parameter, $1..$n
+	SQLParameter
+	// Operator or punctuation, one character at a time
+	SQLSymbol
+)
+
+// SQL token, comments and whitespace are skipped
+type SQLToken struct {
+	Kind  SQLTokenKind
+	Value string
+	// Parentheses nesting depth of the token, parentheses themselves
+	// are at the depth of their content minus one
+	Depth int
+	// Byte offsets of the token in the query
+	Start int
+	End   int
+}
+
+// Reports whether the token is the given keyword, case insensitively
+//
+// Parameters:
+//   - keyword:	the keyword
+//
+// Returns:
+//   - bool:	if the token is a word equal to keyword
+func (t SQLToken) Is(keyword string) bool {
+	return t.Kind == SQLWord && strings.EqualFold(t.Value, keyword)
+}
+
+// Reports whether the token is the given symbol
+//
+// Parameters:
+//   - symbol:	the symbol
+//
+// Returns:
+//   - bool:	if the token is the symbol
+func (t SQLToken) IsSymbol(symbol string) bool {
+	return t.Kind == SQLSymbol && t.Value == symbol
+}
+
+// Kind of a SQL statement, as far as data access is concerned
+type StatementKind int
+
+const (
+	// Neither read nor write, e.g. SET, BEGIN or EXPLAIN
+	StatementOther StatementKind = iota
+	// SELECT, VALUES or TABLE, including WITH queries selecting
+	StatementRead
+	// Data or schema modification
+	StatementWrite
+)
+
+// Commands classified as writes
+var writeCommands = []string{"INSERT", "UPDATE", "DELETE", "MERGE", "CREATE", "DROP", "ALTER", "TRUNCATE", "REPLACE", "GRANT", "REVOKE"}
+
+// Commands classified as reads
+var readCommands = []string{"SELECT", "VALUES", "TABLE"}
+
+// Classified SQL statement
+type SQLStatement struct {
+	// Upper cased leading command, the main statement one for WITH queries
+	Command string
+	Kind    StatementKind
+	// Whether the statement locks the rows it reads, FOR UPDATE and alike
+	Locking bool
+}
+
+// Splits a SQL query into tokens. Strings, quoted identifiers and comments
+// are skipped as a whole, so keywords and semicolons inside them are not
+// tokens
+//
+// Parameters:
+//   - query:	the query string
+//
+// Returns:
+//   - []SQLToken:	the tokens
+//   - error:		if a string, identifier or comment is unterminated or parentheses are unbalanced
+func TokenizeSQL(query string) ([]SQLToken, error) {
+	var tokens []SQLToken
+	depth := 0
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+
+		switch {
+		case isSQLSpace(c):
+			i++
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i - 1
+			}
+			i += end + 1
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end, err := skipBlockComment(query, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			continue
+		case c == '\'':
+			end, err := skipQuoted(query, i, '\'', false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, SQLToken{Kind: SQLString, Value: query[start:end], Depth: depth, Start: start, End: end})
+			i = end
+		case (c == 'E' || c == 'e') && i+1 < len(query) && query[i+1] == '\'':
+			end, err := skipQuoted(query, i+1, '\'', true)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, SQLToken{Kind: SQLString, Value: query[start:end], Depth: depth, Start: start, End: end})
+			i = end
+		case c == '"':
+			end, err := skipQuoted(query, i, '"', false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, SQLToken{Kind: SQLQuotedIdentifier, Value: query[start:end], Depth: depth, Start: start, End: end})
+			i = end
+		case c == '$':
+			if end := skipDigits(query, i+1); end > i+1 {
+				tokens = append(tokens, SQLToken{Kind: SQLParameter, Value: query[start:end], Depth: depth, Start: start, End: end})
+				i = end
+				break
+			}
+
+			// Dollar quoted string, $tag$...$tag$
+			tagEnd := skipWord(query, i+1, false)
+			if tagEnd >= len(query) || query[tagEnd] != '$' {
+				tokens = append(tokens, SQLToken{Kind: SQLSymbol, Value: "$", Depth: depth, Start: start, End: i + 1})
+				i++
+				break
+			}
+			tag := query[i : tagEnd+1]
+			end := strings.Index(query[tagEnd+1:], tag)
+			if end < 0 {
+				return nil, ErrUnterminatedSQL
+			}
+			end += tagEnd + 1 + len(tag)
+			tokens = append(tokens, SQLToken{Kind: SQLString, Value: query[start:end], Depth: depth, Start: start, End: end})
+			i = end
+		case isSQLWordStart(c):
+			end := skipWord(query, i, true)
+			tokens = append(tokens, SQLToken{Kind: SQLWord, Value: query[start:end], Depth: depth, Start: start, End: end})
+			i = end
+		case isSQLDigit(c) || (c == '.' && i+1 < len(query) && isSQLDigit(query[i+1])):
+			end := skipNumber(query, i)
+			tokens = append(tokens, SQLToken{Kind: SQLNumber, Value: query[start:end], Depth: depth, Start: start, End: end})
+			i = end
+		default:
+			if c == ')' {
+				depth--
+				if depth < 0 {
+					return nil, ErrUnbalancedSQLParens
+				}
+			}
+			tokens = append(tokens, SQLToken{Kind: SQLSymbol, Value: query[i : i+1], Depth: depth, Start: start, End: i + 1})
+			if c == '(' {
+				depth++
+			}
+			i++
+		}
+	}
+
+	if depth != 0 {
+		return nil, ErrUnbalancedSQLParens
+	}
+
+	return tokens, nil
+}
+
+// Splits a SQL query into its statements, separated by semicolons outside
+// of strings, identifiers, comments and parentheses. Empty statements are
+// dropped and the separators are not included
+//
+// Parameters:
+//   - query:	the query string
+//
+// Returns:
+//   - []string:	the statements
+//   - error:		if the query cannot be tokenized
+func SplitSQLStatements(query string) ([]string, error) {
+	tokens, err := TokenizeSQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	for _, s := range splitTokens(tokens) {
+		statements = append(statements, query[s[0].Start:s[len(s)-1].End])
+	}
+
+	return statements, nil
+}
+
+// Classifies a single SQL statement, a trailing semicolon is allowed.
+// The command is the first word, for WITH queries it is the one of the main
+// statement and the query is a write if any of its CTEs modifies data.
+// SELECT ... INTO creates a table, so it is a write too
+//
+// Parameters:
+//   - query:	the query string
+//
+// Returns:
+//   - SQLStatement:	the classified statement
+//   - error:			ErrEmptyStatement, ErrMultipleStatements or a tokenizing error
+func ClassifySQL(query string) (SQLStatement, error) {
+	tokens, err := TokenizeSQL(query)
+	if err != nil {
+		return SQLStatement{}, err
+	}
+
+	statements := splitTokens(tokens)
+	switch len(statements) {
+	case 0:
+		return SQLStatement{}, ErrEmptyStatement
+	case 1:
+	default:
+		return SQLStatement{}, ErrMultipleStatements
+	}
+	tokens = statements[0]
+
+	// Skip the parentheses wrapping the whole statement
+	first := 0
+	for first < len(tokens) && tokens[first].IsSymbol("(") {
+		first++
+	}
+	if first == len(tokens) || tokens[first].Kind != SQLWord {
+		return SQLStatement{}, nil
+	}
+
+	statement := SQLStatement{Command: strings.ToUpper(tokens[first].Value)}
+	if statement.Command == "WITH" {
+		statement.Command = ""
+		for i, t := range tokens[first+1:] {
+			if t.Depth == first && isSQLCommand(t) {
+				statement.Command = strings.ToUpper(t.Value)
+				break
+			}
+
+			// Data modifying CTE, AS (INSERT ...)
+			if t.Depth > first && isSQLCommand(t) && tokens[first+i].IsSymbol("(") && isWriteCommand(t.Value) {
+				statement.Kind = StatementWrite
+			}
+		}
+	}
+
+	for i, t := range tokens[first:] {
+		if t.Is("FOR") && i+first+1 < len(tokens) {
+			next := tokens[i+first+1]
+			statement.Locking = statement.Locking || next.Is("UPDATE") || next.Is("SHARE") || next.Is("NO") || next.Is("KEY")
+		}
+		if t.Is("INTO") && t.Depth == first && statement.Command == "SELECT" {
+			statement.Kind = StatementWrite
+		}
+	}
+
+	switch {
+	case statement.Kind == StatementWrite:
+	case isWriteCommand(statement.Command):
+		statement.Kind = StatementWrite
+	case isReadCommand(statement.Command):
+		statement.Kind = StatementRead
+	}
+
+	return statement, nil
+}
+
+// Groups the tokens by statement, dropping the separators and the empty
+// statements
+func splitTokens(tokens []SQLToken) [][]SQLToken {
+	var statements [][]SQLToken
+	start := 0
+	for i, t := range tokens {
+		if t.IsSymbol(";") && t.Depth == 0 {
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+
+	return statements
+}
+
+// Returns the offset after the block comment starting at i, block comments
+// nest in postgres
+func skipBlockComment(query string, i int) (int, error) {
+	depth := 0
+	for i < len(query) {
+		switch {
+		case strings.HasPrefix(query[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(query[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i, nil
+			}
+		default:
+			i++
+		}
+	}
+
+	return 0, ErrUnterminatedSQL
+}
+
+// Returns the offset after the quoted text starting at i, the quote is
+// escaped by doubling it and, if backslashes is set, by a backslash
+func skipQuoted(query string, i int, quote byte, backslashes bool) (int, error) {
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslashes {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+
+	return 0, ErrUnterminatedSQL
+}
+
+// Returns the offset after the word starting at i, words contain dollars
+// past their first character but dollar quote tags don't
+func skipWord(query string, i int, dollars bool) int {
+	for start := i; i < len(query); i++ {
+		c := query[i]
+		if !isSQLWordStart(c) && !(i > start && (isSQLDigit(c) || (dollars && c == '$'))) {
+			break
+		}
+	}
+	return i
+}
+
+func skipDigits(query string, i int) int {
+	for i < len(query) && isSQLDigit(query[i]) {
+		i++
+	}
+	return i
+}
+
+func skipNumber(query string, i int) int {
+	i = skipDigits(query, i)
+	if i < len(query) && query[i] == '.' {
+		i = skipDigits(query, i+1)
+	}
+	if i < len(query) && (query[i] == 'e' || query[i] == 'E') {
+		j := i + 1
+		if j < len(query) && (query[j] == '+' || query[j] == '-') {
+			j++
+		}
+		if end := skipDigits(query, j); end > j {
+			i = end
+		}
+	}
+	return i
+}
+
+func isSQLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isSQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSQLWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// Reports whether the token starts a statement that can follow WITH
+func isSQLCommand(t SQLToken) bool {
+	return t.Is("SELECT") || t.Is("INSERT") || t.Is("UPDATE") || t.Is("DELETE") || t.Is("MERGE") || t.Is("VALUES") || t.Is("TABLE")
+}
+
+func isWriteCommand(command string) bool {
+	for _, c := range writeCommands {
+		if strings.EqualFold(c, command) {
+			return true
+		}
+	}
+	return false
+}
+
+func isReadCommand(command string) bool {
+	for _, c := range readCommands {
+		if strings.EqualFold(c, command) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var TOKENIZE_SQL_SAMPLES = []TestInput[string, []string]{
+	{Input: "SELECT * FROM Asset WHERE id = $1", Correct: []string{"SELECT", "*", "FROM", "Asset", "WHERE", "id", "=", "$1"}},
+	{Input: "SELECT 'a;b', \"x\"\"y\" -- ; DROP\n/* ; /* nested */ */ FROM t", Correct: []string{"SELECT", "'a;b'", ",", `"x""y"`, "FROM", "t"}},
+	{Input: "SELECT E'it\\'s', $tag$ ; $$ $tag$, $$x$$, 1.5e3", Correct: []string{"SELECT", `E'it\'s'`, ",", "$tag$ ; $$ $tag$", ",", "$$x$$", ",", "1.5e3"}},
+	{Input: "SELECT (1) FROM t;", Correct: []string{"SELECT", "(", "1", ")", "FROM", "t", ";"}},
+}
+
+func TestTokenizeSQLFunc(t *testing.T) {
+	for _, s := range TOKENIZE_SQL_SAMPLES {
+		tokens, err := TokenizeSQL(s.Input)
+		if err != nil {
+			t.Errorf("error tokenizing %q: %v", s.Input, err)
+			continue
+		}
+
+		values := make([]string, len(tokens))
+		for i, token := range tokens {
+			values[i] = token.Value
+		}
+		if !reflect.DeepEqual(values, s.Correct) {
+			t.Errorf("wrong tokens of %q, wanted %q, given %q", s.Input, s.Correct, values)
+		}
+	}
+}
+
+var TOKENIZE_SQL_ERROR_SAMPLES = []TestInput[string, error]{
+	{Input: "SELECT 'unterminated", Correct: ErrUnterminatedSQL},
+	{Input: `SELECT "unterminated`, Correct: ErrUnterminatedSQL},
+	{Input: "SELECT 1 /* unterminated", Correct: ErrUnterminatedSQL},
+	{Input: "SELECT $x$ unterminated", Correct: ErrUnterminatedSQL},
+	{Input: "SELECT (1", Correct: ErrUnbalancedSQLParens},
+	{Input: "SELECT 1) UNION (SELECT 2", Correct: ErrUnbalancedSQLParens},
+}
+
+func TestTokenizeSQLErrorsFunc(t *testing.T) {
+	for _, s := range TOKENIZE_SQL_ERROR_SAMPLES {
+		if _, err := TokenizeSQL(s.Input); !errors.Is(err, s.Correct) {
+			t.Errorf("wrong error tokenizing %q, wanted %v, given %v", s.Input, s.Correct, err)
+		}
+	}
+}
+
+func TestSplitSQLStatementsFunc(t *testing.T) {
+	statements, err := SplitSQLStatements("CREATE TABLE t (\n\tid INT\n);\n;\nCREATE INDEX t_idx ON t (id);\nCOMMENT ON TABLE t IS 'a; b'")
+	if err != nil {
+		t.Fatalf("error splitting the statements: %v", err)
+	}
+
+	correct := []string{"CREATE TABLE t (\n\tid INT\n)", "CREATE INDEX t_idx ON t (id)", "COMMENT ON TABLE t IS 'a; b'"}
+	if !reflect.DeepEqual(statements, correct) {
+		t.Errorf("wrong statements, wanted %q, given %q", correct, statements)
+	}
+}
+
+var CLASSIFY_SQL_SAMPLES = []TestInput[string, SQLStatement]{
+	{Input: "SELECT * FROM Price", Correct: SQLStatement{Command: "SELECT", Kind: StatementRead}},
+	{Input: "\n\tselect id FROM Asset WHERE ticker = 'UPDATE';", Correct: SQLStatement{Command: "SELECT", Kind: StatementRead}},
+	{Input: "(SELECT 1) UNION (SELECT 2)", Correct: SQLStatement{Command: "SELECT", Kind: StatementRead}},
+	{Input: "SELECT * FROM Asset FOR UPDATE", Correct: SQLStatement{Command: "SELECT", Kind: StatementRead, Locking: true}},
+	{Input: "SELECT * INTO backup FROM Asset", Correct: SQLStatement{Command: "SELECT", Kind: StatementWrite}},
+	{Input: "WITH last AS (SELECT MAX(id) AS id FROM Price) SELECT * FROM Price, last", Correct: SQLStatement{Command: "SELECT", Kind: StatementRead}},
+	{Input: "WITH gone AS (DELETE FROM Price RETURNING id) SELECT COUNT(*) FROM gone", Correct: SQLStatement{Command: "SELECT", Kind: StatementWrite}},
+	{Input: "WITH ids AS (SELECT id FROM Price) DELETE FROM Price WHERE id IN (SELECT id FROM ids)", Correct: SQLStatement{Command: "DELETE", Kind: StatementWrite}},
+	{Input: "INSERT INTO Asset SELECT * FROM Asset", Correct: SQLStatement{Command: "INSERT", Kind: StatementWrite}},
+	{Input: "REFRESH MATERIALIZED VIEW latest_price", Correct: SQLStatement{Command: "REFRESH"}},
+}
+
+func TestClassifySQLFunc(t *testing.T) {
+	for _, s := range CLASSIFY_SQL_SAMPLES {
+		statement, err := ClassifySQL(s.Input)
+		if err != nil || statement != s.Correct {
+			t.Errorf("wrong classification of %q, wanted %+v, given %+v: %v", s.Input, s.Correct, statement, err)
+		}
+	}
+
+	if _, err := ClassifySQL("SELECT 1; DROP TABLE asset"); !errors.Is(err, ErrMultipleStatements) {
+		t.Errorf("multiple statements have been classified, given: %v", err)
+	}
+	if _, err := ClassifySQL(" ; -- nothing"); !errors.Is(err, ErrEmptyStatement) {
+		t.Errorf("empty statement has been classified, given: %v", err)
+	}
+}
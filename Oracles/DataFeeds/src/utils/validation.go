@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"reflect"
-	"regexp"
+	"time"
 
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 )
@@ -66,7 +68,32 @@ func ValidateTimestampStruct(t reflect.Type) bool {
 	return types.TIMESTAMP.Kind() == t.Kind() && types.TIMESTAMP.PkgPath() == t.PkgPath() && BaseTypeName(types.TIMESTAMP) == BaseTypeName(t)
 }
 
-// Takes a string and checks its validity as a query
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// Checks whether a struct type is stored as a single column value rather
+// than being a nested table: time.Time and the types implementing
+// driver.Valuer or sql.Scanner, such as types.Decimal. Custom structs are not
+//
+// Parameters:
+//   - t:		the reflect type
+//
+// Returns:
+//   - bool:	if the type is a value struct or not
+func ValidateValueStruct(t reflect.Type) bool {
+	if !ValidateStruct(t) || ValidateCustomStruct(t) {
+		return false
+	}
+
+	return t == timeType || t.Implements(valuerType) || reflect.PointerTo(t).Implements(scannerType)
+}
+
+// Takes a string and checks its validity as a query, it must be a single
+// statement modifying data or schema: INSERT, UPDATE, DELETE, MERGE, CREATE,
+// DROP, ALTER, TRUNCATE, REPLACE, GRANT or REVOKE
 //
 // Parameters:
 //   - query:	the query string
@@ -74,11 +101,13 @@ func ValidateTimestampStruct(t reflect.Type) bool {
 // Returns:
 //   - bool:	if the query is valid or not
 func ValidateQuery(query string) bool {
-	sqlRegex := regexp.MustCompile(`(INSERT|UPDATE|DELETE|CREATE|DROP|ALTER|TRUNCATE|REPLACE|GRANT|REVOKE).*`)
-	return sqlRegex.MatchString(query)
+	statement, err := ClassifySQL(query)
+	return err == nil && statement.Kind == StatementWrite
 }
 
-// Takes a string and checks its validity as a query with a result
+// Takes a string and checks its validity as a query with a result, it must
+// be a single statement only reading: SELECT, VALUES, TABLE or a WITH query
+// whose CTEs don't modify data
 //
 // Parameters:
 //   - query:	the query string
@@ -86,6 +115,6 @@ func ValidateQuery(query string) bool {
 // Returns:
 //   - bool:	if the query is valid or not
 func ValidateQueryWithResult(query string) bool {
-	sqlRegex := regexp.MustCompile(`SELECT.*`)
-	return sqlRegex.MatchString(query)
+	statement, err := ClassifySQL(query)
+	return err == nil && statement.Kind == StatementRead
 }
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"database/sql"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/0xPuddi/Exotic-Lend/Oracles/DataFeeds/types"
 )
@@ -128,6 +130,27 @@ func TestValidateStruct(t *testing.T) {
 	}
 }
 
+// Check if stored as a single column value
+var CHECK_VALUE_STRUCT_SAMPLES = []TestInput[any, bool]{
+	{Input: time.Time{}, Correct: true},
+	{Input: types.Decimal{}, Correct: true},
+	{Input: sql.NullString{}, Correct: true},
+	{Input: types.Null[string]{}, Correct: false},
+	{Input: types.Timestamp{}, Correct: false},
+	{Input: types.Asset{}, Correct: false},
+	{Input: "string", Correct: false},
+}
+
+func TestValidateValueStructFunc(t *testing.T) {
+	for _, ps := range CHECK_VALUE_STRUCT_SAMPLES {
+		tps := reflect.TypeOf(ps.Input)
+
+		if ValidateValueStruct(tps) != ps.Correct {
+			t.Errorf("wrong value struct result: %v %v", tps, ps.Correct)
+		}
+	}
+}
+
 var VALIDATE_QUERY_SAMPLES = []TestInput[string, bool]{
 	{Input: "", Correct: false},
 	// Checks for whitespace
@@ -137,6 +160,9 @@ var VALIDATE_QUERY_SAMPLES = []TestInput[string, bool]{
 	{Input: "test", Correct: false},
 	{Input: "NOTVALID", Correct: false},
 	{Input: "SELECT NOT NULL", Correct: false},
+	{Input: "SELECT * FROM Asset WHERE source = 'UPDATE'", Correct: false},
+	{Input: "DELETE FROM Asset WHERE id = 1; DROP TABLE Asset", Correct: false},
+	{Input: "-- comment\nDELETE FROM Asset WHERE id = 1;", Correct: true},
 }
 
 func TestValidateQueryFunc(t *testing.T) {
@@ -159,6 +185,10 @@ var VALIDATE_QUERY_WITH_RESULT_SAMPLES = []TestInput[string, bool]{
 	{Input: "test", Correct: false},
 	{Input: "NOTVALID", Correct: false},
 	{Input: "SELECT * FROM Asset", Correct: true},
+	{Input: "SELECT 1; DROP TABLE asset", Correct: false},
+	{Input: "SELECT 1; -- DROP TABLE asset", Correct: true},
+	{Input: "WITH d AS (DELETE FROM Asset RETURNING id) SELECT * FROM d", Correct: false},
+	{Input: "SELECT * INTO backup FROM Asset", Correct: false},
 }
 
 func TestValidateQueryWithResultFunc(t *testing.T) {